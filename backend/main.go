@@ -3,13 +3,25 @@ package main
 import (
 	"database/sql"
 	"log"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/finsights-ai/backend/packages/alerts"
+	"github.com/finsights-ai/backend/packages/auth"
 	"github.com/finsights-ai/backend/packages/db"
 	"github.com/finsights-ai/backend/packages/dotenv"
+	"github.com/finsights-ai/backend/packages/eodhd"
 	httphandlers "github.com/finsights-ai/backend/packages/http"
+	"github.com/finsights-ai/backend/packages/macro"
+	"github.com/finsights-ai/backend/packages/news"
+	"github.com/finsights-ai/backend/packages/portfolio"
+	"github.com/finsights-ai/backend/packages/scoring"
+	"github.com/finsights-ai/backend/packages/screener"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -17,8 +29,8 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -59,14 +71,164 @@ func main() {
 		log.Fatal("Failed to insert sample data:", err)
 	}
 
+	// Bond yields may be loaded from a CSV file instead of EODHD
+	if path := os.Getenv("MACRO_SERIES_CSV"); path != "" {
+		if _, err := macro.Sync(dbConn, macro.CSVSource{Path: path}, macro.BondYieldSeries, time.Now()); err != nil {
+			log.Println("Failed to load macro series:", err)
+		}
+	}
+
+	// Peer-relative metrics are derived from fundamentals
+	if err := screener.UpdatePeerMetrics(dbConn); err != nil {
+		log.Println("Failed to update peer metrics:", err)
+	}
+
+	// The DCF valuation's rates and growth stages may be configured with a
+	// JSON file
+	if path := os.Getenv("DCF_PARAMS"); path != "" {
+		params, err := screener.LoadDCFParams(path)
+		if err != nil {
+			log.Fatal("Failed to load DCF parameters:", err)
+		}
+		if err := screener.RegisterValuationModel(screener.DCFModel{Params: params}); err != nil {
+			log.Fatal("Failed to configure the DCF model:", err)
+		}
+	}
+
+	// News is scored with a financial word lexicon unless EODHD's own
+	// sentiment is preferred
+	if os.Getenv("NEWS_SCORER") == "eodhd" {
+		screener.SetNewsScorer(news.ProviderScorer{})
+	}
+
+	// Scoring models may be replaced with a JSON file; each model's score
+	// becomes a screener field
+	scoringModels := scoring.DefaultModels()
+	if path := os.Getenv("SCORING_MODELS"); path != "" {
+		scoringModels, err = scoring.LoadModels(path)
+		if err != nil {
+			log.Fatal("Failed to load scoring models:", err)
+		}
+	}
+	if err := scoring.RegisterFields(scoringModels); err != nil {
+		log.Fatal("Failed to register scoring models:", err)
+	}
+	if err := scoring.Update(dbConn, scoringModels); err != nil {
+		log.Println("Failed to update scores:", err)
+	}
+
+	if err := auth.DeleteExpiredSessions(dbConn); err != nil {
+		log.Println("Failed to delete expired sessions:", err)
+	}
+
 	// Initialize database screener client
 	screenerClient := httphandlers.NewDatabaseScreenerClient(dbConn)
+	stocksClient := httphandlers.NewDatabaseStocksClient(dbConn)
+
+	screensClient := httphandlers.NewDatabaseScreensClient(dbConn)
+	watchlistsClient := httphandlers.NewDatabaseWatchlistsClient(dbConn)
+
+	// EODHD is optional; without a token, dividend syncing and the nightly
+	// update are unavailable
+	var dividendSource portfolio.DividendSource
+	var eodhdClient *eodhd.Client
+	if token := os.Getenv("EODHD_API_TOKEN"); token != "" {
+		cachePath := os.Getenv("EODHD_CACHE_PATH")
+		if cachePath == "" {
+			cachePath = "./eodhd-cache"
+		}
+		eodhdClient, err = eodhd.NewClient(token, cachePath)
+		if err != nil {
+			log.Fatal("Failed to create EODHD client:", err)
+		}
+		dividendSource = eodhdClient
+	}
+
+	// Alerts are evaluated after each nightly update. E-mail is delivered
+	// only when an SMTP relay is configured.
+	dispatcher := alerts.NewDispatcher().
+		Register(alerts.ChannelInbox, alerts.NewInboxNotifier(dbConn)).
+		Register(alerts.ChannelWebhook, alerts.NewWebhookNotifier())
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		var smtpAuth smtp.Auth
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			host, _, _ := net.SplitHostPort(addr)
+			smtpAuth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		dispatcher.Register(alerts.ChannelEmail, alerts.NewSMTPNotifier(addr, os.Getenv("SMTP_FROM"), smtpAuth))
+	}
+
+	// The nightly update refreshes the tickers listed in TICKERS, or every
+	// stored ticker, at NIGHTLY_UPDATE_AT (HH:MM, local time)
+	if eodhdClient != nil {
+		hour, minute := 22, 30
+		if at := os.Getenv("NIGHTLY_UPDATE_AT"); at != "" {
+			t, err := time.Parse("15:04", at)
+			if err != nil {
+				log.Fatal("Invalid NIGHTLY_UPDATE_AT:", err)
+			}
+			hour, minute = t.Hour(), t.Minute()
+		}
+		tickers := func() ([]string, error) { return screener.StoredTickers(dbConn) }
+		if list := os.Getenv("TICKERS"); list != "" {
+			tickers = func() ([]string, error) { return strings.Split(list, ","), nil }
+		}
+		// Peer metrics and scores are recomputed from the fresh fundamentals
+		// before the alerts that may screen on them
+		go screener.ScheduleNightlyUpdate(dbConn, eodhdClient, tickers, hour, minute,
+			screener.UpdatePeerMetrics,
+			scoring.UpdateHook(scoringModels),
+			alerts.UpdateHook(dispatcher),
+		)
+	} else {
+		log.Println("EODHD_API_TOKEN is not set; the nightly update is disabled")
+	}
+	portfolioClient := httphandlers.NewDatabasePortfolioClient(dbConn, dividendSource)
+	alertsClient := httphandlers.NewDatabaseAlertsClient(dbConn)
+	authClient := httphandlers.NewDatabaseAuthClient(dbConn)
 
 	// Setup HTTP handlers
 	screenerHandler := httphandlers.NewScreenerHandler(screenerClient)
+	stocksHandler := httphandlers.NewStocksHandler(stocksClient)
+	screensHandler := httphandlers.NewScreensHandler(screensClient, screenerClient)
+	watchlistsHandler := httphandlers.NewWatchlistsHandler(watchlistsClient)
+	portfolioHandler := httphandlers.NewPortfolioHandler(portfolioClient)
+	alertsHandler := httphandlers.NewAlertsHandler(alertsClient, dispatcher)
+	authHandler := httphandlers.NewAuthHandler(authClient)
+
+	rateLimitPlans := httphandlers.DefaultPlans()
+	if config := os.Getenv("RATE_LIMIT_PLANS"); config != "" {
+		rateLimitPlans, err = httphandlers.ParsePlans(config)
+		if err != nil {
+			log.Fatal("Failed to load rate limit plans:", err)
+		}
+	}
+	rateLimiter := httphandlers.NewRateLimiter(rateLimitPlans)
+
+	// Everything except registration, login and shared screen links requires a
+	// session token or API key. Public routes are rate limited per IP, protected
+	// routes per credential and user plan, and failed authentication per IP
+	// before any credential is looked up.
+	requireAuth := httphandlers.RequireAuth(authClient)
+	public := func(next http.HandlerFunc) http.HandlerFunc {
+		return corsMiddleware(rateLimiter.Middleware(next))
+	}
+	protected := func(next http.HandlerFunc) http.HandlerFunc {
+		return corsMiddleware(rateLimiter.GuardAuth(requireAuth(rateLimiter.Middleware(next))))
+	}
 
 	// TODO: Only in development: Setup routes with CORS middleware
-	http.HandleFunc("/api/screener", corsMiddleware(screenerHandler.GetScreenerData))
+	// Routes are registered through the router so that /api/openapi.json
+	// documents exactly what is served
+	router := httphandlers.NewRouter(http.DefaultServeMux, public, protected)
+	authHandler.RegisterRoutes(router)
+	screenerHandler.RegisterRoutes(router)
+	stocksHandler.RegisterRoutes(router)
+	screensHandler.RegisterRoutes(router)
+	watchlistsHandler.RegisterRoutes(router)
+	portfolioHandler.RegisterRoutes(router)
+	alertsHandler.RegisterRoutes(router)
+	router.RegisterRoutes()
 
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
@@ -0,0 +1,303 @@
+package alerts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/screener"
+	"github.com/finsights-ai/backend/packages/screens"
+)
+
+var (
+	// ErrNotFound is returned when an alert does not exist
+	ErrNotFound = errors.New("alert not found")
+	// ErrNotificationNotFound is returned when an inbox notification does not exist
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// Kind is the type of condition an alert watches
+type Kind string
+
+const (
+	// MetricAlert fires when a ticker's metric crosses a threshold, e.g. "AAPL close < 140"
+	MetricAlert Kind = "metric"
+	// ScreenAlert fires when tickers newly match a saved screen
+	ScreenAlert Kind = "screen"
+)
+
+// Delivery channels with a built-in notifier
+const (
+	ChannelInbox   = "inbox"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+var metricOperators = []string{"=", "!=", ">", "<", ">=", "<="}
+
+// Alert is a user-defined condition evaluated after each nightly update
+type Alert struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"-"`
+	Name     string `json:"name"`
+	Kind     Kind   `json:"kind"`
+	Ticker   string `json:"ticker,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    any    `json:"value,omitempty"`
+	ScreenID int64  `json:"screen_id,omitempty"`
+	// Channel selects the notifier; Target is the webhook URL or e-mail address
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+	Active  bool   `json:"active"`
+	// Triggered is set while a metric condition holds so it only fires once per crossing
+	Triggered       bool    `json:"triggered"`
+	LastFiredAt     *string `json:"last_fired_at"`
+	LastEvaluatedAt *string `json:"last_evaluated_at"`
+	CreatedAt       string  `json:"created_at"`
+}
+
+// Event is a record of an alert firing and the outcome of its delivery
+type Event struct {
+	ID        int64    `json:"id"`
+	AlertID   int64    `json:"alert_id"`
+	FiredAt   string   `json:"fired_at"`
+	Message   string   `json:"message"`
+	Tickers   []string `json:"tickers"`
+	Delivered bool     `json:"delivered"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Validate checks that the alert is complete for its kind and channel
+func (a *Alert) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	switch a.Kind {
+	case MetricAlert:
+		a.Ticker = strings.ToUpper(strings.TrimSpace(a.Ticker))
+		if a.Ticker == "" {
+			return errors.New("ticker is required for metric alerts")
+		}
+		if a.Field == "" {
+			return errors.New("field is required for metric alerts")
+		}
+		if !slices.Contains(metricOperators, a.Operator) {
+			return fmt.Errorf("unsupported operator %q", a.Operator)
+		}
+		// The field, its operators and the value's type are checked as the
+		// screener checks filter conditions, so a saved alert can always run
+		condition := screener.FilterCondition{Field: a.Field, Operator: a.Operator, Value: a.Value}
+		if err := (screener.ScreenerFilter{Conditions: []screener.FilterCondition{condition}}).Validate(); err != nil {
+			var fe *screener.FilterError
+			if errors.As(err, &fe) {
+				return errors.New(fe.Message)
+			}
+			return err
+		}
+	case ScreenAlert:
+		if a.ScreenID < 1 {
+			return errors.New("screen_id is required for screen alerts")
+		}
+	default:
+		return fmt.Errorf("unknown alert kind %q", a.Kind)
+	}
+
+	switch a.Channel {
+	case ChannelInbox:
+	case ChannelWebhook:
+		u, err := url.Parse(a.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return errors.New("target must be an http(s) URL for webhook alerts")
+		}
+		// Hostnames are checked again once resolved, when the webhook is sent
+		ip := net.ParseIP(u.Hostname())
+		if strings.EqualFold(u.Hostname(), "localhost") || (ip != nil && !publicAddress(ip)) {
+			return errors.New("target must be a public URL for webhook alerts")
+		}
+	case ChannelEmail:
+		if !strings.Contains(a.Target, "@") {
+			return errors.New("target must be an e-mail address for email alerts")
+		}
+	default:
+		return fmt.Errorf("unknown channel %q", a.Channel)
+	}
+
+	return nil
+}
+
+// CreateAlert stores an alert owned by userID; screen alerts must reference one
+// of the user's own screens
+func CreateAlert(db *sql.DB, userID int64, a Alert) (Alert, error) {
+	if a.Kind == ScreenAlert {
+		if _, err := screens.GetScreen(db, userID, a.ScreenID); err != nil {
+			return Alert{}, err
+		}
+	}
+
+	value, err := json.Marshal(a.Value)
+	if err != nil {
+		return Alert{}, err
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO alerts (user_id, name, kind, ticker, field, operator, value, screen_id, channel, target, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, datetime('now'))`,
+		userID, a.Name, a.Kind, a.Ticker, a.Field, a.Operator, string(value), a.ScreenID, a.Channel, a.Target,
+	)
+	if err != nil {
+		return Alert{}, fmt.Errorf("failed to insert alert: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Alert{}, err
+	}
+
+	return GetAlert(db, userID, id)
+}
+
+func GetAlert(db *sql.DB, userID, id int64) (Alert, error) {
+	return scanAlert(db.QueryRow(selectAlert+" WHERE id = ? AND user_id = ?", id, userID))
+}
+
+func ListAlerts(db *sql.DB, userID int64) ([]Alert, error) {
+	return queryAlerts(db, selectAlert+" WHERE user_id = ? ORDER BY id ASC", userID)
+}
+
+// SetActive pauses or resumes an alert
+func SetActive(db *sql.DB, userID, id int64, active bool) (Alert, error) {
+	res, err := db.Exec(`UPDATE alerts SET active = ? WHERE id = ? AND user_id = ?`, active, id, userID)
+	if err != nil {
+		return Alert{}, fmt.Errorf("failed to update alert: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Alert{}, ErrNotFound
+	}
+	return GetAlert(db, userID, id)
+}
+
+// DeleteAlert removes an alert together with its events and tracked screen matches
+func DeleteAlert(db *sql.DB, userID, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM alerts WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	for _, table := range []string{"alert_events", "alert_matches"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE alert_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListEvents returns the firing history of an alert, newest first
+func ListEvents(db *sql.DB, userID, alertID int64) ([]Event, error) {
+	if _, err := GetAlert(db, userID, alertID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, alert_id, fired_at, message, tickers, delivered, error
+		FROM alert_events
+		WHERE alert_id = ?
+		ORDER BY id DESC`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		var tickers string
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.FiredAt, &e.Message, &tickers, &e.Delivered, &e.Error); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tickers), &e.Tickers); err != nil {
+			return nil, fmt.Errorf("invalid tickers for event %d: %w", e.ID, err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return events, nil
+}
+
+const selectAlert = `
+	SELECT id, user_id, name, kind, ticker, field, operator, value, screen_id, channel, target,
+	       active, triggered, last_fired_at, last_evaluated_at, created_at
+	FROM alerts`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (Alert, error) {
+	var a Alert
+	var value string
+	var lastFired, lastEvaluated sql.NullString
+	err := row.Scan(&a.ID, &a.UserID, &a.Name, &a.Kind, &a.Ticker, &a.Field, &a.Operator, &value, &a.ScreenID,
+		&a.Channel, &a.Target, &a.Active, &a.Triggered, &lastFired, &lastEvaluated, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Alert{}, ErrNotFound
+	}
+	if err != nil {
+		return Alert{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(value), &a.Value); err != nil {
+		return Alert{}, fmt.Errorf("invalid value for alert %d: %w", a.ID, err)
+	}
+	if lastFired.Valid {
+		a.LastFiredAt = &lastFired.String
+	}
+	if lastEvaluated.Valid {
+		a.LastEvaluatedAt = &lastEvaluated.String
+	}
+
+	return a, nil
+}
+
+func queryAlerts(db *sql.DB, query string, args ...any) ([]Alert, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []Alert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return alerts, nil
+}
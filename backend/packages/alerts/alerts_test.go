@@ -0,0 +1,399 @@
+package alerts
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/db"
+	"github.com/finsights-ai/backend/packages/screens"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testUserID = 1
+
+func setupTestDB(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := db.MigrateDatabaseFromFile(conn, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	testData := `
+		INSERT INTO fundamentals (ticker, pe_ratio, roe, earnings_outlook, dividend_yield, dividend_growth_5y, intrinsic_value, margin_of_safety) VALUES
+		('AAPL', 14.5, 0.25, 'positive', 0.005, 0.08, 180.50, 0.25),
+		('KO', 9.7, 0.16, 'positive', 0.045, 0.08, 65.0, 0.25),
+		('PFE', 7.8, 0.12, 'positive', 0.055, 0.10, 55.0, 0.30);
+
+		INSERT INTO prices (ticker, date, close, sma50, sma200) VALUES
+		('AAPL', '2024-01-15', 150.25, 145.80, 140.30),
+		('KO', '2024-01-15', 48.75, 52.20, 55.50),
+		('PFE', '2024-01-15', 42.15, 45.20, 48.90);
+	`
+	if _, err := conn.Exec(testData); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	return conn
+}
+
+// webhookStandIn records every notification POSTed to it
+type webhookStandIn struct {
+	mu       sync.Mutex
+	received []Notification
+}
+
+func (s *webhookStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var n Notification
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.received = append(s.received, n)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// localWebhookNotifier delivers to the stand-ins on loopback, which
+// NewWebhookNotifier refuses
+func localWebhookNotifier() *WebhookNotifier {
+	return newWebhookNotifier(func(net.IP) bool { return true })
+}
+
+func (s *webhookStandIn) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.received)
+}
+
+// smtpStandIn is a minimal SMTP server that accepts every message
+type smtpStandIn struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &smtpStandIn{listener: l}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStandIn) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func TestMetricAlertFiresOncePerCrossing(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	hook := &webhookStandIn{}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	dispatcher := NewDispatcher().Register(ChannelWebhook, localWebhookNotifier())
+
+	a, err := CreateAlert(conn, testUserID, Alert{
+		Name: "Cheap Apple", Kind: MetricAlert, Ticker: "AAPL", Field: "close", Operator: "<", Value: 160.0,
+		Channel: ChannelWebhook, Target: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlert failed: %v", err)
+	}
+
+	for range 2 {
+		if err := Evaluate(conn, dispatcher); err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+	}
+	if hook.count() != 1 {
+		t.Fatalf("Expected exactly 1 webhook delivery while the condition holds, got %d", hook.count())
+	}
+	if !strings.Contains(hook.received[0].Message, "150.25") {
+		t.Errorf("Expected message to contain the current close, got %q", hook.received[0].Message)
+	}
+
+	// Condition stops holding, alert re-arms, then fires again on the next crossing
+	if _, err := conn.Exec(`UPDATE prices SET close = 170 WHERE ticker = 'AAPL'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Evaluate(conn, dispatcher); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE prices SET close = 155 WHERE ticker = 'AAPL'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Evaluate(conn, dispatcher); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if hook.count() != 2 {
+		t.Errorf("Expected a second delivery after re-arming, got %d", hook.count())
+	}
+
+	events, err := ListEvents(conn, testUserID, a.ID)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 || !events[0].Delivered {
+		t.Errorf("Expected 2 delivered events, got %+v", events)
+	}
+}
+
+func TestWebhookStaysOutOfTheServersNetwork(t *testing.T) {
+	hook := &webhookStandIn{}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	// The stand-in listens on loopback
+	if err := NewWebhookNotifier().Notify(server.URL, Notification{}); err == nil || hook.count() != 0 {
+		t.Errorf("Expected loopback refused, got %v with %d deliveries", err, hook.count())
+	}
+
+	// A public endpoint can't redirect the POST elsewhere
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+	if err := localWebhookNotifier().Notify(redirect.URL, Notification{}); err == nil || hook.count() != 0 {
+		t.Errorf("Expected the redirect not followed, got %v with %d deliveries", err, hook.count())
+	}
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher().Register(ChannelWebhook, localWebhookNotifier())
+
+	a, err := CreateAlert(conn, testUserID, Alert{
+		Name: "KO yield", Kind: MetricAlert, Ticker: "KO", Field: "dividend_yield", Operator: ">", Value: 0.04,
+		Channel: ChannelWebhook, Target: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlert failed: %v", err)
+	}
+
+	if err := Evaluate(conn, dispatcher); err == nil {
+		t.Fatal("Expected delivery error")
+	}
+
+	failing = false
+	if err := Evaluate(conn, dispatcher); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+
+	events, err := ListEvents(conn, testUserID, a.ID)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[1].Delivered || !events[0].Delivered {
+		t.Errorf("Expected a failed then a delivered event, got %+v", events)
+	}
+}
+
+func TestScreenAlertReportsNewMatchesByEmail(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	mail := newSMTPStandIn(t)
+	dispatcher := NewDispatcher().
+		Register(ChannelEmail, NewSMTPNotifier(mail.listener.Addr().String(), "alerts@finsights.local", nil)).
+		Register(ChannelInbox, NewInboxNotifier(conn))
+
+	screen, err := screens.CreateScreen(conn, testUserID, screens.Screen{
+		Name:    "Cheap",
+		Filters: json.RawMessage(`[["pe_ratio","<",10]]`),
+	})
+	if err != nil {
+		t.Fatalf("CreateScreen failed: %v", err)
+	}
+
+	for _, channel := range []struct{ channel, target string }{
+		{ChannelEmail, "analyst@example.com"},
+		{ChannelInbox, ""},
+	} {
+		if _, err := CreateAlert(conn, testUserID, Alert{
+			Name: "New cheap stocks", Kind: ScreenAlert, ScreenID: screen.ID,
+			Channel: channel.channel, Target: channel.target,
+		}); err != nil {
+			t.Fatalf("CreateAlert failed: %v", err)
+		}
+	}
+
+	// Baseline: KO and PFE already match and are not reported
+	if err := Evaluate(conn, dispatcher); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(mail.received()) != 0 {
+		t.Fatalf("Expected no e-mail for the baseline, got %d", len(mail.received()))
+	}
+
+	if _, err := conn.Exec(`UPDATE fundamentals SET pe_ratio = 9 WHERE ticker = 'AAPL'`); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := Evaluate(conn, dispatcher); err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+	}
+
+	messages := mail.received()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 e-mail, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], "To: analyst@example.com") || !strings.Contains(messages[0], "AAPL") {
+		t.Errorf("Unexpected e-mail content: %q", messages[0])
+	}
+
+	inbox, err := ListInbox(conn, testUserID, true)
+	if err != nil {
+		t.Fatalf("ListInbox failed: %v", err)
+	}
+	if len(inbox) != 1 || len(inbox[0].Tickers) != 1 || inbox[0].Tickers[0] != "AAPL" {
+		t.Errorf("Expected one inbox entry for AAPL, got %+v", inbox)
+	}
+}
+
+func TestScreenAlertDeactivatedWithItsScreen(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	dispatcher := NewDispatcher().Register(ChannelInbox, NewInboxNotifier(conn))
+
+	screen, err := screens.CreateScreen(conn, testUserID, screens.Screen{Name: "Cheap", Filters: json.RawMessage(`[["pe_ratio","<",10]]`)})
+	if err != nil {
+		t.Fatalf("CreateScreen failed: %v", err)
+	}
+	a, err := CreateAlert(conn, testUserID, Alert{Name: "Cheap", Kind: ScreenAlert, ScreenID: screen.ID, Channel: ChannelInbox})
+	if err != nil {
+		t.Fatalf("CreateAlert failed: %v", err)
+	}
+	if err := screens.DeleteScreen(conn, testUserID, screen.ID); err != nil {
+		t.Fatalf("DeleteScreen failed: %v", err)
+	}
+
+	// The orphaned alert is switched off instead of failing every night
+	for range 2 {
+		if err := Evaluate(conn, dispatcher); err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+	}
+	if a, err := GetAlert(conn, testUserID, a.ID); err != nil || a.Active {
+		t.Errorf("Expected the alert deactivated, got %v, %+v", err, a)
+	}
+}
+
+func TestAlertValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		alert       Alert
+		expectError bool
+	}{
+		{"valid metric", Alert{Name: "a", Kind: MetricAlert, Ticker: "ko", Field: "close", Operator: "<", Value: 40.0, Channel: ChannelInbox}, false},
+		{"valid screen", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelEmail, Target: "a@b.c"}, false},
+		{"unknown kind", Alert{Name: "a", Kind: "other", Channel: ChannelInbox}, true},
+		{"bad operator", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "close", Operator: "LIKE", Value: 1.0, Channel: ChannelInbox}, true},
+		{"unknown field", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "closee", Operator: ">", Value: 1.0, Channel: ChannelInbox}, true},
+		{"string on number field", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "close", Operator: ">", Value: "abc", Channel: ChannelInbox}, true},
+		{"number on string field", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "earnings_outlook", Operator: "=", Value: 1.0, Channel: ChannelInbox}, true},
+		{"string field", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "earnings_outlook", Operator: "!=", Value: "positive", Channel: ChannelInbox}, false},
+		{"computed field", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "price_vs_sma200", Operator: "<", Value: 1.0, Channel: ChannelInbox}, false},
+		{"eodhd alias", Alert{Name: "a", Kind: MetricAlert, Ticker: "KO", Field: "code", Operator: "=", Value: "KO", Channel: ChannelInbox}, false},
+		{"webhook without url", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "nope"}, true},
+		{"public webhook", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "https://hooks.example.com/x"}, false},
+		{"loopback webhook", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "http://127.0.0.1:8080/"}, true},
+		{"localhost webhook", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "http://localhost/"}, true},
+		{"metadata webhook", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "http://169.254.169.254/latest/meta-data"}, true},
+		{"private webhook", Alert{Name: "a", Kind: ScreenAlert, ScreenID: 1, Channel: ChannelWebhook, Target: "http://[fd00::1]/"}, true},
+		{"missing screen", Alert{Name: "a", Kind: ScreenAlert, Channel: ChannelInbox}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.alert.Validate()
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package alerts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/screener"
+	"github.com/finsights-ai/backend/packages/screens"
+)
+
+// UpdateHook returns a hook for screener.RunNightlyUpdate that evaluates all
+// alerts once fresh data has been ingested
+func UpdateHook(dispatcher *Dispatcher) screener.UpdateHook {
+	return func(db *sql.DB) error {
+		return Evaluate(db, dispatcher)
+	}
+}
+
+// Evaluate checks every active alert against the current screener data and
+// dispatches notifications. Metric alerts fire once when their condition starts
+// to hold and re-arm when it stops holding; screen alerts fire only for tickers
+// that did not match at the previous evaluation. A failed delivery leaves the
+// alert state untouched so it is retried on the next run.
+func Evaluate(db *sql.DB, dispatcher *Dispatcher) error {
+	active, err := queryAlerts(db, selectAlert+" WHERE active = 1 ORDER BY id ASC")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+
+	var errs []error
+	for _, a := range active {
+		var err error
+		switch a.Kind {
+		case MetricAlert:
+			err = evaluateMetric(db, dispatcher, a, now)
+		case ScreenAlert:
+			err = evaluateScreen(db, dispatcher, a, now)
+		}
+		if err != nil {
+			log.Printf("Error evaluating alert %d: %v\n", a.ID, err)
+			errs = append(errs, fmt.Errorf("alert %d: %w", a.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func evaluateMetric(db *sql.DB, dispatcher *Dispatcher, a Alert, now string) error {
+	filter := screener.NewFilterBuilder().
+		Ticker(a.Ticker).
+		AddCondition(a.Field, a.Operator, a.Value).
+		BuildWithPagination("ticker.asc", 1, 0)
+
+	results, err := screener.ScreenStocks(db, filter)
+	if err != nil {
+		return err
+	}
+	matched := len(results) > 0
+
+	switch {
+	case matched && !a.Triggered:
+		message := fmt.Sprintf("%s %s %s %v", a.Ticker, a.Field, a.Operator, a.Value)
+		if current, ok := fieldValue(results[0], a.Field); ok {
+			message += fmt.Sprintf(" (current: %v)", current)
+		}
+
+		n := Notification{
+			UserID:    a.UserID,
+			AlertID:   a.ID,
+			AlertName: a.Name,
+			Subject:   "Alert: " + a.Name,
+			Message:   message,
+			Tickers:   []string{a.Ticker},
+			FiredAt:   now,
+		}
+		if err := fire(db, dispatcher, a, n); err != nil {
+			return err
+		}
+		_, err = db.Exec(`
+			UPDATE alerts SET triggered = 1, last_fired_at = ?, last_evaluated_at = ? WHERE id = ?`,
+			now, now, a.ID)
+		return err
+
+	case !matched && a.Triggered:
+		_, err = db.Exec(`UPDATE alerts SET triggered = 0, last_evaluated_at = ? WHERE id = ?`, now, a.ID)
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE alerts SET last_evaluated_at = ? WHERE id = ?`, now, a.ID)
+	return err
+}
+
+func evaluateScreen(db *sql.DB, dispatcher *Dispatcher, a Alert, now string) error {
+	screen, err := screens.GetScreen(db, a.UserID, a.ScreenID)
+	if errors.Is(err, screens.ErrNotFound) {
+		// The screen was deleted, so the alert can never fire again
+		log.Printf("Deactivating alert %d: screen %d was deleted", a.ID, a.ScreenID)
+		_, err = db.Exec(`UPDATE alerts SET active = 0, last_evaluated_at = ? WHERE id = ?`, now, a.ID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	filter, err := screen.ScreenerFilter(0, 0)
+	if err != nil {
+		return err
+	}
+
+	results, err := screener.ScreenStocks(db, filter)
+	if err != nil {
+		return err
+	}
+
+	previous, err := previousMatches(db, a.ID)
+	if err != nil {
+		return err
+	}
+
+	current := make([]string, 0, len(results))
+	newMatches := []string{}
+	for _, r := range results {
+		current = append(current, r.Ticker)
+		if !previous[r.Ticker] {
+			newMatches = append(newMatches, r.Ticker)
+		}
+	}
+	sort.Strings(newMatches)
+
+	// The first evaluation only records a baseline; otherwise creating an alert
+	// would immediately report every current match as new
+	fired := false
+	if a.LastEvaluatedAt != nil && len(newMatches) > 0 {
+		n := Notification{
+			UserID:    a.UserID,
+			AlertID:   a.ID,
+			AlertName: a.Name,
+			Subject:   "Alert: " + a.Name,
+			Message:   fmt.Sprintf("New matches for screen %q: %s", screen.Name, strings.Join(newMatches, ", ")),
+			Tickers:   newMatches,
+			FiredAt:   now,
+		}
+		if err := fire(db, dispatcher, a, n); err != nil {
+			return err
+		}
+		fired = true
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM alert_matches WHERE alert_id = ?`, a.ID); err != nil {
+		return err
+	}
+	for _, ticker := range current {
+		if _, err := tx.Exec(`INSERT INTO alert_matches (alert_id, ticker) VALUES (?, ?)`, a.ID, ticker); err != nil {
+			return err
+		}
+	}
+
+	if fired {
+		_, err = tx.Exec(`UPDATE alerts SET last_fired_at = ?, last_evaluated_at = ? WHERE id = ?`, now, now, a.ID)
+	} else {
+		_, err = tx.Exec(`UPDATE alerts SET last_evaluated_at = ? WHERE id = ?`, now, a.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// fire dispatches a notification and records the delivery outcome as an event
+func fire(db *sql.DB, dispatcher *Dispatcher, a Alert, n Notification) error {
+	deliveryErr := dispatcher.Dispatch(a.Channel, a.Target, n)
+
+	errText := ""
+	if deliveryErr != nil {
+		errText = deliveryErr.Error()
+	}
+
+	tickers, err := json.Marshal(n.Tickers)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO alert_events (alert_id, fired_at, message, tickers, delivered, error)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ID, n.FiredAt, n.Message, string(tickers), deliveryErr == nil, errText,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record alert event: %w", err)
+	}
+
+	return deliveryErr
+}
+
+func previousMatches(db *sql.DB, alertID int64) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT ticker FROM alert_matches WHERE alert_id = ?`, alertID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := map[string]bool{}
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, err
+		}
+		matches[ticker] = true
+	}
+	return matches, rows.Err()
+}
+
+// fieldValue looks up a result column by its JSON name
+func fieldValue(result screener.ScreenerResult, field string) (any, bool) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, false
+	}
+	var values map[string]any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, false
+	}
+	v, ok := values[field]
+	return v, ok
+}
@@ -0,0 +1,244 @@
+package alerts
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Notification is the message delivered when an alert fires
+type Notification struct {
+	UserID    int64    `json:"-"`
+	AlertID   int64    `json:"alert_id"`
+	AlertName string   `json:"alert_name"`
+	Subject   string   `json:"subject"`
+	Message   string   `json:"message"`
+	Tickers   []string `json:"tickers"`
+	FiredAt   string   `json:"fired_at"`
+}
+
+// Notifier delivers a notification to a channel-specific target
+// (a URL for webhooks, an address for e-mail; ignored by the inbox)
+type Notifier interface {
+	Notify(target string, n Notification) error
+}
+
+// Dispatcher routes notifications to the notifier registered for an alert's channel
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{notifiers: map[string]Notifier{}}
+}
+
+// Register sets the notifier for a channel, replacing any previous one
+func (d *Dispatcher) Register(channel string, notifier Notifier) *Dispatcher {
+	d.notifiers[channel] = notifier
+	return d
+}
+
+// Handles reports whether a notifier is registered for channel
+func (d *Dispatcher) Handles(channel string) bool {
+	_, ok := d.notifiers[channel]
+	return ok
+}
+
+func (d *Dispatcher) Dispatch(channel, target string, n Notification) error {
+	notifier, ok := d.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("no notifier registered for channel %q", channel)
+	}
+	return notifier.Notify(target, n)
+}
+
+// InboxNotifier stores notifications in the database for display in the app
+type InboxNotifier struct {
+	db *sql.DB
+}
+
+func NewInboxNotifier(db *sql.DB) *InboxNotifier {
+	return &InboxNotifier{db: db}
+}
+
+func (n *InboxNotifier) Notify(_ string, msg Notification) error {
+	tickers, err := json.Marshal(msg.Tickers)
+	if err != nil {
+		return err
+	}
+	_, err = n.db.Exec(`
+		INSERT INTO notifications (user_id, alert_id, subject, message, tickers, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.UserID, msg.AlertID, msg.Subject, msg.Message, string(tickers), msg.FiredAt,
+	)
+	return err
+}
+
+// WebhookNotifier POSTs the notification as JSON to the alert's target URL.
+// Only public addresses are dialled and redirects aren't followed, so users
+// can't make the server call into its own network.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return newWebhookNotifier(publicAddress)
+}
+
+// newWebhookNotifier dials only the addresses allow accepts. They are checked
+// once resolved, so a hostname can't point past the check.
+func newWebhookNotifier(allow func(net.IP) bool) *WebhookNotifier {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allow(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &WebhookNotifier{client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// publicAddress reports whether ip is reachable from the internet at large.
+// Loopback, private, link-local (such as cloud metadata services) and
+// unspecified addresses belong to the server's own network.
+func publicAddress(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func (n *WebhookNotifier) Notify(target string, msg Notification) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// SMTPNotifier sends the notification as a plain-text e-mail
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier creates an e-mail notifier. addr is host:port; auth may be nil
+// for relays that do not require authentication.
+func NewSMTPNotifier(addr, from string, auth smtp.Auth) *SMTPNotifier {
+	return &SMTPNotifier{addr: addr, from: from, auth: auth}
+}
+
+func (n *SMTPNotifier) Notify(target string, msg Notification) error {
+	if strings.ContainsAny(target, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("invalid e-mail header value")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.from)
+	fmt.Fprintf(&body, "To: %s\r\n", target)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(msg.Message)
+	body.WriteString("\r\n")
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{target}, []byte(body.String())); err != nil {
+		return fmt.Errorf("sending e-mail failed: %w", err)
+	}
+	return nil
+}
+
+// InboxEntry is a notification in the in-app inbox
+type InboxEntry struct {
+	ID        int64    `json:"id"`
+	AlertID   int64    `json:"alert_id"`
+	Subject   string   `json:"subject"`
+	Message   string   `json:"message"`
+	Tickers   []string `json:"tickers"`
+	Read      bool     `json:"read"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// ListInbox returns the user's in-app notifications, newest first
+func ListInbox(db *sql.DB, userID int64, unreadOnly bool) ([]InboxEntry, error) {
+	query := `
+		SELECT id, alert_id, subject, message, tickers, read, created_at
+		FROM notifications
+		WHERE user_id = ?`
+	if unreadOnly {
+		query += " AND read = 0"
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []InboxEntry{}
+	for rows.Next() {
+		var e InboxEntry
+		var tickers string
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.Subject, &e.Message, &tickers, &e.Read, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tickers), &e.Tickers); err != nil {
+			return nil, fmt.Errorf("invalid tickers for notification %d: %w", e.ID, err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return entries, nil
+}
+
+// MarkRead marks an inbox notification as read
+func MarkRead(db *sql.DB, userID, id int64) error {
+	res, err := db.Exec(`UPDATE notifications SET read = 1 WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAPIKeyNotFound is returned when an API key does not exist for the user
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// apiKeyPrefix marks API keys so they can be told apart from session tokens
+const apiKeyPrefix = "fsk_"
+
+// APIKey is a long-lived credential for scripted access. The secret itself is
+// only returned once, when the key is created.
+type APIKey struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	Key        string  `json:"key,omitempty"`
+	LastUsedAt *string `json:"last_used_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// CreateAPIKey issues a new API key for the user. The returned key includes the
+// secret, which is not stored and cannot be retrieved later.
+func CreateAPIKey(db *sql.DB, userID int64, name string) (APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return APIKey{}, errors.New("name is required")
+	}
+
+	key, err := newToken(apiKeyPrefix)
+	if err != nil {
+		return APIKey{}, err
+	}
+	prefix := key[:len(apiKeyPrefix)+8]
+
+	res, err := db.Exec(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at)
+		VALUES (?, ?, ?, ?, datetime('now'))`,
+		userID, name, prefix, hashToken(key),
+	)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	created, err := getAPIKey(db, userID, id)
+	if err != nil {
+		return APIKey{}, err
+	}
+	created.Key = key
+	return created, nil
+}
+
+// ListAPIKeys returns the user's API keys without their secrets
+func ListAPIKeys(db *sql.DB, userID int64) ([]APIKey, error) {
+	rows, err := db.Query(selectAPIKey+" WHERE user_id = ? ORDER BY id ASC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey revokes one of the user's API keys
+func DeleteAPIKey(db *sql.DB, userID, id int64) error {
+	res, err := db.Exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// UserForToken resolves a bearer credential, either a session token or an API
+// key, to its user
+func UserForToken(db *sql.DB, token string) (User, error) {
+	switch {
+	case strings.HasPrefix(token, sessionPrefix):
+		return userForSession(db, token)
+	case strings.HasPrefix(token, apiKeyPrefix):
+		return userForAPIKey(db, token)
+	}
+	return User{}, ErrInvalidToken
+}
+
+func userForAPIKey(db *sql.DB, key string) (User, error) {
+	var u User
+	var keyID int64
+	err := db.QueryRow(`
+		SELECT k.id, u.id, u.email, u.plan, u.created_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = ?`, hashToken(key),
+	).Scan(&keyID, &u.ID, &u.Email, &u.Plan, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrInvalidToken
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE api_keys SET last_used_at = datetime('now') WHERE id = ?`, keyID); err != nil {
+		return User{}, fmt.Errorf("failed to update api key: %w", err)
+	}
+	return u, nil
+}
+
+const selectAPIKey = `
+	SELECT id, name, prefix, last_used_at, created_at
+	FROM api_keys`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func getAPIKey(db *sql.DB, userID, id int64) (APIKey, error) {
+	return scanAPIKey(db.QueryRow(selectAPIKey+" WHERE id = ? AND user_id = ?", id, userID))
+}
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var k APIKey
+	var lastUsed sql.NullString
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &lastUsed, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.String
+	}
+	return k, nil
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a user does not exist
+	ErrNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when registering an e-mail address that already has an account
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrInvalidCredentials is returned when an e-mail/password pair does not match
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidToken is returned for unknown or expired session tokens and API keys
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// MinPasswordLength is the shortest password accepted at registration
+const MinPasswordLength = 8
+
+// User is an account that owns screens, watchlists, portfolios and alerts
+type User struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	// Plan selects the rate limits and daily quota applied to the user
+	Plan      string `json:"plan"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NormalizeEmail trims and lower-cases an e-mail address
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateRegistration checks the e-mail address and password policy
+func ValidateRegistration(email, password string) error {
+	email = NormalizeEmail(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return errors.New("a valid email is required")
+	}
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	return nil
+}
+
+// Register creates a user with a hashed password
+func Register(db *sql.DB, email, password string) (User, error) {
+	if err := ValidateRegistration(email, password); err != nil {
+		return User{}, err
+	}
+	email = NormalizeEmail(email)
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO users (email, password_hash, created_at)
+		VALUES (?, ?, datetime('now'))
+		ON CONFLICT (email) DO NOTHING`,
+		email, hash,
+	)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to insert user: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return User{}, ErrEmailTaken
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return User{}, err
+	}
+
+	return GetUser(db, id)
+}
+
+// Login verifies an e-mail/password pair and returns the matching user
+func Login(db *sql.DB, email, password string) (User, error) {
+	var u User
+	var hash string
+	err := db.QueryRow(`
+		SELECT id, email, plan, created_at, password_hash
+		FROM users WHERE email = ?`, NormalizeEmail(email),
+	).Scan(&u.ID, &u.Email, &u.Plan, &u.CreatedAt, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Hash anyway so unknown addresses take as long as wrong passwords
+		CheckPassword(dummyHash, password)
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+
+	if !CheckPassword(hash, password) {
+		return User{}, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+func GetUser(db *sql.DB, id int64) (User, error) {
+	var u User
+	err := db.QueryRow(`
+		SELECT id, email, plan, created_at
+		FROM users WHERE id = ?`, id,
+	).Scan(&u.ID, &u.Email, &u.Plan, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+	return u, nil
+}
+
+// newToken returns prefix followed by 32 random bytes in hex
+func newToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// hashToken is used to look up session tokens and API keys without storing them.
+// The tokens carry 256 bits of entropy, so an unsalted fast hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := db.MigrateDatabaseFromFile(conn, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	// Keep hashing cheap in tests
+	PasswordIterations = 1000
+
+	return conn
+}
+
+func TestPasswordHashing(t *testing.T) {
+	PasswordIterations = 1000
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$1000$") {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Expected password to match its hash")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("Expected wrong password to be rejected")
+	}
+
+	other, _ := HashPassword("correct horse")
+	if other == hash {
+		t.Error("Expected hashes of the same password to use different salts")
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	user, err := Register(conn, " Alice@Example.com ", "s3cret-password")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("Expected normalized email, got %s", user.Email)
+	}
+
+	if _, err := Register(conn, "alice@example.com", "another-password"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+	if _, err := Register(conn, "bob@example.com", "short"); err == nil {
+		t.Error("Expected short password to be rejected")
+	}
+
+	if _, err := Login(conn, "alice@example.com", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := Login(conn, "nobody@example.com", "s3cret-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+
+	loggedIn, err := Login(conn, "ALICE@example.com", "s3cret-password")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	session, err := CreateSession(conn, loggedIn)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	got, err := UserForToken(conn, session.Token)
+	if err != nil {
+		t.Fatalf("UserForToken failed: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("Expected user %d, got %d", user.ID, got.ID)
+	}
+
+	if err := DeleteSession(conn, session.Token); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+	if _, err := UserForToken(conn, session.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken after logout, got %v", err)
+	}
+}
+
+func TestExpiredSession(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	user, err := Register(conn, "alice@example.com", "s3cret-password")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	session, err := CreateSession(conn, user)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE sessions SET expires_at = datetime('now', '-1 minute')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UserForToken(conn, session.Token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for expired session, got %v", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	alice, _ := Register(conn, "alice@example.com", "s3cret-password")
+	bob, _ := Register(conn, "bob@example.com", "s3cret-password")
+
+	key, err := CreateAPIKey(conn, alice.ID, "nightly script")
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if !strings.HasPrefix(key.Key, key.Prefix) || key.Prefix == key.Key {
+		t.Errorf("Expected key %q to start with its display prefix %q", key.Key, key.Prefix)
+	}
+
+	got, err := UserForToken(conn, key.Key)
+	if err != nil {
+		t.Fatalf("UserForToken failed: %v", err)
+	}
+	if got.ID != alice.ID {
+		t.Errorf("Expected API key to resolve to alice, got %d", got.ID)
+	}
+
+	keys, err := ListAPIKeys(conn, alice.ID)
+	if err != nil {
+		t.Fatalf("ListAPIKeys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Key != "" || keys[0].LastUsedAt == nil {
+		t.Errorf("Expected one key without secret and with last use recorded, got %+v", keys)
+	}
+
+	if err := DeleteAPIKey(conn, bob.ID, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected another user's key to be untouchable, got %v", err)
+	}
+	if err := DeleteAPIKey(conn, alice.ID, key.ID); err != nil {
+		t.Fatalf("DeleteAPIKey failed: %v", err)
+	}
+	if _, err := UserForToken(conn, key.Key); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for revoked key, got %v", err)
+	}
+
+	if _, err := UserForToken(conn, "garbage"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for unknown token format, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SessionTTL is how long a login session stays valid
+var SessionTTL = 7 * 24 * time.Hour
+
+// sessionPrefix marks bearer tokens issued at login
+const sessionPrefix = "fss_"
+
+// Session is a bearer token returned once at login
+type Session struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	User      User   `json:"user"`
+}
+
+// CreateSession issues a new session token for the user
+func CreateSession(db *sql.DB, user User) (Session, error) {
+	token, err := newToken(sessionPrefix)
+	if err != nil {
+		return Session{}, err
+	}
+
+	expiresAt := time.Now().UTC().Add(SessionTTL).Format("2006-01-02 15:04:05")
+	_, err = db.Exec(`
+		INSERT INTO sessions (token_hash, user_id, expires_at, created_at)
+		VALUES (?, ?, ?, datetime('now'))`,
+		hashToken(token), user.ID, expiresAt,
+	)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	return Session{Token: token, ExpiresAt: expiresAt, User: user}, nil
+}
+
+// DeleteSession ends a session; unknown tokens are ignored
+func DeleteSession(db *sql.DB, token string) error {
+	if _, err := db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, hashToken(token)); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions removes sessions past their expiry
+func DeleteExpiredSessions(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM sessions WHERE expires_at <= datetime('now')`)
+	return err
+}
+
+func userForSession(db *sql.DB, token string) (User, error) {
+	var u User
+	err := db.QueryRow(`
+		SELECT u.id, u.email, u.plan, u.created_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > datetime('now')`, hashToken(token),
+	).Scan(&u.ID, &u.Email, &u.Plan, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrInvalidToken
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+	return u, nil
+}
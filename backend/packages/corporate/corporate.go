@@ -0,0 +1,207 @@
+// Package corporate stores corporate actions, splits and dividends, and
+// back-adjusts prices and per-share figures for splits, so that history is
+// comparable with today's share count
+package corporate
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// SplitSource provides a ticker's split history, such as *eodhd.Client
+type SplitSource interface {
+	GetSplits(ticker string, from, to string) ([]eodhd.Split, error)
+}
+
+// Split is a stock split effective on Date. Ratio is the new shares per old
+// share, so 4 for a 4:1 split.
+type Split struct {
+	Date  string  `json:"date"`
+	Ratio float64 `json:"ratio"`
+}
+
+// Adjuster restates figures reported on a date in the share count after the
+// latest split. Prices and per-share figures on a split's date are already
+// post-split.
+type Adjuster struct {
+	splits []Split
+}
+
+// NewAdjuster adjusts for the given splits, in any order
+func NewAdjuster(splits []Split) Adjuster {
+	sorted := append([]Split(nil), splits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+	return Adjuster{splits: sorted}
+}
+
+// Factor is the product of the ratios of splits after date (YYYY-MM-DD, or
+// a timestamp starting with one): what a share on that date has become
+func (a Adjuster) Factor(date string) float64 {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	factor := 1.0
+	for _, s := range a.splits {
+		if s.Date > date {
+			factor *= s.Ratio
+		}
+	}
+	return factor
+}
+
+// Adjust restates a price or per-share figure, such as EPS or a dividend,
+// reported on date
+func (a Adjuster) Adjust(date string, value float64) float64 {
+	return value / a.Factor(date)
+}
+
+// segment is a date range sharing one adjustment factor, From inclusive and
+// To exclusive; empty bounds are open
+type segment struct {
+	From, To string
+	Factor   float64
+}
+
+func (a Adjuster) segments() []segment {
+	result := make([]segment, 0, len(a.splits)+1)
+	from := ""
+	for _, s := range a.splits {
+		result = append(result, segment{From: from, To: s.Date, Factor: a.Factor(from)})
+		from = s.Date
+	}
+	return append(result, segment{From: from, Factor: 1})
+}
+
+// SyncSplits stores the ticker's split history through to and returns every
+// stored split
+func SyncSplits(db *sql.DB, source SplitSource, ticker string, to time.Time) ([]Split, error) {
+	splits, err := source.GetSplits(ticker, "", to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("error getting splits for %s: %w", ticker, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, s := range splits {
+		ratio, err := s.Ratio()
+		if err != nil {
+			return nil, fmt.Errorf("%s split on %s: %w", ticker, s.Date, err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO corporate_actions (ticker, date, kind, value)
+			VALUES (?, ?, 'split', ?)
+			ON CONFLICT(ticker, date, kind) DO UPDATE SET value = excluded.value`,
+			ticker, s.Date, ratio,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save %s split: %w", ticker, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return LoadSplits(db, ticker)
+}
+
+// LoadSplits returns the ticker's stored splits, oldest first
+func LoadSplits(db *sql.DB, ticker string) ([]Split, error) {
+	rows, err := db.Query(`
+		SELECT date, value FROM corporate_actions
+		WHERE ticker = ? AND kind = 'split'
+		ORDER BY date`, ticker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query splits: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Split{}
+	for rows.Next() {
+		var s Split
+		if err := rows.Scan(&s.Date, &s.Ratio); err != nil {
+			return nil, fmt.Errorf("failed to scan split: %w", err)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// AdjustDividends returns the dividends with their values restated for
+// later splits
+func AdjustDividends(divs []eodhd.Dividend, adj Adjuster) []eodhd.Dividend {
+	result := make([]eodhd.Dividend, len(divs))
+	for i, d := range divs {
+		result[i] = d
+		result[i].Value = adj.Adjust(d.Date, d.Value)
+	}
+	return result
+}
+
+// SaveDividends stores dividends as paid, which must not have been adjusted
+// already, along with their adjusted values
+func SaveDividends(db *sql.DB, ticker string, divs []eodhd.Dividend, adj Adjuster) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range divs {
+		_, err := tx.Exec(`
+			INSERT INTO corporate_actions (ticker, date, kind, value, adjusted_value, currency)
+			VALUES (?, ?, 'dividend', ?, ?, NULLIF(?, ''))
+			ON CONFLICT(ticker, date, kind) DO UPDATE SET
+				value = excluded.value, adjusted_value = excluded.adjusted_value, currency = excluded.currency`,
+			ticker, d.Date, d.Value, adj.Adjust(d.Date, d.Value), d.Currency,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save %s dividend: %w", ticker, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Restate re-adjusts the ticker's stored closes and dividends from their raw
+// values, so that history stored before a split stays comparable. Moving
+// averages are rescaled by the change in the close's factor.
+func Restate(db *sql.DB, ticker string, adj Adjuster) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range adj.segments() {
+		to := s.To
+		if to == "" {
+			to = "9999-12-31"
+		}
+		_, err := tx.Exec(`
+			UPDATE prices SET
+				close = raw_close / ?1,
+				sma50 = sma50 * (raw_close / close) / ?1,
+				sma200 = sma200 * (raw_close / close) / ?1
+			WHERE ticker = ?2 AND raw_close IS NOT NULL AND close > 0 AND date >= ?3 AND date < ?4`,
+			s.Factor, ticker, s.From, to,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restate %s prices: %w", ticker, err)
+		}
+		_, err = tx.Exec(`
+			UPDATE corporate_actions SET adjusted_value = value / ?
+			WHERE ticker = ? AND kind = 'dividend' AND date >= ? AND date < ?`,
+			s.Factor, ticker, s.From, to,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to restate %s dividends: %w", ticker, err)
+		}
+	}
+	return tx.Commit()
+}
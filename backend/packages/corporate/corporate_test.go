@@ -0,0 +1,177 @@
+package corporate
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE prices (
+			ticker TEXT,
+			date TEXT,
+			close REAL,
+			sma50 REAL,
+			sma200 REAL,
+			raw_close REAL,
+			PRIMARY KEY (ticker, date)
+		);
+		CREATE TABLE corporate_actions (
+			ticker TEXT NOT NULL,
+			date TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK (kind IN ('split', 'dividend')),
+			value REAL NOT NULL,
+			adjusted_value REAL,
+			currency TEXT,
+			PRIMARY KEY (ticker, date, kind)
+		);`)
+	if err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return db
+}
+
+type fakeSplits []eodhd.Split
+
+func (s fakeSplits) GetSplits(ticker string, from, to string) ([]eodhd.Split, error) {
+	return s, nil
+}
+
+// Apple split 7:1 in 2014 and 4:1 in 2020
+var appleSplits = fakeSplits{
+	{Date: "2020-08-31", Split: "4.000000/1.000000"},
+	{Date: "2014-06-09", Split: "7.000000/1.000000"},
+}
+
+func TestAdjuster(t *testing.T) {
+	adj := NewAdjuster([]Split{{Date: "2020-08-31", Ratio: 4}, {Date: "2014-06-09", Ratio: 7}})
+
+	tests := []struct {
+		date   string
+		factor float64
+	}{
+		{"2013-12-31", 28},
+		{"2014-06-06", 28},
+		// Prices on the split date are already post-split
+		{"2014-06-09", 4},
+		{"2020-08-28T20:00:00+00:00", 4},
+		{"2020-08-31", 1},
+		{"2024-01-15", 1},
+	}
+	for _, tt := range tests {
+		if got := adj.Factor(tt.date); got != tt.factor {
+			t.Errorf("Factor(%s) = %v, want %v", tt.date, got, tt.factor)
+		}
+	}
+	if got := adj.Adjust("2020-08-28", 499.23); math.Abs(got-124.8075) > 1e-9 {
+		t.Errorf("Expected the pre-split close divided by 4, got %v", got)
+	}
+}
+
+func TestSplitRatio(t *testing.T) {
+	if r, err := (eodhd.Split{Split: "1.000000/10.000000"}).Ratio(); err != nil || r != 0.1 {
+		t.Errorf("Expected a reverse split ratio of 0.1, got %v (%v)", r, err)
+	}
+	if _, err := (eodhd.Split{Split: "4:1"}).Ratio(); err == nil {
+		t.Error("Expected an error for an unknown split format")
+	}
+}
+
+func TestSyncSplitsAndDividends(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	splits, err := SyncSplits(db, appleSplits, "AAPL", time.Now())
+	if err != nil {
+		t.Fatalf("SyncSplits failed: %v", err)
+	}
+	if len(splits) != 2 || splits[0].Date != "2014-06-09" || splits[0].Ratio != 7 || splits[1].Ratio != 4 {
+		t.Fatalf("Expected both splits oldest first, got %+v", splits)
+	}
+	adj := NewAdjuster(splits)
+
+	divs := []eodhd.Dividend{
+		{Date: "2019-11-07", Value: 0.77, Currency: "USD"},
+		{Date: "2020-11-06", Value: 0.205, Currency: "USD"},
+	}
+	if err := SaveDividends(db, "AAPL", divs, adj); err != nil {
+		t.Fatalf("SaveDividends failed: %v", err)
+	}
+	adjusted := AdjustDividends(divs, adj)
+	if math.Abs(adjusted[0].Value-0.1925) > 1e-9 || adjusted[1].Value != 0.205 || divs[0].Value != 0.77 {
+		t.Errorf("Expected only the pre-split dividend adjusted, got %+v", adjusted)
+	}
+
+	var raw, stored float64
+	err = db.QueryRow(`SELECT value, adjusted_value FROM corporate_actions WHERE ticker = 'AAPL' AND kind = 'dividend' AND date = '2019-11-07'`).
+		Scan(&raw, &stored)
+	if err != nil {
+		t.Fatalf("Failed to query dividend: %v", err)
+	}
+	if raw != 0.77 || math.Abs(stored-0.1925) > 1e-9 {
+		t.Errorf("Expected raw 0.77 and adjusted 0.1925, got %v and %v", raw, stored)
+	}
+}
+
+func TestRestate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Stored before the 2020 split, when only the 2014 split had happened
+	_, err := db.Exec(`
+		INSERT INTO prices (ticker, date, close, sma50, sma200, raw_close) VALUES
+		('AAPL', '2013-12-31', 80.0, 75.0, 70.0, 560.0),
+		('AAPL', '2020-08-28', 499.23, 450.0, 360.0, 499.23),
+		('AAPL', '2020-09-01', 134.18, 120.0, 95.0, 134.18),
+		('AAPL', '2020-08-27', 500.04, 440.0, 355.0, NULL);
+		INSERT INTO corporate_actions (ticker, date, kind, value, adjusted_value) VALUES
+		('AAPL', '2019-11-07', 'dividend', 0.77, 0.77)`)
+	if err != nil {
+		t.Fatalf("Failed to insert history: %v", err)
+	}
+
+	splits, err := SyncSplits(db, appleSplits, "AAPL", time.Now())
+	if err != nil {
+		t.Fatalf("SyncSplits failed: %v", err)
+	}
+	if err := Restate(db, "AAPL", NewAdjuster(splits)); err != nil {
+		t.Fatalf("Restate failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		date                 string
+		close, sma50, sma200 float64
+	}{
+		{"2013-12-31", 20, 18.75, 17.5},
+		{"2020-08-28", 124.8075, 112.5, 90},
+		{"2020-09-01", 134.18, 120, 95},
+		// Rows without a raw close are left alone
+		{"2020-08-27", 500.04, 440, 355},
+	} {
+		var close, sma50, sma200 float64
+		if err := db.QueryRow(`SELECT close, sma50, sma200 FROM prices WHERE ticker = 'AAPL' AND date = ?`, tt.date).
+			Scan(&close, &sma50, &sma200); err != nil {
+			t.Fatalf("Failed to query %s: %v", tt.date, err)
+		}
+		if math.Abs(close-tt.close) > 1e-9 || math.Abs(sma50-tt.sma50) > 1e-9 || math.Abs(sma200-tt.sma200) > 1e-9 {
+			t.Errorf("%s: expected %v/%v/%v, got %v/%v/%v", tt.date, tt.close, tt.sma50, tt.sma200, close, sma50, sma200)
+		}
+	}
+
+	var dividend float64
+	if err := db.QueryRow(`SELECT adjusted_value FROM corporate_actions WHERE kind = 'dividend'`).Scan(&dividend); err != nil {
+		t.Fatalf("Failed to query dividend: %v", err)
+	}
+	if math.Abs(dividend-0.1925) > 1e-9 {
+		t.Errorf("Expected the dividend restated to 0.1925, got %v", dividend)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -17,6 +18,17 @@ func MigrateDatabaseFromFile(db *sql.DB, schemaFilePath string) error {
 
 	schemaSQL := string(schemaBytes)
 
+	// Older databases need new columns before the schema indexes them
+	if err := addMissingColumns(db); err != nil {
+		return err
+	}
+	if err := dropRemovedColumns(db); err != nil {
+		return err
+	}
+	if err := unshareScreens(db); err != nil {
+		return err
+	}
+
 	if _, err := db.Exec(schemaSQL); err != nil {
 		return fmt.Errorf("failed to execute schema.sql: %w", err)
 	}
@@ -24,3 +36,137 @@ func MigrateDatabaseFromFile(db *sql.DB, schemaFilePath string) error {
 	log.Println("Database migrations completed successfully")
 	return nil
 }
+
+// addedColumns lists columns added to tables after they were first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables as they are, so these are
+// added to them here; new databases get them from schema.sql.
+var addedColumns = []struct {
+	Table      string
+	Column     string
+	Definition string
+}{
+	{"fundamentals", "sector", "TEXT"},
+	{"fundamentals", "industry", "TEXT"},
+	{"fundamentals", "piotroski_f_score", "INTEGER"},
+	{"fundamentals", "piotroski_components", "JSON"},
+	{"fundamentals", "altman_z_score", "REAL"},
+	{"fundamentals", "altman_components", "JSON"},
+	{"fundamentals", "earnings_outlook_evidence", "JSON"},
+	{"fundamentals", "news_sentiment_30d", "REAL"},
+	{"fundamentals", "news_articles_30d", "INTEGER"},
+	{"prices", "raw_close", "REAL"},
+	{"fundamentals", "currency", "TEXT"},
+}
+
+func addMissingColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		columns, err := tableColumns(db, c.Table)
+		if err != nil {
+			return err
+		}
+		// Tables that don't exist yet are created complete by the schema
+		if len(columns) == 0 || columns[c.Column] {
+			continue
+		}
+
+		log.Printf("Adding column %s.%s", c.Table, c.Column)
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.Table, c.Column, c.Definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+	return nil
+}
+
+// droppedColumns lists columns removed from the schema. Databases created
+// before the removal still have them, so they are dropped here.
+var droppedColumns = []struct {
+	Table  string
+	Column string
+}{
+	// DCF values moved to the valuations table
+	{"fundamentals", "dcf_intrinsic_value"},
+	{"fundamentals", "dcf_margin_of_safety"},
+	{"fundamentals", "dcf_details"},
+}
+
+func dropRemovedColumns(db *sql.DB) error {
+	for _, c := range droppedColumns {
+		columns, err := tableColumns(db, c.Table)
+		if err != nil {
+			return err
+		}
+		if !columns[c.Column] {
+			continue
+		}
+
+		log.Printf("Dropping column %s.%s", c.Table, c.Column)
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", c.Table, c.Column)); err != nil {
+			return fmt.Errorf("failed to drop column %s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+	return nil
+}
+
+// unshareScreens makes sharing screens opt-in on databases created while
+// every screen got a share token. SQLite can't drop the NOT NULL constraint
+// on share_token, so the table is rebuilt as schema.sql now creates it, and
+// the tokens handed out unasked are revoked.
+func unshareScreens(db *sql.DB) error {
+	var notNull bool
+	err := db.QueryRow("SELECT \"notnull\" FROM pragma_table_info('screens') WHERE name = 'share_token'").Scan(&notNull)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !notNull) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read columns of screens: %w", err)
+	}
+
+	log.Println("Revoking screen share tokens")
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TABLE screens_unshared (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			filters JSON NOT NULL DEFAULT '',
+			sort TEXT NOT NULL DEFAULT '',
+			columns JSON NOT NULL DEFAULT '[]',
+			version INTEGER NOT NULL DEFAULT 1,
+			share_token TEXT UNIQUE,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO screens_unshared (id, user_id, name, description, filters, sort, columns, version, created_at, updated_at)
+		SELECT id, user_id, name, description, filters, sort, columns, version, created_at, updated_at FROM screens;
+		DROP TABLE screens;
+		ALTER TABLE screens_unshared RENAME TO screens`)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild screens: %w", err)
+	}
+	return tx.Commit()
+}
+
+// tableColumns returns the set of column names of table, empty if it does not exist
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMigrateDropsRemovedColumns(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// A database created while fundamentals held the DCF values
+	_, err = db.Exec(`
+		CREATE TABLE fundamentals (
+			ticker TEXT PRIMARY KEY, pe_ratio REAL, roe REAL, yoy_profit JSON, yoy_turnover JSON,
+			earnings_outlook TEXT, updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+			dividend_yield REAL, dividend_growth_5y REAL, intrinsic_value REAL, margin_of_safety REAL,
+			dcf_intrinsic_value REAL, dcf_margin_of_safety REAL, dcf_details JSON);
+		INSERT INTO fundamentals (ticker, roe, dcf_intrinsic_value) VALUES ('AAPL', 0.3, 180.0)`)
+	if err != nil {
+		t.Fatalf("Failed to create the old table: %v", err)
+	}
+
+	if err := MigrateDatabaseFromFile(db, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+
+	columns, err := tableColumns(db, "fundamentals")
+	if err != nil {
+		t.Fatalf("tableColumns failed: %v", err)
+	}
+	for _, c := range droppedColumns {
+		if columns[c.Column] {
+			t.Errorf("Expected %s dropped, got %v", c.Column, columns)
+		}
+	}
+	if !columns["currency"] {
+		t.Errorf("Expected added columns, got %v", columns)
+	}
+	var roe float64
+	if err := db.QueryRow("SELECT roe FROM fundamentals WHERE ticker = 'AAPL'").Scan(&roe); err != nil || roe != 0.3 {
+		t.Errorf("Expected AAPL's roe kept, got %v, %v", roe, err)
+	}
+
+	// Migrating again leaves the table as it is
+	if err := MigrateDatabaseFromFile(db, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Second migration failed: %v", err)
+	}
+}
+
+func TestMigrateRevokesShareTokens(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// A database created while every screen got a share token
+	_, err = db.Exec(`
+		CREATE TABLE screens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '', filters JSON NOT NULL DEFAULT '', sort TEXT NOT NULL DEFAULT '',
+			columns JSON NOT NULL DEFAULT '[]', version INTEGER NOT NULL DEFAULT 1, share_token TEXT NOT NULL UNIQUE,
+			created_at TEXT DEFAULT CURRENT_TIMESTAMP, updated_at TEXT DEFAULT CURRENT_TIMESTAMP);
+		INSERT INTO screens (user_id, name, version, share_token) VALUES (1, 'Value', 3, 'abc')`)
+	if err != nil {
+		t.Fatalf("Failed to create the old table: %v", err)
+	}
+
+	if err := MigrateDatabaseFromFile(db, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+
+	var name string
+	var version int
+	var token sql.NullString
+	if err := db.QueryRow("SELECT name, version, share_token FROM screens WHERE id = 1").Scan(&name, &version, &token); err != nil {
+		t.Fatalf("Failed to read the screen: %v", err)
+	}
+	if name != "Value" || version != 3 || token.Valid {
+		t.Errorf("Expected the screen kept without its token, got %s v%d %v", name, version, token)
+	}
+	if _, err := db.Exec("INSERT INTO screens (user_id, name) VALUES (1, 'Unshared')"); err != nil {
+		t.Errorf("Expected screens without a share token, got %v", err)
+	}
+}
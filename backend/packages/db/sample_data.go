@@ -25,23 +25,23 @@ func InsertSampleData(db *sql.DB) error {
 	// Sample fundamentals data
 	fundamentalsData := `
 		INSERT OR REPLACE INTO fundamentals
-		(ticker, pe_ratio, roe, earnings_outlook, dividend_yield, dividend_growth_5y, intrinsic_value, margin_of_safety)
+		(ticker, pe_ratio, roe, earnings_outlook, dividend_yield, dividend_growth_5y, intrinsic_value, margin_of_safety, sector, industry, currency)
 		VALUES
-		('AAPL', 14.5, 0.25, 'positive', 0.005, 0.08, 180.50, 0.25),
-		('GOOGL', 13.1, 0.18, 'positive', 0.0, 0.0, 3100.0, 0.15),
-		('MSFT', 12.5, 0.22, 'positive', 0.035, 0.12, 375.0, 0.22),
-		('TSLA', 45.2, 0.15, 'neutral', 0.0, 0.0, 800.0, -0.05),
-		('IBM', 8.3, 0.08, 'negative', 0.045, 0.08, 120.0, 0.35),
-		('KO', 9.7, 0.16, 'positive', 0.045, 0.08, 65.0, 0.25),
-		('JNJ', 11.2, 0.18, 'positive', 0.038, 0.06, 170.0, 0.18),
-		('PFE', 7.8, 0.12, 'positive', 0.055, 0.10, 55.0, 0.30),
-		('WMT', 26.5, 0.19, 'stable', 0.016, 0.04, 145.0, 0.05),
-		('XOM', 13.8, 0.14, 'neutral', 0.058, 0.03, 95.0, 0.12),
-		('JPM', 10.2, 0.16, 'positive', 0.025, 0.05, 155.0, 0.18),
-		('DIS', 22.1, 0.08, 'neutral', 0.0, 0.0, 110.0, 0.08),
-		('NVDA', 65.3, 0.35, 'positive', 0.003, 0.15, 420.0, -0.12),
-		('AMZN', 48.7, 0.12, 'positive', 0.0, 0.0, 3200.0, 0.02),
-		('META', 18.9, 0.24, 'positive', 0.0, 0.0, 285.0, 0.15);
+		('AAPL', 14.5, 0.25, 'positive', 0.005, 0.08, 180.50, 0.25, 'Technology', 'Consumer Electronics', 'USD'),
+		('GOOGL', 13.1, 0.18, 'positive', 0.0, 0.0, 3100.0, 0.15, 'Communication Services', 'Internet Content & Information', 'USD'),
+		('MSFT', 12.5, 0.22, 'positive', 0.035, 0.12, 375.0, 0.22, 'Technology', 'Software - Infrastructure', 'USD'),
+		('TSLA', 45.2, 0.15, 'neutral', 0.0, 0.0, 800.0, -0.05, 'Consumer Cyclical', 'Auto Manufacturers', 'USD'),
+		('IBM', 8.3, 0.08, 'negative', 0.045, 0.08, 120.0, 0.35, 'Technology', 'Information Technology Services', 'USD'),
+		('KO', 9.7, 0.16, 'positive', 0.045, 0.08, 65.0, 0.25, 'Consumer Defensive', 'Beverages - Non-Alcoholic', 'USD'),
+		('JNJ', 11.2, 0.18, 'positive', 0.038, 0.06, 170.0, 0.18, 'Healthcare', 'Drug Manufacturers - General', 'USD'),
+		('PFE', 7.8, 0.12, 'positive', 0.055, 0.10, 55.0, 0.30, 'Healthcare', 'Drug Manufacturers - General', 'USD'),
+		('WMT', 26.5, 0.19, 'stable', 0.016, 0.04, 145.0, 0.05, 'Consumer Defensive', 'Discount Stores', 'USD'),
+		('XOM', 13.8, 0.14, 'neutral', 0.058, 0.03, 95.0, 0.12, 'Energy', 'Oil & Gas Integrated', 'USD'),
+		('JPM', 10.2, 0.16, 'positive', 0.025, 0.05, 155.0, 0.18, 'Financial Services', 'Banks - Diversified', 'USD'),
+		('DIS', 22.1, 0.08, 'neutral', 0.0, 0.0, 110.0, 0.08, 'Communication Services', 'Entertainment', 'USD'),
+		('NVDA', 65.3, 0.35, 'positive', 0.003, 0.15, 420.0, -0.12, 'Technology', 'Semiconductors', 'USD'),
+		('AMZN', 48.7, 0.12, 'positive', 0.0, 0.0, 3200.0, 0.02, 'Consumer Cyclical', 'Internet Retail', 'USD'),
+		('META', 18.9, 0.24, 'positive', 0.0, 0.0, 285.0, 0.15, 'Communication Services', 'Internet Content & Information', 'USD');
 	`
 
 	if _, err := db.Exec(fundamentalsData); err != nil {
@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -103,30 +105,76 @@ type Fundamentals struct {
 	raw map[string]any
 }
 
-// GetFloat returns a float64 from a "::" path like "Earnings::History::2023-12-31::epsActual"
+// NewFundamentals wraps decoded fundamentals JSON
+func NewFundamentals(raw map[string]any) *Fundamentals {
+	return &Fundamentals{raw: raw}
+}
+
+// GetFloat returns a float64 from a "::" path like "Earnings::History::2023-12-31::epsActual".
+// Financial statements report numbers as strings, which are parsed too.
 func (f *Fundamentals) GetFloat(path string) float64 {
+	value, _ := f.LookupFloat(path)
+	return value
+}
+
+// LookupFloat is GetFloat, also reporting whether the path holds a number.
+// EODHD reports unknown figures, such as estimates not yet made, as null.
+func (f *Fundamentals) LookupFloat(path string) (float64, bool) {
 	keys := strings.Split(path, "::")
 	current := f.raw
 
 	for i, key := range keys {
 		if i == len(keys)-1 {
-			if val, ok := current[key].(float64); ok {
-				return val
+			switch val := current[key].(type) {
+			case float64:
+				return val, true
+			case string:
+				parsed, err := strconv.ParseFloat(val, 64)
+				return parsed, err == nil
 			}
-			return 0
+			return 0, false
+		}
+
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			return 0, false
+		}
+		current = next
+	}
+	return 0, false
+}
+
+// GetString returns a string from a "::" path like "General::Sector"
+func (f *Fundamentals) GetString(path string) string {
+	keys := strings.Split(path, "::")
+	current := f.raw
+
+	for i, key := range keys {
+		if i == len(keys)-1 {
+			s, _ := current[key].(string)
+			return s
 		}
 
 		next, ok := current[key].(map[string]any)
 		if !ok {
-			return 0
+			return ""
 		}
 		current = next
 	}
-	return 0
+	return ""
 }
 
 // GetLatestPeriod finds the most recent date (YYYY-MM-DD) available under a nested path.
 func (f *Fundamentals) GetLatestPeriod(path string) string {
+	periods := f.GetPeriods(path)
+	if len(periods) == 0 {
+		return ""
+	}
+	return periods[0]
+}
+
+// GetPeriods lists the date keys (YYYY-MM-DD) under a nested path, newest first
+func (f *Fundamentals) GetPeriods(path string) []string {
 	keys := strings.Split(path, "::")
 	current := f.raw
 
@@ -134,19 +182,17 @@ func (f *Fundamentals) GetLatestPeriod(path string) string {
 	for _, key := range keys {
 		next, ok := current[key].(map[string]any)
 		if !ok {
-			return ""
+			return nil
 		}
 		current = next
 	}
 
-	// Find the latest date key
-	var latest string
+	periods := make([]string, 0, len(current))
 	for k := range current {
-		if k > latest {
-			latest = k
-		}
+		periods = append(periods, k)
 	}
-	return latest
+	sort.Sort(sort.Reverse(sort.StringSlice(periods)))
+	return periods
 }
 
 func (c *Client) GetFundamentalsRaw(ticker string) (*Fundamentals, error) {
@@ -224,3 +270,126 @@ func (c *Client) GetDividends(ticker string, from, to string) ([]Dividend, error
 	err := c.get(endpoint, params, &result)
 	return result, err
 }
+
+// Split is a stock split effective on Date, e.g. "4.000000/1.000000" for
+// four new shares per old share
+type Split struct {
+	Date  string `json:"date"`
+	Split string `json:"split"`
+}
+
+// Ratio returns the new shares per old share
+func (s Split) Ratio() (float64, error) {
+	newShares, oldShares, ok := strings.Cut(s.Split, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid split %q", s.Split)
+	}
+	n, errN := strconv.ParseFloat(strings.TrimSpace(newShares), 64)
+	o, errO := strconv.ParseFloat(strings.TrimSpace(oldShares), 64)
+	if errN != nil || errO != nil || n <= 0 || o <= 0 {
+		return 0, fmt.Errorf("invalid split %q", s.Split)
+	}
+	return n / o, nil
+}
+
+func (c *Client) GetSplits(ticker string, from, to string) ([]Split, error) {
+	endpoint := fmt.Sprintf("splits/%s", ticker)
+	params := url.Values{}
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+
+	var result []Split
+	err := c.get(endpoint, params, &result)
+	return result, err
+}
+
+// Yield is a bond's yield in percent on a date
+type Yield struct {
+	Date  string  `json:"date"`
+	Yield float64 `json:"close"`
+}
+
+// GetBondYields retrieves daily yields of a bond series such as US10Y.GBOND,
+// which EODHD serves as end-of-day data quoted in percent
+func (c *Client) GetBondYields(symbol string, from, to string) ([]Yield, error) {
+	endpoint := fmt.Sprintf("eod/%s", symbol)
+	params := url.Values{}
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+
+	var result []Yield
+	err := c.get(endpoint, params, &result)
+	return result, err
+}
+
+// NewsArticle is a financial news article about one or more tickers
+type NewsArticle struct {
+	Date      string         `json:"date"`
+	Title     string         `json:"title"`
+	Content   string         `json:"content"`
+	Link      string         `json:"link"`
+	Symbols   []string       `json:"symbols"`
+	Tags      []string       `json:"tags"`
+	Sentiment *NewsSentiment `json:"sentiment"`
+}
+
+// NewsSentiment is EODHD's own scoring of an article; Polarity runs from -1
+// to 1
+type NewsSentiment struct {
+	Polarity float64 `json:"polarity"`
+	Negative float64 `json:"neg"`
+	Neutral  float64 `json:"neu"`
+	Positive float64 `json:"pos"`
+}
+
+// GetNews retrieves the latest articles about a ticker, newest first.
+// from and to in YYYY-MM-DD format.
+func (c *Client) GetNews(ticker string, from, to string, limit int) ([]NewsArticle, error) {
+	params := url.Values{}
+	params.Set("s", ticker)
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var result []NewsArticle
+	err := c.get("news", params, &result)
+	return result, err
+}
+
+// DailySentiment is the normalized sentiment of a day's articles about a
+// ticker, from -1 to 1
+type DailySentiment struct {
+	Date       string  `json:"date"`
+	Count      int     `json:"count"`
+	Normalized float64 `json:"normalized"`
+}
+
+// GetSentiments retrieves daily news sentiment for tickers, keyed by ticker
+func (c *Client) GetSentiments(tickers []string, from, to string) (map[string][]DailySentiment, error) {
+	params := url.Values{}
+	params.Set("s", strings.Join(tickers, ","))
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+
+	var result map[string][]DailySentiment
+	err := c.get("sentiments", params, &result)
+	return result, err
+}
@@ -0,0 +1,116 @@
+// Package export writes tabular results as CSV, XLSX or Parquet. Writers
+// accept one row at a time so callers can stream large result sets.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Format is a supported export file format
+type Format string
+
+const (
+	CSV     Format = "csv"
+	XLSX    Format = "xlsx"
+	Parquet Format = "parquet"
+)
+
+var contentTypes = map[Format]string{
+	CSV:     "text/csv; charset=utf-8",
+	XLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	Parquet: "application/vnd.apache.parquet",
+}
+
+// Formats lists the supported formats
+var Formats = []Format{CSV, XLSX, Parquet}
+
+// ContentType returns the MIME type to send with the format
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// ParseFormat validates a format name such as "csv"
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(name))
+	if _, ok := contentTypes[f]; !ok {
+		return "", fmt.Errorf("unsupported format %q", name)
+	}
+	return f, nil
+}
+
+// FormatForAccept returns the first export format named in an Accept header,
+// or false if the client did not ask for one
+func FormatForAccept(accept string) (Format, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for _, f := range Formats {
+			if want, _, _ := mime.ParseMediaType(f.ContentType()); mediaType == want {
+				return f, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ColumnType is the type of the values in a column
+type ColumnType int
+
+const (
+	String ColumnType = iota
+	Number
+)
+
+// Column describes one output column
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Writer writes rows of values in column order. Values are strings for String
+// columns and float64 for Number columns; nil is written as an empty cell.
+// Close must be called to complete the file.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// NewWriter returns a writer for the format
+func NewWriter(f Format, w io.Writer, columns []Column) (Writer, error) {
+	switch f {
+	case CSV:
+		return NewCSVWriter(w, columns)
+	case XLSX:
+		return NewXLSXWriter(w, columns)
+	case Parquet:
+		return NewParquetWriter(w, columns)
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+func checkRow(columns []Column, values []any) error {
+	if len(values) != len(columns) {
+		return fmt.Errorf("expected %d values, got %d", len(columns), len(values))
+	}
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		switch columns[i].Type {
+		case String:
+			if _, ok := v.(string); !ok {
+				return fmt.Errorf("column %s: expected a string, got %T", columns[i].Name, v)
+			}
+		case Number:
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("column %s: expected a float64, got %T", columns[i].Name, v)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+var testColumns = []Column{{Name: "ticker", Type: String}, {Name: "pe_ratio", Type: Number}}
+
+var testRows = [][]any{
+	{"AAPL", 25.5},
+	{"A&B <C>", nil},
+}
+
+func writeAll(t *testing.T, f Format) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf, testColumns)
+	if err != nil {
+		t.Fatalf("NewWriter(%s) failed: %v", f, err)
+	}
+	for _, row := range testRows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, CSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	want := [][]string{{"ticker", "pe_ratio"}, {"AAPL", "25.5"}, {"A&B <C>", ""}}
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d records, got %d", len(want), len(records))
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("Record %d: expected %v, got %v", i, want[i], records[i])
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	data := writeAll(t, XLSX)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">ticker</t></is></c>`,
+		`<c r="B2"><v>25.5</v></c>`,
+		`A&amp;B &lt;C&gt;`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Expected sheet to contain %s", want)
+		}
+	}
+	if strings.Contains(sheet, `r="B3"`) {
+		t.Error("Expected nil values to be left empty")
+	}
+}
+
+func TestParquet(t *testing.T) {
+	ParquetRowGroupSize = 1
+	defer func() { ParquetRowGroupSize = 65536 }()
+
+	data := writeAll(t, Parquet)
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("Expected PAR1 magic at both ends")
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		t.Fatalf("Invalid footer length %d", footerLen)
+	}
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, want := range []string{"ticker", "pe_ratio", "finsights"} {
+		if !bytes.Contains(footer, []byte(want)) {
+			t.Errorf("Expected footer to contain %q", want)
+		}
+	}
+
+	// Values are stored once, in their column chunk
+	if got := bytes.Count(data, []byte("AAPL")); got != 1 {
+		t.Errorf("Expected AAPL once, got %d", got)
+	}
+}
+
+// TestParquetGolden pins the writer's output to testdata/screener.parquet:
+// two row groups of ticker (optional UTF8) and pe_ratio (optional double),
+// holding {"AAPL", 25.5} and {"A&B <C>", null}. testdata/check_parquet.py
+// reads it with pyarrow; after changing the writer, rerun with -update and
+// check the new file with it.
+func TestParquetGolden(t *testing.T) {
+	ParquetRowGroupSize = 1
+	defer func() { ParquetRowGroupSize = 65536 }()
+
+	data := writeAll(t, Parquet)
+	golden := filepath.Join("testdata", "screener.parquet")
+	if *update {
+		if err := os.WriteFile(golden, data, 0o644); err != nil {
+			t.Fatalf("Failed to update %s: %v", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", golden, err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Parquet output differs from %s", golden)
+	}
+}
+
+func TestRowValidation(t *testing.T) {
+	w, _ := NewCSVWriter(io.Discard, testColumns)
+	if err := w.WriteRow([]any{"AAPL"}); err == nil {
+		t.Error("Expected error for a short row")
+	}
+	if err := w.WriteRow([]any{25.5, "AAPL"}); err == nil {
+		t.Error("Expected error for mistyped values")
+	}
+}
+
+func TestFormatForAccept(t *testing.T) {
+	if f, ok := FormatForAccept("application/json, text/csv;q=0.9"); !ok || f != CSV {
+		t.Errorf("Expected csv, got %q %v", f, ok)
+	}
+	if _, ok := FormatForAccept("application/json"); ok {
+		t.Error("Expected JSON not to select an export format")
+	}
+}
+
+func TestColumnRef(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := columnRef(i); got != want {
+			t.Errorf("columnRef(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
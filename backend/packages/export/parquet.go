@@ -0,0 +1,330 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// ParquetRowGroupSize is the number of rows buffered before a row group is
+// written. Parquet is columnar, so each row group is held in memory.
+var ParquetRowGroupSize = 65536
+
+// Parquet constants from the format's thrift definitions
+const (
+	parquetDouble    = 5
+	parquetByteArray = 6
+	parquetOptional  = 1
+	parquetUTF8      = 0
+	parquetPlain     = 0
+	parquetRLE       = 3
+	parquetDataPage  = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn buffers one column of the current row group
+type parquetColumn struct {
+	defLevels []bool
+	values    bytes.Buffer
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroup struct {
+	rows    int64
+	size    int64
+	columns []columnChunk
+}
+
+type parquetWriter struct {
+	w       *countingWriter
+	columns []Column
+	buffers []parquetColumn
+	rows    int
+	groups  []rowGroup
+}
+
+// NewParquetWriter writes an uncompressed Parquet file with one optional
+// column per Column: DOUBLE for numbers and UTF-8 BYTE_ARRAY for strings
+func NewParquetWriter(w io.Writer, columns []Column) (Writer, error) {
+	pw := &parquetWriter{
+		w:       &countingWriter{w: w},
+		columns: columns,
+		buffers: make([]parquetColumn, len(columns)),
+	}
+	if _, err := pw.w.Write(parquetMagic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) WriteRow(values []any) error {
+	if err := checkRow(pw.columns, values); err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		col := &pw.buffers[i]
+		col.defLevels = append(col.defLevels, v != nil)
+		switch v := v.(type) {
+		case string:
+			binary.Write(&col.values, binary.LittleEndian, uint32(len(v)))
+			col.values.WriteString(v)
+		case float64:
+			binary.Write(&col.values, binary.LittleEndian, math.Float64bits(v))
+		}
+	}
+
+	pw.rows++
+	if pw.rows >= ParquetRowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// flushRowGroup writes the buffered rows as a row group with a single data
+// page per column
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+
+	group := rowGroup{rows: int64(pw.rows)}
+	for i := range pw.buffers {
+		col := &pw.buffers[i]
+
+		var page bytes.Buffer
+		levels := encodeDefinitionLevels(col.defLevels)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+		page.Write(col.values.Bytes())
+
+		var header compactWriter
+		header.beginStruct()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.structField(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunk := columnChunk{offset: pw.w.n, values: int64(pw.rows)}
+		if _, err := pw.w.Write(header.b); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(page.Bytes()); err != nil {
+			return err
+		}
+		chunk.size = pw.w.n - chunk.offset
+		group.size += chunk.size
+		group.columns = append(group.columns, chunk)
+
+		col.defLevels = col.defLevels[:0]
+		col.values.Reset()
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.rows = 0
+	return nil
+}
+
+// Close writes the remaining rows and the file footer
+func (pw *parquetWriter) Close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	footer := pw.fileMetadata()
+	if _, err := pw.w.Write(footer); err != nil {
+		return err
+	}
+	if err := binary.Write(pw.w, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(parquetMagic)
+	return err
+}
+
+func (pw *parquetWriter) fileMetadata() []byte {
+	var totalRows int64
+	for _, g := range pw.groups {
+		totalRows += g.rows
+	}
+
+	var m compactWriter
+	m.beginStruct()
+	m.i32(1, 1)
+
+	m.list(2, compactStruct, len(pw.columns)+1)
+	m.beginStruct()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(pw.columns)))
+	m.endStruct()
+	for _, c := range pw.columns {
+		m.beginStruct()
+		m.i32(1, parquetType(c))
+		m.i32(3, parquetOptional)
+		m.binary(4, c.Name)
+		if c.Type == String {
+			m.i32(6, parquetUTF8)
+		}
+		m.endStruct()
+	}
+
+	m.i64(3, totalRows)
+
+	m.list(4, compactStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		m.beginStruct()
+		m.list(1, compactStruct, len(g.columns))
+		for i, chunk := range g.columns {
+			m.beginStruct()
+			m.i64(2, chunk.offset)
+			m.structField(3)
+			m.i32(1, parquetType(pw.columns[i]))
+			m.list(2, compactI32, 2)
+			m.zigzag(parquetPlain)
+			m.zigzag(parquetRLE)
+			m.list(3, compactBinary, 1)
+			m.rawBinary(pw.columns[i].Name)
+			m.i32(4, 0) // UNCOMPRESSED
+			m.i64(5, chunk.values)
+			m.i64(6, chunk.size)
+			m.i64(7, chunk.size)
+			m.i64(9, chunk.offset)
+			m.endStruct()
+			m.endStruct()
+		}
+		m.i64(2, g.size)
+		m.i64(3, g.rows)
+		m.endStruct()
+	}
+
+	m.binary(6, "finsights")
+	m.endStruct()
+	return m.b
+}
+
+func parquetType(c Column) int32 {
+	if c.Type == Number {
+		return parquetDouble
+	}
+	return parquetByteArray
+}
+
+// encodeDefinitionLevels encodes 0/1 definition levels with the RLE half of
+// Parquet's RLE/bit-packing hybrid, one run per stretch of equal levels
+func encodeDefinitionLevels(defined []bool) []byte {
+	var out []byte
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		out = binary.AppendUvarint(out, uint64(end-start)<<1)
+		if defined[start] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		start = end
+	}
+	return out
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Thrift compact protocol type codes
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter encodes the thrift compact protocol, which Parquet uses for
+// page headers and the file footer
+type compactWriter struct {
+	b         []byte
+	lastField []int16
+}
+
+func (c *compactWriter) beginStruct() {
+	c.lastField = append(c.lastField, 0)
+}
+
+func (c *compactWriter) endStruct() {
+	c.b = append(c.b, 0)
+	c.lastField = c.lastField[:len(c.lastField)-1]
+}
+
+func (c *compactWriter) field(id int16, typ byte) {
+	last := &c.lastField[len(c.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		c.b = append(c.b, byte(delta)<<4|typ)
+	} else {
+		c.b = append(c.b, typ)
+		c.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (c *compactWriter) zigzag(v int64) {
+	c.b = binary.AppendUvarint(c.b, uint64(v<<1^v>>63))
+}
+
+func (c *compactWriter) i32(id int16, v int32) {
+	c.field(id, compactI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compactWriter) i64(id int16, v int64) {
+	c.field(id, compactI64)
+	c.zigzag(v)
+}
+
+func (c *compactWriter) binary(id int16, s string) {
+	c.field(id, compactBinary)
+	c.rawBinary(s)
+}
+
+func (c *compactWriter) rawBinary(s string) {
+	c.b = binary.AppendUvarint(c.b, uint64(len(s)))
+	c.b = append(c.b, s...)
+}
+
+func (c *compactWriter) structField(id int16) {
+	c.field(id, compactStruct)
+	c.beginStruct()
+}
+
+// list writes a list header; the caller writes the n elements
+func (c *compactWriter) list(id int16, elem byte, n int) {
+	c.field(id, compactList)
+	if n < 15 {
+		c.b = append(c.b, byte(n)<<4|elem)
+		return
+	}
+	c.b = append(c.b, 0xf0|elem)
+	c.b = binary.AppendUvarint(c.b, uint64(n))
+}
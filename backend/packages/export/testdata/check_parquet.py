@@ -0,0 +1,16 @@
+"""Checks screener.parquet with pyarrow: python3 check_parquet.py [file]"""
+import sys
+
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+path = sys.argv[1] if len(sys.argv) > 1 else "screener.parquet"
+f = pq.ParquetFile(path)
+
+assert f.metadata.num_row_groups == 2, f.metadata
+assert f.schema_arrow == pa.schema([("ticker", pa.string()), ("pe_ratio", pa.float64())]), f.schema_arrow
+assert f.read().to_pylist() == [
+    {"ticker": "AAPL", "pe_ratio": 25.5},
+    {"ticker": "A&B <C>", "pe_ratio": None},
+], f.read().to_pylist()
+print("ok")
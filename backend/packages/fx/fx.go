@@ -0,0 +1,237 @@
+// Package fx stores daily exchange rates against the US dollar and converts
+// prices between currencies
+package fx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// Base is the currency rates are stored against
+const Base = "USD"
+
+// ErrNoRate is returned when a currency has no rate on or before a date
+var ErrNoRate = errors.New("no exchange rate")
+
+// Targets are synced besides the securities' own currencies, so that screens
+// can be converted into them
+var Targets = []string{"EUR", "GBP", "CHF", "JPY"}
+
+// Source provides daily forex closes such as EURUSD.FOREX, e.g.
+// *eodhd.Client
+type Source interface {
+	GetEODData(ticker string, from, to string) ([]eodhd.EODData, error)
+}
+
+// minorUnits are currencies some exchanges quote prices in, as fractions of
+// a major currency: London in pence, Johannesburg in cents and Tel Aviv in
+// agorot
+var minorUnits = map[string]struct {
+	Major    string
+	PerMajor float64
+}{
+	"GBX": {"GBP", 100},
+	"ZAC": {"ZAR", 100},
+	"ILA": {"ILS", 100},
+}
+
+// minorSpellings are the mixed-case codes some data sources use for minor
+// units, which upper-casing would confuse with their major currency
+var minorSpellings = map[string]string{"GBp": "GBX", "ZAc": "ZAC", "ILa": "ILA"}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Normalize upper-cases a currency code such as "eur" and checks it is three
+// letters. Pence spelt "GBp" become GBX.
+func Normalize(currency string) (string, error) {
+	c := strings.TrimSpace(currency)
+	if minor, ok := minorSpellings[c]; ok {
+		return minor, nil
+	}
+	c = strings.ToUpper(c)
+	if !currencyCode.MatchString(c) {
+		return "", fmt.Errorf("invalid currency %q: use a three-letter code such as EUR", currency)
+	}
+	return c, nil
+}
+
+// Major returns the currency rates are stored for and how many units of
+// currency it is worth, e.g. GBP and 100 for GBX
+func Major(currency string) (string, float64) {
+	if m, ok := minorUnits[currency]; ok {
+		return m.Major, m.PerMajor
+	}
+	return currency, 1
+}
+
+// historyStart is where a currency without stored rates is synced from
+const historyStart = "2000-01-01"
+
+// Sync stores the currency's rates from its latest stored date through to,
+// returning how many were fetched. Minor units sync their major currency.
+func Sync(db *sql.DB, source Source, currency string, to time.Time) (int, error) {
+	major, _ := Major(currency)
+	if major == Base {
+		return 0, nil
+	}
+
+	var latest sql.NullString
+	if err := db.QueryRow("SELECT MAX(date) FROM fx_rates WHERE currency = ?", major).Scan(&latest); err != nil {
+		return 0, fmt.Errorf("failed to query fx rates: %w", err)
+	}
+	from := historyStart
+	if latest.Valid {
+		from = latest.String
+	}
+
+	symbol := major + Base + ".FOREX"
+	closes, err := source.GetEODData(symbol, from, to.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("error getting %s: %w", symbol, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, c := range closes {
+		if c.Close <= 0 {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO fx_rates (currency, date, rate) VALUES (?, ?, ?)
+			ON CONFLICT(currency, date) DO UPDATE SET rate = excluded.rate`,
+			major, c.Date, c.Close,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save %s on %s: %w", symbol, c.Date, err)
+		}
+	}
+	return len(closes), tx.Commit()
+}
+
+// SyncAll syncs the currencies securities are quoted in and the Targets
+func SyncAll(db *sql.DB, source Source, to time.Time) error {
+	rows, err := db.Query("SELECT DISTINCT currency FROM fundamentals WHERE currency IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("failed to query currencies: %w", err)
+	}
+	currencies := slices.Clone(Targets)
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			rows.Close()
+			return err
+		}
+		currencies = append(currencies, c)
+	}
+	rows.Close()
+
+	synced := map[string]bool{}
+	var errs []error
+	for _, c := range currencies {
+		major, _ := Major(c)
+		if synced[major] {
+			continue
+		}
+		synced[major] = true
+		if _, err := Sync(db, source, major, to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RateAsOf returns the US dollars one unit of currency was worth on or
+// before date (YYYY-MM-DD)
+func RateAsOf(db *sql.DB, currency, date string) (float64, error) {
+	major, perMajor := Major(currency)
+	if major == Base {
+		return 1 / perMajor, nil
+	}
+
+	var rate float64
+	err := db.QueryRow(`
+		SELECT rate FROM fx_rates
+		WHERE currency = ? AND date <= ?
+		ORDER BY date DESC LIMIT 1`, major, date,
+	).Scan(&rate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w for %s on %s", ErrNoRate, currency, date)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query fx rates: %w", err)
+	}
+	return rate / perMajor, nil
+}
+
+// Convert converts an amount between currencies at the rates on or before
+// date. A minor unit and its major currency, such as GBX and GBP, convert
+// without rates.
+func Convert(db *sql.DB, amount float64, from, to, date string) (float64, error) {
+	fromMajor, fromPer := Major(from)
+	toMajor, toPer := Major(to)
+	if fromMajor == toMajor {
+		return amount * toPer / fromPer, nil
+	}
+	fromRate, err := RateAsOf(db, from, date)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := RateAsOf(db, to, date)
+	if err != nil {
+		return 0, err
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// ConversionExpr is SQL for the factor converting amounts in the currency
+// held by column into target, which must be normalized, at the latest rates.
+// It is NULL when either currency has no rate.
+func ConversionExpr(column, target string) string {
+	return fmt.Sprintf("(CASE WHEN %s = '%s' THEN 1.0 ELSE %s / %s END)",
+		column, target, usdExpr(column), usdLiteral(target))
+}
+
+// usdExpr is SQL for the US dollars one unit of the currency held by column
+// is worth
+func usdExpr(column string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "(CASE %s WHEN '%s' THEN 1.0", column, Base)
+	minors := make([]string, 0, len(minorUnits))
+	for c := range minorUnits {
+		minors = append(minors, c)
+	}
+	slices.Sort(minors)
+	for _, c := range minors {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %s", c, usdLiteral(c))
+	}
+	fmt.Fprintf(&b, " ELSE %s END)", latestRate(column))
+	return b.String()
+}
+
+// usdLiteral is SQL for the US dollars one unit of a known currency is worth
+func usdLiteral(currency string) string {
+	major, perMajor := Major(currency)
+	if major == Base {
+		return fmt.Sprintf("%g", 1/perMajor)
+	}
+	rate := latestRate("'" + major + "'")
+	if perMajor != 1 {
+		return fmt.Sprintf("(%s / %g)", rate, perMajor)
+	}
+	return rate
+}
+
+func latestRate(currency string) string {
+	return fmt.Sprintf("(SELECT fx.rate FROM fx_rates fx WHERE fx.currency = %s ORDER BY fx.date DESC LIMIT 1)", currency)
+}
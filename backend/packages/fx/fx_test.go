@@ -0,0 +1,161 @@
+package fx
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE fundamentals (
+			ticker TEXT PRIMARY KEY,
+			currency TEXT
+		);
+		CREATE TABLE fx_rates (
+			currency TEXT NOT NULL,
+			date TEXT NOT NULL,
+			rate REAL NOT NULL,
+			PRIMARY KEY (currency, date)
+		);`)
+	if err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return db
+}
+
+// fakeSource serves fixed closes per symbol and records the requests
+type fakeSource struct {
+	closes   map[string][]eodhd.EODData
+	requests []string
+}
+
+func (s *fakeSource) GetEODData(ticker string, from, to string) ([]eodhd.EODData, error) {
+	s.requests = append(s.requests, ticker+" from "+from)
+	closes, ok := s.closes[ticker]
+	if !ok {
+		return nil, errors.New("unknown symbol " + ticker)
+	}
+	return closes, nil
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"eur", "EUR", true},
+		{" USD ", "USD", true},
+		{"GBp", "GBX", true},
+		{"gbp", "GBP", true},
+		{"euro", "", false},
+		{"E1R", "", false},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSyncAndConvert(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO fundamentals (ticker, currency) VALUES
+		('AAPL', 'USD'), ('SAP.XETRA', 'EUR'), ('ULVR.LSE', 'GBX'), ('BARC.LSE', 'GBX')`)
+	if err != nil {
+		t.Fatalf("Failed to insert fundamentals: %v", err)
+	}
+	source := &fakeSource{closes: map[string][]eodhd.EODData{
+		"EURUSD.FOREX": {{Date: "2024-01-12", Close: 1.05}, {Date: "2024-01-15", Close: 1.10}},
+		"GBPUSD.FOREX": {{Date: "2024-01-15", Close: 1.25}},
+		"CHFUSD.FOREX": {{Date: "2024-01-15", Close: 1.15}},
+	}}
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	// JPY is unknown to the source; the others are still stored
+	if err := SyncAll(db, source, now); err == nil {
+		t.Error("Expected the JPY error")
+	}
+	if len(source.requests) != 4 {
+		t.Errorf("Expected EUR, GBP, CHF and JPY synced once each, got %v", source.requests)
+	}
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		date     string
+		want     float64
+	}{
+		{100, "EUR", "USD", "2024-01-15", 110},
+		{100, "EUR", "USD", "2024-01-13", 105},
+		{3800, "GBX", "EUR", "2024-01-15", 38 * 1.25 / 1.10},
+		{110, "USD", "EUR", "2024-01-15", 100},
+		{5, "GBX", "GBX", "2024-01-15", 5},
+		{3800, "GBX", "GBP", "2024-01-01", 38},
+	}
+	for _, tt := range tests {
+		got, err := Convert(db, tt.amount, tt.from, tt.to, tt.date)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%v %s to %s on %s) = %v, %v; want %v", tt.amount, tt.from, tt.to, tt.date, got, err, tt.want)
+		}
+	}
+	if _, err := RateAsOf(db, "EUR", "2024-01-01"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate before the first rate, got %v", err)
+	}
+
+	// The next sync continues from the latest stored rate
+	source.requests = nil
+	if _, err := Sync(db, source, "EUR", now.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if source.requests[0] != "EURUSD.FOREX from 2024-01-15" {
+		t.Errorf("Expected to continue from 2024-01-15, got %v", source.requests)
+	}
+}
+
+func TestConversionExpr(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		INSERT INTO fx_rates (currency, date, rate) VALUES
+		('EUR', '2024-01-12', 1.05), ('EUR', '2024-01-15', 1.10), ('GBP', '2024-01-15', 1.25)`)
+	if err != nil {
+		t.Fatalf("Failed to insert rates: %v", err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     sql.NullFloat64
+	}{
+		{"USD", "EUR", sql.NullFloat64{Float64: 1 / 1.10, Valid: true}},
+		{"EUR", "EUR", sql.NullFloat64{Float64: 1, Valid: true}},
+		{"GBX", "EUR", sql.NullFloat64{Float64: 1.25 / 100 / 1.10, Valid: true}},
+		{"EUR", "GBX", sql.NullFloat64{Float64: 1.10 / 1.25 * 100, Valid: true}},
+		{"EUR", "USD", sql.NullFloat64{Float64: 1.10, Valid: true}},
+		// Currencies without rates convert to NULL
+		{"JPY", "EUR", sql.NullFloat64{}},
+		{"EUR", "CHF", sql.NullFloat64{}},
+	}
+	for _, tt := range tests {
+		var got sql.NullFloat64
+		if err := db.QueryRow("SELECT "+ConversionExpr("c.currency", tt.to)+" FROM (SELECT ? AS currency) c", tt.from).Scan(&got); err != nil {
+			t.Fatalf("%s to %s: %v", tt.from, tt.to, err)
+		}
+		if got.Valid != tt.want.Valid || math.Abs(got.Float64-tt.want.Float64) > 1e-9 {
+			t.Errorf("%s to %s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
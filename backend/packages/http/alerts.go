@@ -0,0 +1,271 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/finsights-ai/backend/packages/alerts"
+	"github.com/finsights-ai/backend/packages/openapi"
+	"github.com/finsights-ai/backend/packages/screens"
+)
+
+type AlertsClient interface {
+	ListAlerts(userID int64) ([]alerts.Alert, error)
+	GetAlert(userID, id int64) (alerts.Alert, error)
+	CreateAlert(userID int64, a alerts.Alert) (alerts.Alert, error)
+	SetActive(userID, id int64, active bool) (alerts.Alert, error)
+	DeleteAlert(userID, id int64) error
+	ListEvents(userID, id int64) ([]alerts.Event, error)
+	ListInbox(userID int64, unreadOnly bool) ([]alerts.InboxEntry, error)
+	MarkRead(userID, id int64) error
+}
+
+// DatabaseAlertsClient implements AlertsClient using the database
+type DatabaseAlertsClient struct {
+	db *sql.DB
+}
+
+func NewDatabaseAlertsClient(db *sql.DB) *DatabaseAlertsClient {
+	return &DatabaseAlertsClient{db: db}
+}
+
+func (c *DatabaseAlertsClient) ListAlerts(userID int64) ([]alerts.Alert, error) {
+	return alerts.ListAlerts(c.db, userID)
+}
+
+func (c *DatabaseAlertsClient) GetAlert(userID, id int64) (alerts.Alert, error) {
+	return alerts.GetAlert(c.db, userID, id)
+}
+
+func (c *DatabaseAlertsClient) CreateAlert(userID int64, a alerts.Alert) (alerts.Alert, error) {
+	return alerts.CreateAlert(c.db, userID, a)
+}
+
+func (c *DatabaseAlertsClient) SetActive(userID, id int64, active bool) (alerts.Alert, error) {
+	return alerts.SetActive(c.db, userID, id, active)
+}
+
+func (c *DatabaseAlertsClient) DeleteAlert(userID, id int64) error {
+	return alerts.DeleteAlert(c.db, userID, id)
+}
+
+func (c *DatabaseAlertsClient) ListEvents(userID, id int64) ([]alerts.Event, error) {
+	return alerts.ListEvents(c.db, userID, id)
+}
+
+func (c *DatabaseAlertsClient) ListInbox(userID int64, unreadOnly bool) ([]alerts.InboxEntry, error) {
+	return alerts.ListInbox(c.db, userID, unreadOnly)
+}
+
+func (c *DatabaseAlertsClient) MarkRead(userID, id int64) error {
+	return alerts.MarkRead(c.db, userID, id)
+}
+
+// AlertsHandler serves alert definitions, their firing history and the in-app inbox
+type AlertsHandler struct {
+	client AlertsClient
+	// dispatcher delivers the alerts, so only its channels can be chosen
+	dispatcher *alerts.Dispatcher
+}
+
+func NewAlertsHandler(client AlertsClient, dispatcher *alerts.Dispatcher) *AlertsHandler {
+	return &AlertsHandler{client: client, dispatcher: dispatcher}
+}
+
+// RegisterRoutes registers the alert and notification inbox endpoints
+func (h *AlertsHandler) RegisterRoutes(rt *Router) {
+	rt.Protected("/api/alerts", h.Alerts,
+		openapi.Spec{Method: http.MethodGet, ID: "listAlerts", Summary: "List alerts", Tag: "alerts", Response: []alerts.Alert{}},
+		openapi.Spec{Method: http.MethodPost, ID: "createAlert", Summary: "Create an alert", Tag: "alerts", Body: alerts.Alert{}, Status: http.StatusCreated, Response: alerts.Alert{}},
+	)
+	rt.Protected("/api/alerts/{id}", h.Alert,
+		openapi.Spec{Method: http.MethodGet, ID: "getAlert", Summary: "Get an alert", Tag: "alerts", Response: alerts.Alert{}},
+		openapi.Spec{Method: http.MethodPatch, ID: "setAlertActive", Summary: "Pause or resume an alert", Tag: "alerts", Body: AlertStateRequest{}, Response: alerts.Alert{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "deleteAlert", Summary: "Delete an alert", Tag: "alerts", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/alerts/{id}/events", h.Events,
+		openapi.Spec{Method: http.MethodGet, ID: "listAlertEvents", Summary: "List an alert's firings", Tag: "alerts", Response: []alerts.Event{}},
+	)
+	rt.Protected("/api/notifications", h.Inbox,
+		openapi.Spec{Method: http.MethodGet, ID: "listNotifications", Summary: "List inbox notifications", Tag: "alerts",
+			Query:    []*openapi.Parameter{{Name: "unread", Description: "Only return unread notifications", Schema: &openapi.Schema{Type: "boolean"}}},
+			Response: []alerts.InboxEntry{}},
+	)
+	rt.Protected("/api/notifications/{id}/read", h.MarkRead,
+		openapi.Spec{Method: http.MethodPost, ID: "markNotificationRead", Summary: "Mark a notification as read", Tag: "alerts", Status: http.StatusNoContent},
+	)
+}
+
+// AlertStateRequest is the body for pausing or resuming an alert
+type AlertStateRequest struct {
+	Active bool `json:"active"`
+}
+
+// Alerts handles /api/alerts (list and create)
+func (h *AlertsHandler) Alerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.client.ListAlerts(userID)
+		if err != nil {
+			h.sendAlertError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		var a alerts.Alert
+		if !decodeJSON(w, r, &a) {
+			return
+		}
+		if err := a.Validate(); err != nil {
+			sendError(w, http.StatusBadRequest, "INVALID_ALERT", "Invalid alert: "+err.Error())
+			return
+		}
+		if !h.dispatcher.Handles(a.Channel) {
+			sendError(w, http.StatusBadRequest, "INVALID_ALERT", fmt.Sprintf("Invalid alert: channel %q is not configured on this server", a.Channel))
+			return
+		}
+		created, err := h.client.CreateAlert(userID, a)
+		if err != nil {
+			h.sendAlertError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusCreated, created)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// Alert handles /api/alerts/{id} (get, pause/resume and delete)
+func (h *AlertsHandler) Alert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Alert ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a, err := h.client.GetAlert(userID, id)
+		if err != nil {
+			h.sendAlertError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, a)
+
+	case http.MethodPatch:
+		var req AlertStateRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		a, err := h.client.SetActive(userID, id, req.Active)
+		if err != nil {
+			h.sendAlertError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, a)
+
+	case http.MethodDelete:
+		if err := h.client.DeleteAlert(userID, id); err != nil {
+			h.sendAlertError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET, PATCH and DELETE methods are allowed")
+	}
+}
+
+// Events handles /api/alerts/{id}/events
+func (h *AlertsHandler) Events(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Alert ID")
+	if !ok {
+		return
+	}
+
+	events, err := h.client.ListEvents(userID, id)
+	if err != nil {
+		h.sendAlertError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, events)
+}
+
+// Inbox handles /api/notifications; pass unread=true to hide read notifications
+func (h *AlertsHandler) Inbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.client.ListInbox(userID, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		h.sendAlertError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, entries)
+}
+
+// MarkRead handles /api/notifications/{id}/read
+func (h *AlertsHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Notification ID")
+	if !ok {
+		return
+	}
+
+	if err := h.client.MarkRead(userID, id); err != nil {
+		h.sendAlertError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AlertsHandler) sendAlertError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, alerts.ErrNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Alert not found")
+	case errors.Is(err, alerts.ErrNotificationNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Notification not found")
+	case errors.Is(err, screens.ErrNotFound):
+		sendError(w, http.StatusBadRequest, "INVALID_ALERT", "Invalid alert: screen not found")
+	default:
+		log.Printf("Error accessing alerts: %v", err)
+		sendError(w, http.StatusInternalServerError, "ALERTS_ERROR", "Failed to access alerts")
+	}
+}
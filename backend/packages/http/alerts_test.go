@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/alerts"
+	"github.com/finsights-ai/backend/packages/auth"
+)
+
+// recordingAlertsClient stores created alerts and fails on anything else
+type recordingAlertsClient struct {
+	AlertsClient
+	created []alerts.Alert
+}
+
+func (c *recordingAlertsClient) CreateAlert(userID int64, a alerts.Alert) (alerts.Alert, error) {
+	c.created = append(c.created, a)
+	return a, nil
+}
+
+func TestCreateAlertRequiresConfiguredChannel(t *testing.T) {
+	client := &recordingAlertsClient{}
+	// No SMTP relay, so e-mail isn't available
+	handler := NewAlertsHandler(client, alerts.NewDispatcher().Register(alerts.ChannelInbox, alerts.NewInboxNotifier(nil)))
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/alerts", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, auth.User{ID: 7}))
+		w := httptest.NewRecorder()
+		handler.Alerts(w, req)
+		return w
+	}
+
+	w := create(`{"name":"Cheap KO","kind":"metric","ticker":"KO","field":"close","operator":"<","value":40,"channel":"email","target":"a@example.com"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unconfigured channel, got %d", w.Code)
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || !strings.Contains(body.Message, "not configured") {
+		t.Errorf("Expected the channel reported, got %+v, %v", body, err)
+	}
+
+	if w := create(`{"name":"Cheap KO","kind":"metric","ticker":"KO","field":"close","operator":"<","value":40,"channel":"inbox"}`); w.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for the inbox, got %d: %s", w.Code, w.Body)
+	}
+	if len(client.created) != 1 || client.created[0].Channel != alerts.ChannelInbox {
+		t.Errorf("Expected only the inbox alert created, got %+v", client.created)
+	}
+}
@@ -0,0 +1,321 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/auth"
+	"github.com/finsights-ai/backend/packages/openapi"
+)
+
+type AuthClient interface {
+	Register(email, password string) (auth.User, error)
+	Login(email, password string) (auth.Session, error)
+	Logout(token string) error
+	UserForToken(token string) (auth.User, error)
+	ListAPIKeys(userID int64) ([]auth.APIKey, error)
+	CreateAPIKey(userID int64, name string) (auth.APIKey, error)
+	DeleteAPIKey(userID, id int64) error
+}
+
+// DatabaseAuthClient implements AuthClient using the database
+type DatabaseAuthClient struct {
+	db *sql.DB
+}
+
+func NewDatabaseAuthClient(db *sql.DB) *DatabaseAuthClient {
+	return &DatabaseAuthClient{db: db}
+}
+
+func (c *DatabaseAuthClient) Register(email, password string) (auth.User, error) {
+	return auth.Register(c.db, email, password)
+}
+
+func (c *DatabaseAuthClient) Login(email, password string) (auth.Session, error) {
+	user, err := auth.Login(c.db, email, password)
+	if err != nil {
+		return auth.Session{}, err
+	}
+	return auth.CreateSession(c.db, user)
+}
+
+func (c *DatabaseAuthClient) Logout(token string) error {
+	return auth.DeleteSession(c.db, token)
+}
+
+func (c *DatabaseAuthClient) UserForToken(token string) (auth.User, error) {
+	return auth.UserForToken(c.db, token)
+}
+
+func (c *DatabaseAuthClient) ListAPIKeys(userID int64) ([]auth.APIKey, error) {
+	return auth.ListAPIKeys(c.db, userID)
+}
+
+func (c *DatabaseAuthClient) CreateAPIKey(userID int64, name string) (auth.APIKey, error) {
+	return auth.CreateAPIKey(c.db, userID, name)
+}
+
+func (c *DatabaseAuthClient) DeleteAPIKey(userID, id int64) error {
+	return auth.DeleteAPIKey(c.db, userID, id)
+}
+
+type contextKey int
+
+const userContextKey contextKey = iota
+
+// UserFromContext returns the user authenticated by RequireAuth
+func UserFromContext(ctx context.Context) (auth.User, bool) {
+	user, ok := ctx.Value(userContextKey).(auth.User)
+	return user, ok
+}
+
+// bearerToken reads the credential from "Authorization: Bearer <token>" or,
+// for scripts, the X-API-Key header
+func bearerToken(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// RequireAuth returns middleware that rejects requests without a valid session
+// token or API key and stores the authenticated user in the request context
+func RequireAuth(client AuthClient) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				sendError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+				return
+			}
+
+			user, err := client.UserForToken(token)
+			if errors.Is(err, auth.ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				sendError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid or expired credentials")
+				return
+			}
+			if err != nil {
+				log.Printf("Error authenticating request: %v", err)
+				sendError(w, http.StatusInternalServerError, "AUTH_ERROR", "Failed to authenticate request")
+				return
+			}
+
+			next(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+		}
+	}
+}
+
+// requireUserID returns the authenticated user's ID, writing a 401 response if
+// the handler was mounted without RequireAuth
+func requireUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return 0, false
+	}
+	return user.ID, true
+}
+
+// AuthHandler serves registration, login and API key management
+type AuthHandler struct {
+	client AuthClient
+}
+
+func NewAuthHandler(client AuthClient) *AuthHandler {
+	return &AuthHandler{client: client}
+}
+
+// RegisterRoutes registers the account, session and API key endpoints
+func (h *AuthHandler) RegisterRoutes(rt *Router) {
+	rt.Public("/api/auth/register", h.Register,
+		openapi.Spec{Method: http.MethodPost, ID: "register", Summary: "Create an account", Tag: "auth", Body: CredentialsRequest{}, Status: http.StatusCreated, Response: auth.User{}},
+	)
+	rt.Public("/api/auth/login", h.Login,
+		openapi.Spec{Method: http.MethodPost, ID: "login", Summary: "Start a session", Tag: "auth", Body: CredentialsRequest{}, Response: auth.Session{}},
+	)
+	rt.Protected("/api/auth/logout", h.Logout,
+		openapi.Spec{Method: http.MethodPost, ID: "logout", Summary: "End the current session", Tag: "auth", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/auth/me", h.Me,
+		openapi.Spec{Method: http.MethodGet, ID: "getCurrentUser", Summary: "Get the authenticated user", Tag: "auth", Response: auth.User{}},
+	)
+	rt.Protected("/api/auth/api-keys", h.APIKeys,
+		openapi.Spec{Method: http.MethodGet, ID: "listAPIKeys", Summary: "List API keys", Tag: "auth", Response: []auth.APIKey{}},
+		openapi.Spec{Method: http.MethodPost, ID: "createAPIKey", Summary: "Create an API key; the key is only returned once", Tag: "auth", Body: APIKeyRequest{}, Status: http.StatusCreated, Response: auth.APIKey{}},
+	)
+	rt.Protected("/api/auth/api-keys/{id}", h.APIKey,
+		openapi.Spec{Method: http.MethodDelete, ID: "deleteAPIKey", Summary: "Revoke an API key", Tag: "auth", Status: http.StatusNoContent},
+	)
+}
+
+// CredentialsRequest is the body for registration and login
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// APIKeyRequest is the body for creating an API key
+type APIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// Register handles POST /api/auth/register
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	var req CredentialsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := auth.ValidateRegistration(req.Email, req.Password); err != nil {
+		sendError(w, http.StatusBadRequest, "INVALID_REGISTRATION", err.Error())
+		return
+	}
+
+	user, err := h.client.Register(req.Email, req.Password)
+	if errors.Is(err, auth.ErrEmailTaken) {
+		sendError(w, http.StatusConflict, "EMAIL_TAKEN", "An account with this email already exists")
+		return
+	}
+	if err != nil {
+		h.sendAuthError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusCreated, user)
+}
+
+// Login handles POST /api/auth/login and returns a session token
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	var req CredentialsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	session, err := h.client.Login(req.Email, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		sendError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
+		return
+	}
+	if err != nil {
+		h.sendAuthError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, session)
+}
+
+// Logout handles POST /api/auth/logout and ends the current session
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	if err := h.client.Logout(bearerToken(r)); err != nil {
+		h.sendAuthError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me handles GET /api/auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	sendJSON(w, http.StatusOK, user)
+}
+
+// APIKeys handles /api/auth/api-keys (list and create)
+func (h *AuthHandler) APIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := h.client.ListAPIKeys(userID)
+		if err != nil {
+			h.sendAuthError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, keys)
+
+	case http.MethodPost:
+		var req APIKeyRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			sendError(w, http.StatusBadRequest, "INVALID_API_KEY", "Invalid API key: name is required")
+			return
+		}
+		key, err := h.client.CreateAPIKey(userID, req.Name)
+		if err != nil {
+			h.sendAuthError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusCreated, key)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// APIKey handles DELETE /api/auth/api-keys/{id}
+func (h *AuthHandler) APIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only DELETE method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "API key ID")
+	if !ok {
+		return
+	}
+
+	if err := h.client.DeleteAPIKey(userID, id); err != nil {
+		h.sendAuthError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) sendAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "API key not found")
+	default:
+		log.Printf("Error accessing accounts: %v", err)
+		sendError(w, http.StatusInternalServerError, "AUTH_ERROR", "Failed to process request")
+	}
+}
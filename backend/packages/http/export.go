@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/export"
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+// exportFormat reads the requested file format from the format parameter,
+// falling back to the Accept header. ok is false for JSON responses.
+func exportFormat(r *http.Request) (format export.Format, ok bool, err error) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		format, ok = export.FormatForAccept(r.Header.Get("Accept"))
+		return format, ok, nil
+	}
+	if name == "json" {
+		return "", false, nil
+	}
+	format, err = export.ParseFormat(name)
+	return format, err == nil, err
+}
+
+// exportResults streams every row matching filter as a file download. The
+// download is only committed once the first row has been read, so failures
+// to start the writer or run the query are reported as errors. Errors after
+// that can only be logged, as the status has been sent.
+func exportResults(w http.ResponseWriter, client ScreenerClient, filter screener.ScreenerFilter, format export.Format, fields []screener.Field) {
+	filter.Limit = 0
+	filter.Offset = 0
+
+	filename := fmt.Sprintf("screener-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+	out := &pendingDownload{w: w, contentType: format.ContentType(), filename: filename}
+
+	columns := make([]export.Column, len(fields))
+	for i, f := range fields {
+		columns[i] = export.Column{Name: f.Name, Type: export.Number}
+		if f.Type == screener.TypeString {
+			columns[i].Type = export.String
+		}
+	}
+
+	writer, err := export.NewWriter(format, out, columns)
+	if err != nil {
+		log.Printf("Error starting %s export: %v", format, err)
+		sendError(w, http.StatusInternalServerError, "EXPORT_ERROR", "Failed to start the export")
+		return
+	}
+
+	row := make([]any, len(columns))
+	err = client.StreamRows(filter, func(result screener.Row) error {
+		if err := out.commit(); err != nil {
+			return err
+		}
+		for i, c := range columns {
+			row[i] = result[c.Name]
+		}
+		return writer.WriteRow(row)
+	})
+	if err != nil {
+		log.Printf("Error streaming %s export: %v", format, err)
+		if !out.committed {
+			sendError(w, http.StatusInternalServerError, "SCREENER_ERROR", "Failed to fetch screener data")
+		}
+		return
+	}
+
+	// Results without rows are still a complete file
+	if err := out.commit(); err != nil {
+		log.Printf("Error writing %s export: %v", format, err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("Error finishing %s export: %v", format, err)
+	}
+}
+
+// pendingDownload holds back what is written to it, such as a file's header,
+// until commit sets the download headers and sends it on
+type pendingDownload struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	buf         bytes.Buffer
+	committed   bool
+}
+
+func (d *pendingDownload) Write(p []byte) (int, error) {
+	if !d.committed {
+		return d.buf.Write(p)
+	}
+	return d.w.Write(p)
+}
+
+func (d *pendingDownload) commit() error {
+	if d.committed {
+		return nil
+	}
+	d.committed = true
+	d.w.Header().Set("Content-Type", d.contentType)
+	d.w.Header().Set("Content-Disposition", `attachment; filename="`+d.filename+`"`)
+	_, err := d.buf.WriteTo(d.w)
+	return err
+}
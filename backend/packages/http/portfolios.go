@@ -0,0 +1,286 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/openapi"
+	"github.com/finsights-ai/backend/packages/portfolio"
+)
+
+type PortfolioClient interface {
+	ListPortfolios(userID int64) ([]portfolio.Portfolio, error)
+	CreatePortfolio(userID int64, name, description string) (portfolio.Portfolio, error)
+	DeletePortfolio(userID, id int64) error
+	GetSummary(userID, id int64) (portfolio.Summary, error)
+	ListTransactions(userID, id int64) ([]portfolio.Transaction, error)
+	AddTransaction(userID int64, t portfolio.Transaction) (portfolio.Transaction, error)
+	DeleteTransaction(userID, id, transactionID int64) error
+	SyncDividends(userID, id int64) ([]portfolio.Transaction, error)
+}
+
+// DatabasePortfolioClient implements PortfolioClient using the database.
+// Dividend syncing is only available when a dividend source is configured.
+type DatabasePortfolioClient struct {
+	db        *sql.DB
+	dividends portfolio.DividendSource
+}
+
+func NewDatabasePortfolioClient(db *sql.DB, dividends portfolio.DividendSource) *DatabasePortfolioClient {
+	return &DatabasePortfolioClient{db: db, dividends: dividends}
+}
+
+func (c *DatabasePortfolioClient) ListPortfolios(userID int64) ([]portfolio.Portfolio, error) {
+	return portfolio.ListPortfolios(c.db, userID)
+}
+
+func (c *DatabasePortfolioClient) CreatePortfolio(userID int64, name, description string) (portfolio.Portfolio, error) {
+	return portfolio.CreatePortfolio(c.db, userID, name, description)
+}
+
+func (c *DatabasePortfolioClient) DeletePortfolio(userID, id int64) error {
+	return portfolio.DeletePortfolio(c.db, userID, id)
+}
+
+func (c *DatabasePortfolioClient) GetSummary(userID, id int64) (portfolio.Summary, error) {
+	return portfolio.GetSummary(c.db, userID, id)
+}
+
+func (c *DatabasePortfolioClient) ListTransactions(userID, id int64) ([]portfolio.Transaction, error) {
+	return portfolio.ListTransactions(c.db, userID, id)
+}
+
+func (c *DatabasePortfolioClient) AddTransaction(userID int64, t portfolio.Transaction) (portfolio.Transaction, error) {
+	return portfolio.AddTransaction(c.db, userID, t)
+}
+
+func (c *DatabasePortfolioClient) DeleteTransaction(userID, id, transactionID int64) error {
+	return portfolio.DeleteTransaction(c.db, userID, id, transactionID)
+}
+
+func (c *DatabasePortfolioClient) SyncDividends(userID, id int64) ([]portfolio.Transaction, error) {
+	if c.dividends == nil {
+		return nil, errDividendSourceMissing
+	}
+	return portfolio.SyncDividends(c.db, c.dividends, userID, id)
+}
+
+var errDividendSourceMissing = errors.New("no dividend source configured")
+
+// PortfolioHandler serves the portfolio and transaction endpoints
+type PortfolioHandler struct {
+	client PortfolioClient
+}
+
+func NewPortfolioHandler(client PortfolioClient) *PortfolioHandler {
+	return &PortfolioHandler{client: client}
+}
+
+// RegisterRoutes registers the portfolio endpoints
+func (h *PortfolioHandler) RegisterRoutes(rt *Router) {
+	rt.Protected("/api/portfolios", h.Portfolios,
+		openapi.Spec{Method: http.MethodGet, ID: "listPortfolios", Summary: "List portfolios", Tag: "portfolios", Response: []portfolio.Portfolio{}},
+		openapi.Spec{Method: http.MethodPost, ID: "createPortfolio", Summary: "Create a portfolio", Tag: "portfolios", Body: PortfolioRequest{}, Status: http.StatusCreated, Response: portfolio.Portfolio{}},
+	)
+	rt.Protected("/api/portfolios/{id}", h.Portfolio,
+		openapi.Spec{Method: http.MethodGet, ID: "getPortfolio", Summary: "Get positions, P&L and returns", Tag: "portfolios", Response: portfolio.Summary{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "deletePortfolio", Summary: "Delete a portfolio", Tag: "portfolios", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/portfolios/{id}/transactions", h.Transactions,
+		openapi.Spec{Method: http.MethodGet, ID: "listTransactions", Summary: "List transactions", Tag: "portfolios", Response: []portfolio.Transaction{}},
+		openapi.Spec{Method: http.MethodPost, ID: "addTransaction", Summary: "Record a transaction", Tag: "portfolios", Body: portfolio.Transaction{}, Status: http.StatusCreated, Response: portfolio.Transaction{}},
+	)
+	rt.Protected("/api/portfolios/{id}/transactions/{transactionID}", h.Transaction,
+		openapi.Spec{Method: http.MethodDelete, ID: "deleteTransaction", Summary: "Delete a transaction", Tag: "portfolios", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/portfolios/{id}/dividends/sync", h.SyncDividends,
+		openapi.Spec{Method: http.MethodPost, ID: "syncDividends", Summary: "Book missing dividend payments", Tag: "portfolios", Response: []portfolio.Transaction{}},
+	)
+}
+
+// PortfolioRequest is the body for creating a portfolio
+type PortfolioRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Portfolios handles /api/portfolios (list and create)
+func (h *PortfolioHandler) Portfolios(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.client.ListPortfolios(userID)
+		if err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		var req PortfolioRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			sendError(w, http.StatusBadRequest, "INVALID_PORTFOLIO", "Name is required")
+			return
+		}
+		created, err := h.client.CreatePortfolio(userID, req.Name, req.Description)
+		if err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusCreated, created)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// Portfolio handles /api/portfolios/{id} (summary with positions and performance, delete)
+func (h *PortfolioHandler) Portfolio(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Portfolio ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		summary, err := h.client.GetSummary(userID, id)
+		if err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, summary)
+
+	case http.MethodDelete:
+		if err := h.client.DeletePortfolio(userID, id); err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and DELETE methods are allowed")
+	}
+}
+
+// Transactions handles /api/portfolios/{id}/transactions (list and record)
+func (h *PortfolioHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Portfolio ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		txs, err := h.client.ListTransactions(userID, id)
+		if err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, txs)
+
+	case http.MethodPost:
+		var t portfolio.Transaction
+		if !decodeJSON(w, r, &t) {
+			return
+		}
+		t.PortfolioID = id
+		if err := t.Validate(); err != nil {
+			sendError(w, http.StatusBadRequest, "INVALID_TRANSACTION", "Invalid transaction: "+err.Error())
+			return
+		}
+		created, err := h.client.AddTransaction(userID, t)
+		if err != nil {
+			h.sendPortfolioError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusCreated, created)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// Transaction handles /api/portfolios/{id}/transactions/{transactionID} (delete)
+func (h *PortfolioHandler) Transaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only DELETE method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Portfolio ID")
+	if !ok {
+		return
+	}
+	transactionID, ok := parsePathID(w, r, "transactionID", "Transaction ID")
+	if !ok {
+		return
+	}
+
+	if err := h.client.DeleteTransaction(userID, id, transactionID); err != nil {
+		h.sendPortfolioError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SyncDividends handles /api/portfolios/{id}/dividends/sync and books missing dividend payments
+func (h *PortfolioHandler) SyncDividends(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Portfolio ID")
+	if !ok {
+		return
+	}
+
+	added, err := h.client.SyncDividends(userID, id)
+	if err != nil {
+		h.sendPortfolioError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, added)
+}
+
+func (h *PortfolioHandler) sendPortfolioError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, portfolio.ErrNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Portfolio not found")
+	case errors.Is(err, portfolio.ErrTransactionNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Transaction not found")
+	case errors.Is(err, portfolio.ErrInsufficientQuantity):
+		sendError(w, http.StatusUnprocessableEntity, "INSUFFICIENT_QUANTITY", err.Error())
+	case errors.Is(err, errDividendSourceMissing):
+		sendError(w, http.StatusNotImplemented, "DIVIDENDS_UNAVAILABLE", "Dividend data source is not configured")
+	default:
+		log.Printf("Error accessing portfolio: %v", err)
+		sendError(w, http.StatusInternalServerError, "PORTFOLIO_ERROR", "Failed to access portfolio")
+	}
+}
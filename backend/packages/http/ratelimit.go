@@ -0,0 +1,324 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AnonymousPlan applies to requests without credentials, keyed by client IP
+const AnonymousPlan = "anonymous"
+
+// Plan configures the token bucket and daily quota for a class of clients
+type Plan struct {
+	// Burst is the bucket capacity, i.e. how many requests may be made at once
+	Burst int `json:"burst"`
+	// RefillPerSecond is the sustained request rate
+	RefillPerSecond float64 `json:"refill_per_second"`
+	// DailyQuota caps requests per UTC day; 0 means unlimited
+	DailyQuota int `json:"daily_quota"`
+}
+
+// DefaultPlans are used when no plan configuration is supplied
+func DefaultPlans() map[string]Plan {
+	return map[string]Plan{
+		AnonymousPlan: {Burst: 10, RefillPerSecond: 0.2, DailyQuota: 500},
+		"free":        {Burst: 20, RefillPerSecond: 1, DailyQuota: 5000},
+		"pro":         {Burst: 100, RefillPerSecond: 10, DailyQuota: 100000},
+	}
+}
+
+// ParsePlans reads a JSON object of plan name to Plan, e.g.
+// {"free": {"burst": 20, "refill_per_second": 1, "daily_quota": 5000}}
+func ParsePlans(data string) (map[string]Plan, error) {
+	var plans map[string]Plan
+	if err := json.Unmarshal([]byte(data), &plans); err != nil {
+		return nil, fmt.Errorf("invalid rate limit plans: %w", err)
+	}
+	for name, p := range plans {
+		if p.Burst < 1 || p.RefillPerSecond <= 0 || p.DailyQuota < 0 {
+			return nil, fmt.Errorf("invalid rate limit plan %q: burst and refill must be positive", name)
+		}
+	}
+	if _, ok := plans[AnonymousPlan]; !ok {
+		return nil, fmt.Errorf("rate limit plans must include %q", AnonymousPlan)
+	}
+	return plans, nil
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type quota struct {
+	day  string
+	used int
+}
+
+// RateLimiter throttles clients with an in-memory token bucket per credential
+// (or per IP for anonymous requests) and counts requests against a daily quota
+// per user. State is not shared between processes.
+type RateLimiter struct {
+	plans map[string]Plan
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	quotas    map[string]*quota
+	lastSweep time.Time
+}
+
+func NewRateLimiter(plans map[string]Plan) *RateLimiter {
+	return &RateLimiter{
+		plans:   plans,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+		quotas:  map[string]*quota{},
+	}
+}
+
+// rateLimitDecision is the outcome of a single request against the limiter
+type rateLimitDecision struct {
+	allowed    bool
+	quotaHit   bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// allow takes one token from the bucket identified by bucketKey and counts the
+// request against the quota identified by quotaKey
+func (l *RateLimiter) allow(bucketKey, quotaKey string, plan Plan) rateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.refill(bucketKey, plan, now)
+
+	d := rateLimitDecision{limit: plan.Burst}
+
+	var q *quota
+	if plan.DailyQuota > 0 {
+		day := now.UTC().Format("2006-01-02")
+		q = l.quotas[quotaKey]
+		if q == nil || q.day != day {
+			q = &quota{day: day}
+			l.quotas[quotaKey] = q
+		}
+		if q.used >= plan.DailyQuota {
+			midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+			d.quotaHit = true
+			d.remaining = int(b.tokens)
+			d.reset = midnight.Sub(now)
+			d.retryAfter = d.reset
+			return d
+		}
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / plan.RefillPerSecond * float64(time.Second))
+		d.remaining = 0
+		d.reset = wait
+		d.retryAfter = wait
+		return d
+	}
+
+	b.tokens--
+	if q != nil {
+		q.used++
+	}
+	d.allowed = true
+	d.remaining = int(b.tokens)
+	d.reset = time.Duration((float64(plan.Burst) - b.tokens) / plan.RefillPerSecond * float64(time.Second))
+	return d
+}
+
+// refill returns the bucket identified by key with the tokens accrued since
+// it was last used. Called with l.mu held.
+func (l *RateLimiter) refill(key string, plan Plan, now time.Time) *bucket {
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(plan.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(plan.Burst), b.tokens+now.Sub(b.updated).Seconds()*plan.RefillPerSecond)
+	b.updated = now
+	return b
+}
+
+// sweep drops buckets idle for over an hour and quotas from earlier days so
+// departed clients do not accumulate. Called with l.mu held.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < 10*time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > time.Hour {
+			delete(l.buckets, key)
+		}
+	}
+	day := now.UTC().Format("2006-01-02")
+	for key, q := range l.quotas {
+		if q.day != day {
+			delete(l.quotas, key)
+		}
+	}
+}
+
+// Middleware limits requests per client. When mounted inside RequireAuth the
+// authenticated user's plan applies, the bucket is keyed by the credential and
+// the daily quota is shared by all of the user's keys; otherwise the anonymous
+// plan applies per client IP.
+func (l *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		planName := AnonymousPlan
+		bucketKey := "ip:" + clientIP(r)
+		quotaKey := bucketKey
+
+		if user, ok := UserFromContext(r.Context()); ok {
+			planName = user.Plan
+			sum := sha256.Sum256([]byte(bearerToken(r)))
+			bucketKey = "key:" + hex.EncodeToString(sum[:8])
+			quotaKey = "user:" + strconv.FormatInt(user.ID, 10)
+		}
+
+		plan, ok := l.plans[planName]
+		if !ok {
+			plan = l.plans[AnonymousPlan]
+		}
+
+		d := l.allow(bucketKey, quotaKey, plan)
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(d.limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(d.remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", plan.Burst, ceilSeconds(time.Duration(float64(plan.Burst)/plan.RefillPerSecond*float64(time.Second)))))
+
+		if !d.allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(d.retryAfter)))
+			if d.quotaHit {
+				sendError(w, http.StatusTooManyRequests, "QUOTA_EXCEEDED",
+					fmt.Sprintf("Daily quota of %d requests exceeded", plan.DailyQuota))
+				return
+			}
+			sendError(w, http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests, please slow down")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// GuardAuth limits failed authentication per client IP with the anonymous
+// plan's bucket. Mounted outside RequireAuth, it reserves a token before the
+// credentials are looked up, rejecting the client when its bucket is empty,
+// and returns the token unless the response is a 401. Requests with missing
+// or guessed keys therefore cannot flood protected routes, even concurrently.
+// Authenticated requests are left to Middleware.
+func (l *RateLimiter) GuardAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan := l.plans[AnonymousPlan]
+		key := "auth:" + clientIP(r)
+
+		l.mu.Lock()
+		b := l.refill(key, plan, l.now())
+		reserved := b.tokens >= 1
+		if reserved {
+			b.tokens--
+		}
+		tokens := b.tokens
+		l.mu.Unlock()
+
+		if !reserved {
+			wait := time.Duration((1 - tokens) / plan.RefillPerSecond * float64(time.Second))
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(wait)))
+			sendError(w, http.StatusTooManyRequests, "RATE_LIMITED", "Too many failed authentication attempts, please slow down")
+			return
+		}
+
+		// The token is returned as soon as the status is known, so slow
+		// responses such as exports don't hold it
+		rec := &statusRecorder{ResponseWriter: w, onStatus: func(status int) {
+			if status != http.StatusUnauthorized {
+				l.refund(key, plan)
+			}
+		}}
+		next(rec, r)
+		if rec.status == 0 {
+			rec.setStatus(http.StatusOK)
+		}
+	}
+}
+
+// refund returns a token reserved by GuardAuth
+func (l *RateLimiter) refund(key string, plan Plan) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.refill(key, plan, l.now())
+	b.tokens = math.Min(float64(plan.Burst), b.tokens+1)
+}
+
+// statusRecorder remembers the status code written through it and reports it
+// to onStatus
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	onStatus func(status int)
+}
+
+func (r *statusRecorder) setStatus(code int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = code
+	if r.onStatus != nil {
+		r.onStatus(code)
+	}
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.setStatus(code)
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.setStatus(http.StatusOK)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed exports
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// clientIP returns the host part of the connection's remote address. Forwarded
+// headers are ignored because they can be set by the client.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,281 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/auth"
+)
+
+func newTestLimiter(plans map[string]Plan) (*RateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(plans)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	limiter, now := newTestLimiter(map[string]Plan{
+		AnonymousPlan: {Burst: 2, RefillPerSecond: 0.5},
+	})
+	handler := limiter.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/screener", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	for i := range 2 {
+		if w := do("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := do("10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the burst is used up, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected RateLimit-Remaining 0, got %q", got)
+	}
+
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if body.Error != "RATE_LIMITED" {
+		t.Errorf("Expected RATE_LIMITED, got %s", body.Error)
+	}
+
+	// Other clients have their own bucket
+	if w := do("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected a different IP to be allowed, got %d", w.Code)
+	}
+
+	*now = now.Add(2 * time.Second)
+	w = do("10.0.0.1:1234")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected a request to be allowed after refill, got %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("Expected RateLimit-Limit 2, got %q", got)
+	}
+}
+
+func TestRateLimiterDailyQuota(t *testing.T) {
+	limiter, now := newTestLimiter(map[string]Plan{
+		AnonymousPlan: {Burst: 1, RefillPerSecond: 1},
+		"free":        {Burst: 10, RefillPerSecond: 10, DailyQuota: 3},
+	})
+	handler := limiter.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	user := auth.User{ID: 7, Plan: "free"}
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/screener", nil)
+		req.Header.Set("X-API-Key", key)
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, user))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// The quota is shared between all of the user's keys
+	for i, key := range []string{"fsk_a", "fsk_b", "fsk_a"} {
+		if w := do(key); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := do("fsk_c")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the quota is used up, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "43200" {
+		t.Errorf("Expected Retry-After until midnight UTC, got %q", got)
+	}
+
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if body.Error != "QUOTA_EXCEEDED" {
+		t.Errorf("Expected QUOTA_EXCEEDED, got %s", body.Error)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if w := do("fsk_a"); w.Code != http.StatusOK {
+		t.Errorf("Expected the quota to reset the next day, got %d", w.Code)
+	}
+}
+
+// fakeAuthClient authenticates a single token
+type fakeAuthClient struct {
+	AuthClient
+	token string
+	user  auth.User
+}
+
+func (c fakeAuthClient) UserForToken(token string) (auth.User, error) {
+	if token != c.token {
+		return auth.User{}, auth.ErrInvalidToken
+	}
+	return c.user, nil
+}
+
+func TestGuardAuthLimitsFailedAttempts(t *testing.T) {
+	limiter, now := newTestLimiter(map[string]Plan{
+		AnonymousPlan: {Burst: 2, RefillPerSecond: 0.5},
+		"free":        {Burst: 10, RefillPerSecond: 10},
+	})
+	lookups := 0
+	client := fakeAuthClient{token: "fsk_good", user: auth.User{ID: 7, Plan: "free"}}
+	handler := limiter.GuardAuth(RequireAuth(countingAuthClient{client, &lookups})(limiter.Middleware(
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/watchlists", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// Authenticated requests don't use up the guard's bucket
+	for i := range 5 {
+		if w := do("fsk_good"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	for i := range 2 {
+		if w := do("fsk_guess"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Bad key %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+	lookups = 0
+	w := do("fsk_guess")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after repeated bad keys, got %d", w.Code)
+	}
+	if lookups != 0 {
+		t.Errorf("Expected the key not to be looked up once throttled, got %d lookups", lookups)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+
+	*now = now.Add(2 * time.Second)
+	if w := do("fsk_good"); w.Code != http.StatusOK {
+		t.Errorf("Expected requests to be allowed after refill, got %d", w.Code)
+	}
+}
+
+func TestGuardAuthReservesTokensForConcurrentAttempts(t *testing.T) {
+	limiter, now := newTestLimiter(map[string]Plan{
+		AnonymousPlan: {Burst: 2, RefillPerSecond: 0.5},
+	})
+	release := make(chan struct{})
+	handler := limiter.GuardAuth(RequireAuth(blockingAuthClient{fakeAuthClient{}, release})(
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	do := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/watchlists", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-API-Key", "fsk_guess")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	// Two guesses hold the bucket's tokens while their keys are looked up,
+	// so the other eight are turned away
+	codes := make(chan int, 10)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- do()
+		}()
+	}
+	throttled := 0
+	for range 8 {
+		select {
+		case code := <-codes:
+			if code == http.StatusTooManyRequests {
+				throttled++
+			}
+		case <-time.After(5 * time.Second):
+			close(release)
+			t.Fatal("Expected the guesses beyond the burst to be turned away without waiting")
+		}
+	}
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusUnauthorized {
+			t.Errorf("Expected the reserved guesses to fail authentication, got %d", code)
+		}
+	}
+	if throttled != 8 {
+		t.Errorf("Expected 8 guesses throttled, got %d", throttled)
+	}
+
+	// The bucket never went below empty, so one refilled token lets the
+	// next attempt through
+	*now = now.Add(2 * time.Second)
+	if code := do(); code != http.StatusUnauthorized {
+		t.Errorf("Expected an attempt after 2 seconds, got %d", code)
+	}
+}
+
+// blockingAuthClient rejects every key once release is closed
+type blockingAuthClient struct {
+	fakeAuthClient
+	release chan struct{}
+}
+
+func (c blockingAuthClient) UserForToken(token string) (auth.User, error) {
+	<-c.release
+	return c.fakeAuthClient.UserForToken(token)
+}
+
+// countingAuthClient counts token lookups
+type countingAuthClient struct {
+	fakeAuthClient
+	lookups *int
+}
+
+func (c countingAuthClient) UserForToken(token string) (auth.User, error) {
+	*c.lookups++
+	return c.fakeAuthClient.UserForToken(token)
+}
+
+func TestParsePlans(t *testing.T) {
+	if _, err := ParsePlans(`{"anonymous": {"burst": 5, "refill_per_second": 1}}`); err != nil {
+		t.Errorf("Expected valid plans, got %v", err)
+	}
+	if _, err := ParsePlans(`{"free": {"burst": 5, "refill_per_second": 1}}`); err == nil {
+		t.Error("Expected error when the anonymous plan is missing")
+	}
+	if _, err := ParsePlans(`{"anonymous": {"burst": 0, "refill_per_second": 1}}`); err == nil {
+		t.Error("Expected error for zero burst")
+	}
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// decodeJSON decodes the request body into v, writing a 400 response if it is malformed
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		sendError(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// parsePathID reads a positive integer path parameter, writing a 400 response if it is invalid
+func parsePathID(w http.ResponseWriter, r *http.Request, name, label string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id < 1 {
+		sendError(w, http.StatusBadRequest, "INVALID_ID", label+" must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/finsights-ai/backend/packages/openapi"
+)
+
+// Router registers handlers on a ServeMux and records each route in the
+// OpenAPI document, so the published spec always matches what is served.
+// Query parameters are validated against the document before the handler runs.
+type Router struct {
+	mux       *http.ServeMux
+	doc       *openapi.Document
+	public    func(http.HandlerFunc) http.HandlerFunc
+	protected func(http.HandlerFunc) http.HandlerFunc
+}
+
+// NewRouter creates a router. public and protected wrap handlers with the
+// middleware for anonymous and authenticated routes respectively.
+func NewRouter(mux *http.ServeMux, public, protected func(http.HandlerFunc) http.HandlerFunc) *Router {
+	doc := openapi.New("Finsights API", "1.0.0")
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"sessionToken": {Type: "http", Scheme: "bearer", Description: "Session token from /api/auth/login"},
+		"apiKey":       {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API key from /api/auth/api-keys"},
+	}
+	return &Router{mux: mux, doc: doc, public: public, protected: protected}
+}
+
+// Document returns the OpenAPI document for the routes registered so far
+func (rt *Router) Document() *openapi.Document {
+	return rt.doc
+}
+
+// Public registers a route that does not require credentials
+func (rt *Router) Public(pattern string, handler http.HandlerFunc, specs ...openapi.Spec) {
+	rt.handle(pattern, handler, false, specs)
+}
+
+// Protected registers a route that requires a session token or API key
+func (rt *Router) Protected(pattern string, handler http.HandlerFunc, specs ...openapi.Spec) {
+	rt.handle(pattern, handler, true, specs)
+}
+
+func (rt *Router) handle(pattern string, handler http.HandlerFunc, secured bool, specs []openapi.Spec) {
+	ops := map[string]*openapi.Operation{}
+	for _, spec := range specs {
+		op := rt.doc.Add(pattern, spec)
+		if secured {
+			op.Security = []map[string][]string{{"sessionToken": {}}, {"apiKey": {}}}
+			rt.doc.AddResponse(op, "401", "Missing or invalid credentials", ErrorResponse{})
+		}
+		rt.doc.AddResponse(op, "429", "Rate limit or daily quota exceeded", ErrorResponse{})
+		rt.doc.AddResponse(op, "default", "Error", ErrorResponse{})
+		ops[spec.Method] = op
+	}
+
+	wrap := rt.public
+	if secured {
+		wrap = rt.protected
+	}
+	rt.mux.HandleFunc(pattern, wrap(validateQuery(ops, handler)))
+}
+
+// validateQuery rejects requests whose query parameters do not match the
+// operation's declared parameters
+func validateQuery(ops map[string]*openapi.Operation, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if op, ok := ops[r.Method]; ok {
+			if err := op.ValidateQuery(r.URL.Query()); err != nil {
+				var verr *openapi.ValidationError
+				code := "INVALID_PARAMETER"
+				if errors.As(err, &verr) && verr.ErrorCode != "" {
+					code = verr.ErrorCode
+				}
+				sendErrorDetails(w, http.StatusBadRequest, code, "Invalid query parameter "+err.Error(), verr)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// ServeOpenAPI handles /api/openapi.json
+func (rt *Router) ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+	sendJSON(w, http.StatusOK, rt.doc)
+}
+
+// RegisterRoutes registers the OpenAPI document itself
+func (rt *Router) RegisterRoutes() {
+	rt.Public("/api/openapi.json", rt.ServeOpenAPI,
+		openapi.Spec{Method: http.MethodGet, ID: "getOpenAPI", Summary: "OpenAPI document for this API", Tag: "meta", Response: json.RawMessage{}},
+	)
+}
+
+// paginationParams documents the page and limit parameters read by parsePagination
+func paginationParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		{Name: "page", Description: "1-based page number", ErrorCode: "INVALID_PAGE",
+			Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Ptr(1.0), Default: 1}},
+		{Name: "limit", Description: "Results per page", ErrorCode: "INVALID_LIMIT",
+			Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Ptr(1.0), Maximum: openapi.Ptr(1000.0), Default: 50}},
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+func newTestRouter(t *testing.T) *http.ServeMux {
+	t.Helper()
+	mux := http.NewServeMux()
+	passthrough := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	rt := NewRouter(mux, passthrough, passthrough)
+
+	client := &MockScreenerClient{
+		screenStocksFunc: func(filter screener.ScreenerFilter) ([]screener.ScreenerResult, error) {
+			return []screener.ScreenerResult{}, nil
+		},
+	}
+	NewScreenerHandler(client).RegisterRoutes(rt)
+	rt.RegisterRoutes()
+	return mux
+}
+
+func TestRouterValidatesScreenerQuery(t *testing.T) {
+	mux := newTestRouter(t)
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		expectedCode string
+	}{
+		{"valid", url.Values{"filters": {`[["pe_ratio","<",15],["ticker","IN",["AAPL"]]]`}, "sort": {"roe.desc"}}, ""},
+		{"unknown field", url.Values{"filters": {`[["1=1) OR (1","=",1]]`}}, "INVALID_FILTER"},
+		{"unknown operator", url.Values{"filters": {`[["pe_ratio","<>",15]]`}}, "INVALID_FILTER"},
+		{"multi-key sort", url.Values{"sort": {"dividend_yield.desc,upside.desc"}}, ""},
+		{"unknown sort", url.Values{"sort": {"pe_ratio.sideways"}}, "INVALID_SORT"},
+		{"unknown sort field", url.Values{"sort": {"roe.desc,volume.asc"}}, "INVALID_SORT"},
+		{"limit too large", url.Values{"limit": {"5000"}}, "INVALID_LIMIT"},
+		{"currency", url.Values{"currency": {"eur"}, "sort": {"close.asc"}}, ""},
+		{"invalid currency", url.Values{"currency": {"euro"}}, "INVALID_CURRENCY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/screener?"+tt.query.Encode(), nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if tt.expectedCode == "" {
+				if rr.Code != http.StatusOK {
+					t.Errorf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+				}
+				return
+			}
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d", rr.Code)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if body.Error != tt.expectedCode {
+				t.Errorf("Expected %s, got %s (%s)", tt.expectedCode, body.Error, body.Message)
+			}
+		})
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	mux := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rr.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/api/screener"]["get"]; !ok {
+		t.Error("Expected GET /api/screener to be documented")
+	}
+	for _, name := range []string{"ScreenerResponse", "ScreenerResult", "ErrorResponse"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("Expected component schema %s", name)
+		}
+	}
+}
+
+func TestScreenerExport(t *testing.T) {
+	client := &MockScreenerClient{
+		screenStocksFunc: func(filter screener.ScreenerFilter) ([]screener.ScreenerResult, error) {
+			if filter.Limit != 0 || filter.Offset != 0 {
+				t.Errorf("Expected exports to be unpaginated, got limit %d offset %d", filter.Limit, filter.Offset)
+			}
+			return []screener.ScreenerResult{{Ticker: "AAPL", PE: 25.5}, {Ticker: "MSFT", PE: 30}}, nil
+		},
+	}
+	handler := NewScreenerHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/screener?format=csv&limit=1&fields=ticker,pe_ratio", nil)
+	rr := httptest.NewRecorder()
+	handler.GetScreenerData(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected CSV content type, got %q", ct)
+	}
+	if want := "ticker,pe_ratio\nAAPL,25.5\nMSFT,30\n"; rr.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/screener", nil)
+	req.Header.Set("Accept", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	rr = httptest.NewRecorder()
+	handler.GetScreenerData(rr, req)
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasSuffix(got, `.xlsx"`) {
+		t.Errorf("Expected an xlsx attachment, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/screener?format=csv&fields=ticker,volume", nil)
+	rr = httptest.NewRecorder()
+	handler.GetScreenerData(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown column, got %d", rr.Code)
+	}
+}
@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/finsights-ai/backend/packages/export"
+	"github.com/finsights-ai/backend/packages/fx"
+	"github.com/finsights-ai/backend/packages/openapi"
 	"github.com/finsights-ai/backend/packages/screener"
 )
 
 type ScreenerClient interface {
 	ScreenStocks(filter screener.ScreenerFilter) ([]screener.ScreenerResult, error)
+	ScreenRows(filter screener.ScreenerFilter) ([]screener.Row, error)
+	StreamRows(filter screener.ScreenerFilter, fn func(screener.Row) error) error
 }
 
 // DatabaseScreenerClient implements ScreenerClient using the database
@@ -28,6 +36,14 @@ func (c *DatabaseScreenerClient) ScreenStocks(filter screener.ScreenerFilter) ([
 	return screener.ScreenStocks(c.db, filter)
 }
 
+func (c *DatabaseScreenerClient) ScreenRows(filter screener.ScreenerFilter) ([]screener.Row, error) {
+	return screener.ScreenRows(c.db, filter)
+}
+
+func (c *DatabaseScreenerClient) StreamRows(filter screener.ScreenerFilter, fn func(screener.Row) error) error {
+	return screener.StreamRows(c.db, filter, fn)
+}
+
 type ScreenerHandler struct {
 	client ScreenerClient
 }
@@ -38,17 +54,107 @@ func NewScreenerHandler(client ScreenerClient) *ScreenerHandler {
 	}
 }
 
+// RegisterRoutes registers the screener endpoints
+func (h *ScreenerHandler) RegisterRoutes(rt *Router) {
+	rt.Public("/api/screener/fields", h.GetScreenerFields,
+		openapi.Spec{Method: http.MethodGet, ID: "listScreenerFields", Summary: "List screenable fields", Tag: "screener",
+			Response: []screener.Field{}},
+	)
+	rt.Protected("/api/screener", h.GetScreenerData,
+		openapi.Spec{Method: http.MethodGet, ID: "screenStocks", Summary: "Screen stocks", Tag: "screener",
+			Query:      append(screenerParams(), paginationParams()...),
+			Response:   openapi.OneOf{ScreenerResponse{}, ScreenerRowsResponse{}},
+			AltContent: exportContentTypes()},
+	)
+}
+
+const conditionDescription = "A [field, operator, value] condition. IN and NOT IN take a list of strings, " +
+	"BETWEEN takes [min, max], IS NULL and IS NOT NULL take null, and top_pct and bottom_pct take " +
+	"a percentage of all tickers ranked by the field"
+
+// screenerParams documents the filters and sort parameters. Filter fields,
+// operators and sort keys come from the screener package, so requests using
+// anything else are rejected before reaching the query builder.
+func screenerParams() []*openapi.Parameter {
+	condition := &openapi.Schema{
+		Type:        "array",
+		Description: conditionDescription,
+		PrefixItems: []*openapi.Schema{
+			openapi.StringEnum(screener.FilterFields()),
+			openapi.StringEnum(screener.FilterOperators),
+			{},
+		},
+		MinItems: openapi.Ptr(3),
+		MaxItems: openapi.Ptr(3),
+	}
+
+	filters := openapi.JSONParameter("filters", "JSON array of filter conditions, all of which must match",
+		&openapi.Schema{Type: "array", Items: condition})
+	filters.ErrorCode = "INVALID_FILTER"
+
+	sort := openapi.ListParameter("sort",
+		"Sort keys in field.direction form, applied in order; missing values sort last",
+		openapi.StringEnum(screener.SortKeys()))
+	sort.Schema.Default = "pe_ratio.asc"
+	sort.ErrorCode = "INVALID_SORT"
+
+	format := openapi.StringEnum([]string{"json", string(export.CSV), string(export.XLSX), string(export.Parquet)})
+	format.Default = "json"
+
+	fields := openapi.ListParameter("fields",
+		"Fields to return, including computed fields and ratios of two numeric fields such as close/sma200. "+
+			"Responses then hold each row as a map of the selected fields.",
+		&openapi.Schema{Type: "string", Description: "One of " + strings.Join(screener.FieldNames(), ", ") + ", or a ratio a/b"})
+	fields.ErrorCode = "INVALID_FIELDS"
+
+	return []*openapi.Parameter{
+		filters,
+		sort,
+		{Name: "format", Description: "Download every matching row as a file instead of a JSON page; the Accept header may be used instead",
+			ErrorCode: "INVALID_FORMAT", Schema: format},
+		fields,
+		{Name: "currency", Description: "Three-letter currency such as EUR to convert prices, moving averages and intrinsic values into, " +
+			"at the latest exchange rates. Amounts are otherwise in each security's own currency, and are null when a rate is missing.",
+			ErrorCode: "INVALID_CURRENCY", Schema: &openapi.Schema{Type: "string"}},
+	}
+}
+
+func exportContentTypes() []string {
+	types := make([]string, len(export.Formats))
+	for i, f := range export.Formats {
+		types[i] = f.ContentType()
+	}
+	return types
+}
+
 type ScreenerResponse struct {
 	Data       []screener.ScreenerResult `json:"data"`
 	Page       int                       `json:"page"`
 	Limit      int                       `json:"limit"`
 	TotalCount int                       `json:"total_count"`
 	HasMore    bool                      `json:"has_more"`
+	// Currency is set when amounts were converted into it
+	Currency string `json:"currency,omitempty"`
+}
+
+// ScreenerRowsResponse is returned instead of ScreenerResponse when fields
+// are selected
+type ScreenerRowsResponse struct {
+	Data       []screener.Row `json:"data"`
+	Fields     []string       `json:"fields"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalCount int            `json:"total_count"`
+	HasMore    bool           `json:"has_more"`
+	// Currency is set when amounts were converted into it
+	Currency string `json:"currency,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
+	// Details locates validation failures, e.g. the offending filter condition
+	Details any `json:"details,omitempty"`
 }
 
 func (h *ScreenerHandler) GetScreenerData(w http.ResponseWriter, r *http.Request) {
@@ -62,28 +168,18 @@ func (h *ScreenerHandler) GetScreenerData(w http.ResponseWriter, r *http.Request
 	query := r.URL.Query()
 
 	// Parse pagination parameters
-	page, err := parseIntParam(query.Get("page"), 1)
-	if err != nil || page < 1 {
-		h.sendError(w, http.StatusBadRequest, "INVALID_PAGE", "Page must be a positive integer")
-		return
-	}
-
-	limit, err := parseIntParam(query.Get("limit"), 50)
-	if err != nil || limit < 1 || limit > 1000 {
-		h.sendError(w, http.StatusBadRequest, "INVALID_LIMIT", "Limit must be between 1 and 1000")
+	page, limit, ok := parsePagination(w, query)
+	if !ok {
 		return
 	}
 
-	// Calculate offset
-	offset := (page - 1) * limit
-
 	// Parse filter parameters
 	filters := query.Get("filters")
 
 	// Parse the filter from JSON format
 	baseFilter, err := screener.ParseFilterFromJSON(filters)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_FILTER", "Invalid filter format: "+err.Error())
+		sendFilterError(w, err)
 		return
 	}
 
@@ -92,59 +188,200 @@ func (h *ScreenerHandler) GetScreenerData(w http.ResponseWriter, r *http.Request
 	if sort == "" {
 		sort = "pe_ratio.asc" // Default sort
 	}
+	if _, err := screener.ParseSort(sort); err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_SORT", "Invalid sort: "+err.Error())
+		return
+	}
+
+	// Parse the selected fields, if any
+	var fieldNames []string
+	if param := query.Get("fields"); param != "" {
+		fieldNames = strings.Split(param, ",")
+	}
+	fields, err := screener.ParseFields(fieldNames)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_FIELDS", "Invalid fields: "+err.Error())
+		return
+	}
 
-	// Create final filter with pagination
+	// Parse the currency amounts are converted into, if any
+	var currency string
+	if param := query.Get("currency"); param != "" {
+		if currency, err = fx.Normalize(param); err != nil {
+			h.sendError(w, http.StatusBadRequest, "INVALID_CURRENCY", "Invalid currency: "+err.Error())
+			return
+		}
+	}
+
+	// Create final filter
 	filter := screener.ScreenerFilter{
 		Conditions: baseFilter.Conditions,
 		Sort:       sort,
-		Limit:      limit + 1, // Request one extra to check if there are more results
-		Offset:     offset,
+		Fields:     fieldNames,
+		Currency:   currency,
+	}
+
+	// Exports stream every matching row rather than a single page
+	format, isExport, err := exportFormat(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "INVALID_FORMAT", "Format must be json, csv, xlsx or parquet")
+		return
+	}
+	if isExport {
+		if len(fields) == 0 {
+			fields, _ = screener.ParseFields(screener.ResultColumns)
+		}
+		exportResults(w, h.client, filter, format, fields)
+		return
 	}
 
 	// Call custom screener
-	results, err := h.client.ScreenStocks(filter)
+	var response any
+	if len(fields) > 0 {
+		response, err = screenRowsPage(h.client, filter, fields, page, limit)
+	} else {
+		response, err = screenPage(h.client, filter, page, limit)
+	}
 	if err != nil {
 		log.Printf("Error calling ScreenStocks: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "SCREENER_ERROR", "Failed to fetch screener data")
 		return
 	}
 
-	// Determine if there are more results
-	hasMore := len(results) > limit
-	if hasMore {
-		results = results[:limit] // Remove the extra result
+	// Set response headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// parsePagination reads page and limit query parameters, writing a 400 response if they are invalid
+func parsePagination(w http.ResponseWriter, query url.Values) (int, int, bool) {
+	page, err := parseIntParam(query.Get("page"), 1)
+	if err != nil || page < 1 {
+		sendError(w, http.StatusBadRequest, "INVALID_PAGE", "Page must be a positive integer")
+		return 0, 0, false
 	}
 
-	// Create response
-	response := ScreenerResponse{
+	limit, err := parseIntParam(query.Get("limit"), 50)
+	if err != nil || limit < 1 || limit > 1000 {
+		sendError(w, http.StatusBadRequest, "INVALID_LIMIT", "Limit must be between 1 and 1000")
+		return 0, 0, false
+	}
+
+	return page, limit, true
+}
+
+// GetScreenerFields lists the fields that can be filtered, sorted and
+// selected, with the metadata needed to build a filter UI
+func (h *ScreenerHandler) GetScreenerFields(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+	sendJSON(w, http.StatusOK, screener.Fields())
+}
+
+// screenPage runs the filter for a single page of results
+func screenPage(client ScreenerClient, filter screener.ScreenerFilter, page, limit int) (ScreenerResponse, error) {
+	filter.Limit = limit + 1 // Request one extra to check if there are more results
+	filter.Offset = (page - 1) * limit
+
+	results, err := client.ScreenStocks(filter)
+	if err != nil {
+		return ScreenerResponse{}, err
+	}
+
+	results, hasMore := trimPage(results, limit)
+	return ScreenerResponse{
 		Data:       results,
 		Page:       page,
 		Limit:      limit,
 		TotalCount: len(results), // Current page size
 		HasMore:    hasMore,
+		Currency:   filter.Currency,
+	}, nil
+}
+
+// screenRowsPage runs the filter for a single page of rows holding the selected fields
+func screenRowsPage(client ScreenerClient, filter screener.ScreenerFilter, fields []screener.Field, page, limit int) (ScreenerRowsResponse, error) {
+	filter.Limit = limit + 1
+	filter.Offset = (page - 1) * limit
+
+	rows, err := client.ScreenRows(filter)
+	if err != nil {
+		return ScreenerRowsResponse{}, err
 	}
 
-	// Set response headers
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+
+	rows, hasMore := trimPage(rows, limit)
+	return ScreenerRowsResponse{
+		Data:       rows,
+		Fields:     names,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: len(rows),
+		HasMore:    hasMore,
+		Currency:   filter.Currency,
+	}, nil
+}
+
+// trimPage drops the extra result requested to detect further pages
+func trimPage[T any](results []T, limit int) ([]T, bool) {
+	if len(results) > limit {
+		return results[:limit], true
+	}
+	return results, false
+}
+
+func (h *ScreenerHandler) sendError(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	sendError(w, statusCode, errorCode, message)
+}
+
+// sendJSON writes v as a JSON response with the given status code
+func sendJSON(w http.ResponseWriter, statusCode int, v any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.WriteHeader(statusCode)
 
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
 		log.Printf("Error encoding response: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "ENCODING_ERROR", "Failed to encode response")
-		return
 	}
 }
 
-func (h *ScreenerHandler) sendError(w http.ResponseWriter, statusCode int, errorCode, message string) {
+func sendError(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	sendErrorDetails(w, statusCode, errorCode, message, nil)
+}
+
+// sendFilterError reports an invalid filter as INVALID_FILTER, with the
+// offending condition as details when it is known
+func sendFilterError(w http.ResponseWriter, err error) {
+	sendErrorDetails(w, http.StatusBadRequest, "INVALID_FILTER", "Invalid filter: "+err.Error(), filterErrorDetails(err))
+}
+
+// filterErrorDetails returns the *screener.FilterError wrapped in err, if any
+func filterErrorDetails(err error) any {
+	var filterErr *screener.FilterError
+	if errors.As(err, &filterErr) {
+		return filterErr
+	}
+	return nil
+}
+
+func sendErrorDetails(w http.ResponseWriter, statusCode int, errorCode, message string, details any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
 	errorResponse := ErrorResponse{
 		Error:   errorCode,
 		Message: message,
+		Details: details,
 	}
 
 	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
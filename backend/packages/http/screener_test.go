@@ -2,9 +2,11 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/finsights-ai/backend/packages/screener"
@@ -22,6 +24,40 @@ func (m *MockScreenerClient) ScreenStocks(filter screener.ScreenerFilter) ([]scr
 	return nil, nil
 }
 
+// ScreenRows converts the mocked results to rows holding the requested fields
+func (m *MockScreenerClient) ScreenRows(filter screener.ScreenerFilter) ([]screener.Row, error) {
+	results, err := m.ScreenStocks(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := filter.Fields
+	if len(fields) == 0 {
+		fields = screener.ResultColumns
+	}
+	rows := make([]screener.Row, len(results))
+	for i, r := range results {
+		rows[i] = screener.Row{}
+		for _, f := range fields {
+			rows[i][f] = r.Value(f)
+		}
+	}
+	return rows, nil
+}
+
+func (m *MockScreenerClient) StreamRows(filter screener.ScreenerFilter, fn func(screener.Row) error) error {
+	rows, err := m.ScreenRows(filter)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func TestGetScreenerData(t *testing.T) {
 	// Mock data
 	mockResults := []screener.ScreenerResult{
@@ -225,6 +261,33 @@ func TestGetScreenerData(t *testing.T) {
 	}
 }
 
+func TestGetScreenerDataInvalidFilterDetails(t *testing.T) {
+	handler := NewScreenerHandler(&MockScreenerClient{})
+
+	query := url.Values{"filters": {`[["roe",">",0.1],["pe_ratio","<","cheap"]]`}}
+	req := httptest.NewRequest(http.MethodGet, "/api/screener?"+query.Encode(), nil)
+	rr := httptest.NewRecorder()
+	handler.GetScreenerData(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	var response struct {
+		Error   string               `json:"error"`
+		Details screener.FilterError `json:"details"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if response.Error != "INVALID_FILTER" {
+		t.Errorf("Expected INVALID_FILTER, got %s", response.Error)
+	}
+	if response.Details.Index != 1 || response.Details.Field != "pe_ratio" || response.Details.Message == "" {
+		t.Errorf("Expected details for the second condition, got %+v", response.Details)
+	}
+}
+
 func TestGetScreenerDataMethodNotAllowed(t *testing.T) {
 	mockClient := &MockScreenerClient{}
 	handler := NewScreenerHandler(mockClient)
@@ -239,6 +302,37 @@ func TestGetScreenerDataMethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestGetScreenerFields(t *testing.T) {
+	handler := NewScreenerHandler(&MockScreenerClient{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/screener/fields", nil)
+	rr := httptest.NewRecorder()
+	handler.GetScreenerFields(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var fields []map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(fields) != len(screener.FieldNames()) {
+		t.Errorf("Expected %d fields, got %d", len(screener.FieldNames()), len(fields))
+	}
+	for _, f := range fields {
+		if _, ok := f["expr"]; ok {
+			t.Error("SQL expressions must not be exposed")
+		}
+		if f["name"] == "roe" && (f["unit"] != "percent" || f["table"] != "fundamentals") {
+			t.Errorf("Unexpected roe metadata: %v", f)
+		}
+		if f["name"] == "ticker" && f["eodhd_alias"] != "code" {
+			t.Errorf("Expected ticker alias code, got %v", f["eodhd_alias"])
+		}
+	}
+}
+
 func TestParseIntParam(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -492,3 +586,40 @@ func TestParseFilterFromJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestExportReportsErrorsBeforeTheFirstRow(t *testing.T) {
+	export := func(client *MockScreenerClient) *httptest.ResponseRecorder {
+		handler := NewScreenerHandler(client)
+		req := httptest.NewRequest(http.MethodGet, "/api/screener?format=csv&fields=ticker,close", nil)
+		rr := httptest.NewRecorder()
+		handler.GetScreenerData(rr, req)
+		return rr
+	}
+
+	rr := export(&MockScreenerClient{screenStocksFunc: func(screener.ScreenerFilter) ([]screener.ScreenerResult, error) {
+		return nil, errors.New("database is locked")
+	}})
+	if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("Expected a 500 without a download, got %d with %q", rr.Code, rr.Header().Get("Content-Disposition"))
+	}
+	var body ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil || body.Error != "SCREENER_ERROR" {
+		t.Errorf("Expected a SCREENER_ERROR response, got %q (%v)", body.Error, err)
+	}
+
+	// Without matches the download is a file holding just the header
+	rr = export(&MockScreenerClient{})
+	if rr.Code != http.StatusOK || rr.Body.String() != "ticker,close\n" {
+		t.Errorf("Expected a header-only CSV, got %d: %q", rr.Code, rr.Body.String())
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("Expected a download, got %q", rr.Header().Get("Content-Disposition"))
+	}
+
+	rr = export(&MockScreenerClient{screenStocksFunc: func(screener.ScreenerFilter) ([]screener.ScreenerResult, error) {
+		return []screener.ScreenerResult{{Ticker: "AAPL", Close: 150.25}}, nil
+	}})
+	if rr.Code != http.StatusOK || rr.Body.String() != "ticker,close\nAAPL,150.25\n" {
+		t.Errorf("Expected the row after the header, got %d: %q", rr.Code, rr.Body.String())
+	}
+}
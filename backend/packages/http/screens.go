@@ -0,0 +1,362 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/finsights-ai/backend/packages/openapi"
+	"github.com/finsights-ai/backend/packages/screener"
+	"github.com/finsights-ai/backend/packages/screens"
+)
+
+type ScreensClient interface {
+	ListScreens(userID int64) ([]screens.Screen, error)
+	GetScreen(userID, id int64) (screens.Screen, error)
+	GetScreenByShareToken(token string) (screens.Screen, error)
+	CreateScreen(userID int64, s screens.Screen) (screens.Screen, error)
+	UpdateScreen(userID, id int64, s screens.Screen) (screens.Screen, error)
+	DeleteScreen(userID, id int64) error
+	ShareScreen(userID, id int64) (screens.Screen, error)
+	UnshareScreen(userID, id int64) error
+	ListScreenVersions(userID, id int64) ([]screens.ScreenVersion, error)
+}
+
+// DatabaseScreensClient implements ScreensClient using the database
+type DatabaseScreensClient struct {
+	db *sql.DB
+}
+
+func NewDatabaseScreensClient(db *sql.DB) *DatabaseScreensClient {
+	return &DatabaseScreensClient{db: db}
+}
+
+func (c *DatabaseScreensClient) ListScreens(userID int64) ([]screens.Screen, error) {
+	return screens.ListScreens(c.db, userID)
+}
+
+func (c *DatabaseScreensClient) GetScreen(userID, id int64) (screens.Screen, error) {
+	return screens.GetScreen(c.db, userID, id)
+}
+
+func (c *DatabaseScreensClient) GetScreenByShareToken(token string) (screens.Screen, error) {
+	return screens.GetScreenByShareToken(c.db, token)
+}
+
+func (c *DatabaseScreensClient) CreateScreen(userID int64, s screens.Screen) (screens.Screen, error) {
+	return screens.CreateScreen(c.db, userID, s)
+}
+
+func (c *DatabaseScreensClient) UpdateScreen(userID, id int64, s screens.Screen) (screens.Screen, error) {
+	return screens.UpdateScreen(c.db, userID, id, s)
+}
+
+func (c *DatabaseScreensClient) DeleteScreen(userID, id int64) error {
+	return screens.DeleteScreen(c.db, userID, id)
+}
+
+func (c *DatabaseScreensClient) ShareScreen(userID, id int64) (screens.Screen, error) {
+	return screens.ShareScreen(c.db, userID, id)
+}
+
+func (c *DatabaseScreensClient) UnshareScreen(userID, id int64) error {
+	return screens.UnshareScreen(c.db, userID, id)
+}
+
+func (c *DatabaseScreensClient) ListScreenVersions(userID, id int64) ([]screens.ScreenVersion, error) {
+	return screens.ListScreenVersions(c.db, userID, id)
+}
+
+// ScreensHandler serves CRUD endpoints for saved screens and runs them against the screener
+type ScreensHandler struct {
+	client   ScreensClient
+	screener ScreenerClient
+}
+
+func NewScreensHandler(client ScreensClient, screenerClient ScreenerClient) *ScreensHandler {
+	return &ScreensHandler{
+		client:   client,
+		screener: screenerClient,
+	}
+}
+
+// RegisterRoutes registers the saved screen endpoints, including shared links
+func (h *ScreensHandler) RegisterRoutes(rt *Router) {
+	rt.Protected("/api/screens", h.Screens,
+		openapi.Spec{Method: http.MethodGet, ID: "listScreens", Summary: "List saved screens", Tag: "screens", Response: []screens.Screen{}},
+		openapi.Spec{Method: http.MethodPost, ID: "createScreen", Summary: "Save a screen", Tag: "screens", Body: screens.Screen{}, Status: http.StatusCreated, Response: screens.Screen{}},
+	)
+	rt.Protected("/api/screens/{id}", h.Screen,
+		openapi.Spec{Method: http.MethodGet, ID: "getScreen", Summary: "Get a saved screen", Tag: "screens", Response: screens.Screen{}},
+		openapi.Spec{Method: http.MethodPut, ID: "updateScreen", Summary: "Update a saved screen, recording a new version", Tag: "screens", Body: screens.Screen{}, Response: screens.Screen{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "deleteScreen", Summary: "Delete a saved screen", Tag: "screens", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/screens/{id}/versions", h.Versions,
+		openapi.Spec{Method: http.MethodGet, ID: "listScreenVersions", Summary: "List the versions of a saved screen", Tag: "screens", Response: []screens.ScreenVersion{}},
+	)
+	rt.Protected("/api/screens/{id}/share", h.Share,
+		openapi.Spec{Method: http.MethodPost, ID: "shareScreen", Summary: "Share a saved screen, replacing any earlier share link", Tag: "screens", Response: screens.Screen{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "unshareScreen", Summary: "Stop sharing a saved screen", Tag: "screens", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/screens/{id}/run", h.Run,
+		openapi.Spec{Method: http.MethodGet, ID: "runScreen", Summary: "Run a saved screen", Tag: "screens", Query: paginationParams(),
+			Response: openapi.OneOf{ScreenerResponse{}, ScreenerRowsResponse{}}},
+	)
+	rt.Public("/api/shared/screens/{token}", h.Shared,
+		openapi.Spec{Method: http.MethodGet, ID: "getSharedScreen", Summary: "Get a shared screen", Tag: "screens", Response: screens.Screen{}},
+	)
+	rt.Public("/api/shared/screens/{token}/run", h.RunShared,
+		openapi.Spec{Method: http.MethodGet, ID: "runSharedScreen", Summary: "Run a shared screen", Tag: "screens", Query: paginationParams(),
+			Response: openapi.OneOf{ScreenerResponse{}, ScreenerRowsResponse{}}},
+	)
+}
+
+// Screens handles /api/screens (list and create)
+func (h *ScreensHandler) Screens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.client.ListScreens(userID)
+		if err != nil {
+			log.Printf("Error listing screens: %v", err)
+			sendError(w, http.StatusInternalServerError, "SCREENS_ERROR", "Failed to list screens")
+			return
+		}
+		sendJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		s, ok := decodeScreen(w, r)
+		if !ok {
+			return
+		}
+		created, err := h.client.CreateScreen(userID, s)
+		if err != nil {
+			log.Printf("Error creating screen: %v", err)
+			sendError(w, http.StatusInternalServerError, "SCREENS_ERROR", "Failed to create screen")
+			return
+		}
+		sendJSON(w, http.StatusCreated, created)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// Screen handles /api/screens/{id} (get, update and delete)
+func (h *ScreensHandler) Screen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Screen ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s, err := h.client.GetScreen(userID, id)
+		if err != nil {
+			h.sendScreenError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, s)
+
+	case http.MethodPut:
+		s, ok := decodeScreen(w, r)
+		if !ok {
+			return
+		}
+		updated, err := h.client.UpdateScreen(userID, id, s)
+		if err != nil {
+			h.sendScreenError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		if err := h.client.DeleteScreen(userID, id); err != nil {
+			h.sendScreenError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET, PUT and DELETE methods are allowed")
+	}
+}
+
+// Versions handles /api/screens/{id}/versions
+func (h *ScreensHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Screen ID")
+	if !ok {
+		return
+	}
+
+	versions, err := h.client.ListScreenVersions(userID, id)
+	if err != nil {
+		h.sendScreenError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, versions)
+}
+
+// Share handles /api/screens/{id}/share. POST creates a new share token and
+// DELETE revokes it.
+func (h *ScreensHandler) Share(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Screen ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s, err := h.client.ShareScreen(userID, id)
+		if err != nil {
+			h.sendScreenError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, s)
+
+	case http.MethodDelete:
+		if err := h.client.UnshareScreen(userID, id); err != nil {
+			h.sendScreenError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST and DELETE methods are allowed")
+	}
+}
+
+// Run handles /api/screens/{id}/run and returns a page of screener results,
+// as rows of the saved columns when the screen has any
+func (h *ScreensHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Screen ID")
+	if !ok {
+		return
+	}
+
+	s, err := h.client.GetScreen(userID, id)
+	if err != nil {
+		h.sendScreenError(w, err)
+		return
+	}
+
+	h.run(w, r, s)
+}
+
+// Shared handles /api/shared/screens/{token} and returns the shared screen
+// without its token, which only the owner manages
+func (h *ScreensHandler) Shared(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	s, err := h.client.GetScreenByShareToken(r.PathValue("token"))
+	if err != nil {
+		h.sendScreenError(w, err)
+		return
+	}
+	s.ShareToken = ""
+	sendJSON(w, http.StatusOK, s)
+}
+
+// RunShared handles /api/shared/screens/{token}/run
+func (h *ScreensHandler) RunShared(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	s, err := h.client.GetScreenByShareToken(r.PathValue("token"))
+	if err != nil {
+		h.sendScreenError(w, err)
+		return
+	}
+
+	h.run(w, r, s)
+}
+
+func (h *ScreensHandler) run(w http.ResponseWriter, r *http.Request, s screens.Screen) {
+	page, limit, ok := parsePagination(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	filter, err := s.ScreenerFilter(0, 0)
+	if err != nil {
+		sendErrorDetails(w, http.StatusUnprocessableEntity, "INVALID_FILTER", "Saved screen has an invalid filter: "+err.Error(), filterErrorDetails(err))
+		return
+	}
+
+	// Saved columns are projected like the screener's fields parameter
+	var response any
+	if len(filter.Fields) > 0 {
+		var fields []screener.Field
+		fields, err = screener.ParseFields(filter.Fields)
+		if err != nil {
+			sendError(w, http.StatusUnprocessableEntity, "INVALID_FIELDS", "Saved screen has invalid columns: "+err.Error())
+			return
+		}
+		response, err = screenRowsPage(h.screener, filter, fields, page, limit)
+	} else {
+		response, err = screenPage(h.screener, filter, page, limit)
+	}
+	if err != nil {
+		log.Printf("Error running screen %d: %v", s.ID, err)
+		sendError(w, http.StatusInternalServerError, "SCREENER_ERROR", "Failed to fetch screener data")
+		return
+	}
+	sendJSON(w, http.StatusOK, response)
+}
+
+func (h *ScreensHandler) sendScreenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, screens.ErrNotFound) {
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Screen not found")
+		return
+	}
+	log.Printf("Error accessing screen: %v", err)
+	sendError(w, http.StatusInternalServerError, "SCREENS_ERROR", "Failed to access screen")
+}
+
+func decodeScreen(w http.ResponseWriter, r *http.Request) (screens.Screen, bool) {
+	var s screens.Screen
+	if !decodeJSON(w, r, &s) {
+		return screens.Screen{}, false
+	}
+	if err := s.Validate(); err != nil {
+		sendErrorDetails(w, http.StatusBadRequest, "INVALID_SCREEN", "Invalid screen: "+err.Error(), filterErrorDetails(err))
+		return screens.Screen{}, false
+	}
+	return s, true
+}
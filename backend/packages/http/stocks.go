@@ -0,0 +1,65 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/openapi"
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+type StocksClient interface {
+	GetStock(ticker string) (screener.StockDetail, error)
+}
+
+// DatabaseStocksClient implements StocksClient using the database
+type DatabaseStocksClient struct {
+	db *sql.DB
+}
+
+func NewDatabaseStocksClient(db *sql.DB) *DatabaseStocksClient {
+	return &DatabaseStocksClient{db: db}
+}
+
+func (c *DatabaseStocksClient) GetStock(ticker string) (screener.StockDetail, error) {
+	return screener.GetStock(c.db, ticker)
+}
+
+// StocksHandler serves the per-ticker detail endpoints
+type StocksHandler struct {
+	client StocksClient
+}
+
+func NewStocksHandler(client StocksClient) *StocksHandler {
+	return &StocksHandler{client: client}
+}
+
+// RegisterRoutes registers the stock endpoints
+func (h *StocksHandler) RegisterRoutes(rt *Router) {
+	rt.Protected("/api/stocks/{ticker}", h.Stock,
+		openapi.Spec{Method: http.MethodGet, ID: "getStock", Summary: "Get a stock's fields, valuations and headlines", Tag: "stocks",
+			Response: screener.StockDetail{}},
+	)
+}
+
+// Stock handles /api/stocks/{ticker}
+func (h *StocksHandler) Stock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET method is allowed")
+		return
+	}
+
+	stock, err := h.client.GetStock(strings.ToUpper(strings.TrimSpace(r.PathValue("ticker"))))
+	switch {
+	case errors.Is(err, screener.ErrTickerNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Ticker not found")
+	case err != nil:
+		log.Printf("Error getting stock: %v", err)
+		sendError(w, http.StatusInternalServerError, "STOCKS_ERROR", "Failed to get stock")
+	default:
+		sendJSON(w, http.StatusOK, stock)
+	}
+}
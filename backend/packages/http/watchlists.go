@@ -0,0 +1,282 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/openapi"
+	"github.com/finsights-ai/backend/packages/watchlists"
+)
+
+type WatchlistsClient interface {
+	ListWatchlists(userID int64) ([]watchlists.Watchlist, error)
+	GetWatchlist(userID, id int64) (watchlists.Watchlist, error)
+	CreateWatchlist(userID int64, name, description string) (watchlists.Watchlist, error)
+	UpdateWatchlist(userID, id int64, name, description string) (watchlists.Watchlist, error)
+	DeleteWatchlist(userID, id int64) error
+	SaveItem(userID, id int64, ticker, notes string, targetPrice *float64) error
+	RemoveItem(userID, id int64, ticker string) error
+}
+
+// DatabaseWatchlistsClient implements WatchlistsClient using the database
+type DatabaseWatchlistsClient struct {
+	db *sql.DB
+}
+
+func NewDatabaseWatchlistsClient(db *sql.DB) *DatabaseWatchlistsClient {
+	return &DatabaseWatchlistsClient{db: db}
+}
+
+func (c *DatabaseWatchlistsClient) ListWatchlists(userID int64) ([]watchlists.Watchlist, error) {
+	return watchlists.ListWatchlists(c.db, userID)
+}
+
+func (c *DatabaseWatchlistsClient) GetWatchlist(userID, id int64) (watchlists.Watchlist, error) {
+	return watchlists.GetWatchlist(c.db, userID, id)
+}
+
+func (c *DatabaseWatchlistsClient) CreateWatchlist(userID int64, name, description string) (watchlists.Watchlist, error) {
+	return watchlists.CreateWatchlist(c.db, userID, name, description)
+}
+
+func (c *DatabaseWatchlistsClient) UpdateWatchlist(userID, id int64, name, description string) (watchlists.Watchlist, error) {
+	return watchlists.UpdateWatchlist(c.db, userID, id, name, description)
+}
+
+func (c *DatabaseWatchlistsClient) DeleteWatchlist(userID, id int64) error {
+	return watchlists.DeleteWatchlist(c.db, userID, id)
+}
+
+func (c *DatabaseWatchlistsClient) SaveItem(userID, id int64, ticker, notes string, targetPrice *float64) error {
+	return watchlists.SaveItem(c.db, userID, id, ticker, notes, targetPrice)
+}
+
+func (c *DatabaseWatchlistsClient) RemoveItem(userID, id int64, ticker string) error {
+	return watchlists.RemoveItem(c.db, userID, id, ticker)
+}
+
+// WatchlistsHandler serves the watchlist REST endpoints
+type WatchlistsHandler struct {
+	client WatchlistsClient
+}
+
+func NewWatchlistsHandler(client WatchlistsClient) *WatchlistsHandler {
+	return &WatchlistsHandler{client: client}
+}
+
+// RegisterRoutes registers the watchlist endpoints
+func (h *WatchlistsHandler) RegisterRoutes(rt *Router) {
+	rt.Protected("/api/watchlists", h.Watchlists,
+		openapi.Spec{Method: http.MethodGet, ID: "listWatchlists", Summary: "List watchlists", Tag: "watchlists", Response: []watchlists.Watchlist{}},
+		openapi.Spec{Method: http.MethodPost, ID: "createWatchlist", Summary: "Create a watchlist", Tag: "watchlists", Body: WatchlistRequest{}, Status: http.StatusCreated, Response: watchlists.Watchlist{}},
+	)
+	rt.Protected("/api/watchlists/{id}", h.Watchlist,
+		openapi.Spec{Method: http.MethodGet, ID: "getWatchlist", Summary: "Get a watchlist with its items", Tag: "watchlists", Response: watchlists.Watchlist{}},
+		openapi.Spec{Method: http.MethodPut, ID: "updateWatchlist", Summary: "Rename a watchlist", Tag: "watchlists", Body: WatchlistRequest{}, Response: watchlists.Watchlist{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "deleteWatchlist", Summary: "Delete a watchlist", Tag: "watchlists", Status: http.StatusNoContent},
+	)
+	rt.Protected("/api/watchlists/{id}/items", h.Items,
+		openapi.Spec{Method: http.MethodPost, ID: "addWatchlistItem", Summary: "Add a ticker to a watchlist", Tag: "watchlists", Body: WatchlistItemRequest{}, Response: watchlists.Watchlist{}},
+	)
+	rt.Protected("/api/watchlists/{id}/items/{ticker}", h.Item,
+		openapi.Spec{Method: http.MethodPut, ID: "updateWatchlistItem", Summary: "Update a ticker's notes and target price", Tag: "watchlists", Body: WatchlistItemRequest{}, Response: watchlists.Watchlist{}},
+		openapi.Spec{Method: http.MethodDelete, ID: "removeWatchlistItem", Summary: "Remove a ticker from a watchlist", Tag: "watchlists", Status: http.StatusNoContent},
+	)
+}
+
+// WatchlistRequest is the body for creating or updating a watchlist
+type WatchlistRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// WatchlistItemRequest is the body for adding or updating a watchlist item
+type WatchlistItemRequest struct {
+	Ticker      string   `json:"ticker"`
+	Notes       string   `json:"notes"`
+	TargetPrice *float64 `json:"target_price"`
+}
+
+// Watchlists handles /api/watchlists (list and create)
+func (h *WatchlistsHandler) Watchlists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		lists, err := h.client.ListWatchlists(userID)
+		if err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, lists)
+
+	case http.MethodPost:
+		req, ok := decodeWatchlist(w, r)
+		if !ok {
+			return
+		}
+		created, err := h.client.CreateWatchlist(userID, req.Name, req.Description)
+		if err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusCreated, created)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET and POST methods are allowed")
+	}
+}
+
+// Watchlist handles /api/watchlists/{id} (get with metrics, update and delete)
+func (h *WatchlistsHandler) Watchlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Watchlist ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		wl, err := h.client.GetWatchlist(userID, id)
+		if err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, wl)
+
+	case http.MethodPut:
+		req, ok := decodeWatchlist(w, r)
+		if !ok {
+			return
+		}
+		updated, err := h.client.UpdateWatchlist(userID, id, req.Name, req.Description)
+		if err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		sendJSON(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		if err := h.client.DeleteWatchlist(userID, id); err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET, PUT and DELETE methods are allowed")
+	}
+}
+
+// Items handles /api/watchlists/{id}/items (add a ticker)
+func (h *WatchlistsHandler) Items(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST method is allowed")
+		return
+	}
+
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Watchlist ID")
+	if !ok {
+		return
+	}
+
+	var req WatchlistItemRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	h.saveItem(w, userID, id, req)
+}
+
+// Item handles /api/watchlists/{id}/items/{ticker} (update notes/target and remove)
+func (h *WatchlistsHandler) Item(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := parsePathID(w, r, "id", "Watchlist ID")
+	if !ok {
+		return
+	}
+	ticker := r.PathValue("ticker")
+
+	switch r.Method {
+	case http.MethodPut:
+		var req WatchlistItemRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		req.Ticker = ticker
+		h.saveItem(w, userID, id, req)
+
+	case http.MethodDelete:
+		if err := h.client.RemoveItem(userID, id, ticker); err != nil {
+			h.sendWatchlistError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only PUT and DELETE methods are allowed")
+	}
+}
+
+func (h *WatchlistsHandler) saveItem(w http.ResponseWriter, userID, id int64, req WatchlistItemRequest) {
+	if watchlists.NormalizeTicker(req.Ticker) == "" {
+		sendError(w, http.StatusBadRequest, "INVALID_TICKER", "Ticker is required")
+		return
+	}
+	if req.TargetPrice != nil && *req.TargetPrice <= 0 {
+		sendError(w, http.StatusBadRequest, "INVALID_TARGET_PRICE", "Target price must be positive")
+		return
+	}
+
+	if err := h.client.SaveItem(userID, id, req.Ticker, req.Notes, req.TargetPrice); err != nil {
+		h.sendWatchlistError(w, err)
+		return
+	}
+
+	wl, err := h.client.GetWatchlist(userID, id)
+	if err != nil {
+		h.sendWatchlistError(w, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, wl)
+}
+
+func (h *WatchlistsHandler) sendWatchlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, watchlists.ErrNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Watchlist not found")
+	case errors.Is(err, watchlists.ErrItemNotFound):
+		sendError(w, http.StatusNotFound, "NOT_FOUND", "Ticker is not on this watchlist")
+	default:
+		log.Printf("Error accessing watchlist: %v", err)
+		sendError(w, http.StatusInternalServerError, "WATCHLISTS_ERROR", "Failed to access watchlist")
+	}
+}
+
+func decodeWatchlist(w http.ResponseWriter, r *http.Request) (WatchlistRequest, bool) {
+	var req WatchlistRequest
+	if !decodeJSON(w, r, &req) {
+		return WatchlistRequest{}, false
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		sendError(w, http.StatusBadRequest, "INVALID_WATCHLIST", "Name is required")
+		return WatchlistRequest{}, false
+	}
+	return req, true
+}
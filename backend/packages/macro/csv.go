@@ -0,0 +1,49 @@
+package macro
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// CSVSource stands in for EODHD with yields from a CSV file holding series,
+// date and value columns after a header row, e.g.
+//
+//	series,date,value
+//	US10Y.GBOND,2024-01-15,4.05
+type CSVSource struct {
+	Path string
+}
+
+// GetBondYields returns the file's values for symbol between from and to
+// inclusive, in file order
+func (s CSVSource) GetBondYields(symbol string, from, to string) ([]eodhd.Yield, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 3
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid macro CSV: %w", err)
+	}
+
+	var result []eodhd.Yield
+	for i, r := range records {
+		if i == 0 || r[0] != symbol || (from != "" && r[1] < from) || (to != "" && r[1] > to) {
+			continue
+		}
+		value, err := strconv.ParseFloat(r[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid macro CSV line %d: %w", i+1, err)
+		}
+		result = append(result, eodhd.Yield{Date: r[1], Yield: value})
+	}
+	return result, nil
+}
@@ -0,0 +1,86 @@
+// Package macro stores macroeconomic time series, such as bond yields, that
+// valuations depend on
+package macro
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// BondYieldSeries is the yield fed to the Graham formula, in percent
+const BondYieldSeries = "US10Y.GBOND"
+
+// ErrNoData is returned when a series has no value on or before a date
+var ErrNoData = errors.New("no macro data")
+
+// YieldSource provides historical yields of a series, such as *eodhd.Client
+// or a CSVSource
+type YieldSource interface {
+	GetBondYields(symbol string, from, to string) ([]eodhd.Yield, error)
+}
+
+// Observation is a series' value on a date
+type Observation struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// historyStart is where a series without stored values is synced from
+const historyStart = "2000-01-01"
+
+// Sync stores the series' values from its latest stored date through to,
+// returning how many were fetched
+func Sync(db *sql.DB, source YieldSource, series string, to time.Time) (int, error) {
+	var latest sql.NullString
+	if err := db.QueryRow("SELECT MAX(date) FROM macro_series WHERE series = ?", series).Scan(&latest); err != nil {
+		return 0, fmt.Errorf("failed to query macro series: %w", err)
+	}
+	from := historyStart
+	if latest.Valid {
+		from = latest.String
+	}
+
+	yields, err := source.GetBondYields(series, from, to.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("error getting %s: %w", series, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, y := range yields {
+		_, err := tx.Exec(`
+			INSERT INTO macro_series (series, date, value) VALUES (?, ?, ?)
+			ON CONFLICT(series, date) DO UPDATE SET value = excluded.value`,
+			series, y.Date, y.Yield,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save %s on %s: %w", series, y.Date, err)
+		}
+	}
+	return len(yields), tx.Commit()
+}
+
+// ValueAsOf returns the series' latest value on or before date (YYYY-MM-DD)
+func ValueAsOf(db *sql.DB, series, date string) (Observation, error) {
+	var o Observation
+	err := db.QueryRow(`
+		SELECT date, value FROM macro_series
+		WHERE series = ? AND date <= ?
+		ORDER BY date DESC LIMIT 1`, series, date,
+	).Scan(&o.Date, &o.Value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Observation{}, ErrNoData
+	}
+	if err != nil {
+		return Observation{}, fmt.Errorf("failed to query macro series: %w", err)
+	}
+	return o, nil
+}
@@ -0,0 +1,92 @@
+package macro
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE macro_series (
+			series TEXT NOT NULL,
+			date TEXT NOT NULL,
+			value REAL NOT NULL,
+			PRIMARY KEY (series, date)
+		);`)
+	if err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return db
+}
+
+func writeCSV(t *testing.T, content string) CSVSource {
+	path := filepath.Join(t.TempDir(), "macro.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	return CSVSource{Path: path}
+}
+
+func TestCSVSource(t *testing.T) {
+	source := writeCSV(t, "series,date,value\n"+
+		"US10Y.GBOND,2024-01-12,3.94\n"+
+		"US2Y.GBOND,2024-01-12,4.14\n"+
+		"US10Y.GBOND,2024-01-16,4.06\n"+
+		"US10Y.GBOND,2024-02-01,3.88\n")
+
+	yields, err := source.GetBondYields("US10Y.GBOND", "2024-01-01", "2024-01-31")
+	if err != nil {
+		t.Fatalf("GetBondYields failed: %v", err)
+	}
+	if len(yields) != 2 || yields[0].Yield != 3.94 || yields[1].Date != "2024-01-16" {
+		t.Errorf("Expected January's 10-year yields, got %+v", yields)
+	}
+
+	bad := writeCSV(t, "series,date,value\nUS10Y.GBOND,2024-01-12,n/a\n")
+	if _, err := bad.GetBondYields("US10Y.GBOND", "", ""); err == nil {
+		t.Error("Expected an error for a non-numeric value")
+	}
+}
+
+func TestSyncAndValueAsOf(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	source := writeCSV(t, "series,date,value\n"+
+		"US10Y.GBOND,2024-01-12,3.94\n"+
+		"US10Y.GBOND,2024-01-16,4.06\n")
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	n, err := Sync(db, source, BondYieldSeries, to)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 values synced, got %d, %v", n, err)
+	}
+	// The next sync starts from the latest stored date, so only that day is
+	// fetched again
+	if n, err := Sync(db, source, BondYieldSeries, to); err != nil || n != 1 {
+		t.Errorf("Expected only the latest date to be refetched, got %d, %v", n, err)
+	}
+
+	// Weekends and holidays use the last value before them
+	o, err := ValueAsOf(db, BondYieldSeries, "2024-01-15")
+	if err != nil {
+		t.Fatalf("ValueAsOf failed: %v", err)
+	}
+	if o.Date != "2024-01-12" || o.Value != 3.94 {
+		t.Errorf("Expected Friday's 3.94%%, got %+v", o)
+	}
+
+	if _, err := ValueAsOf(db, BondYieldSeries, "2023-12-31"); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData before the series starts, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package news stores financial news articles per ticker, scored for
+// sentiment as they are ingested, and aggregates their rolling sentiment
+package news
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// ErrNoData is returned when a ticker has no scored articles in a window
+var ErrNoData = errors.New("no news data")
+
+// Source provides news articles about a ticker, such as *eodhd.Client
+type Source interface {
+	GetNews(ticker string, from, to string, limit int) ([]eodhd.NewsArticle, error)
+}
+
+// SentimentSource provides the provider's own daily sentiment, such as
+// *eodhd.Client
+type SentimentSource interface {
+	GetSentiments(tickers []string, from, to string) (map[string][]eodhd.DailySentiment, error)
+}
+
+// Article is a stored article's metadata. Sentiment is nil when the scorer
+// failed on it.
+type Article struct {
+	Ticker      string   `json:"ticker"`
+	PublishedAt string   `json:"published_at"`
+	Title       string   `json:"title"`
+	Link        string   `json:"link"`
+	Tags        []string `json:"tags"`
+	Sentiment   *float64 `json:"sentiment"`
+	Scorer      string   `json:"scorer"`
+}
+
+// Sentiment is the mean sentiment of a ticker's articles over a window
+type Sentiment struct {
+	Score    float64 `json:"score"`
+	Articles int     `json:"articles"`
+}
+
+const (
+	// historyDays are fetched for a ticker without stored articles
+	historyDays = 30
+	// fetchLimit caps the articles fetched per sync
+	fetchLimit = 100
+)
+
+// Sync fetches the ticker's articles since its latest stored one through to,
+// scores and stores them, and returns how many were fetched. Articles the
+// scorer fails on are stored without a sentiment.
+func Sync(db *sql.DB, source Source, scorer Scorer, ticker string, to time.Time) (int, error) {
+	var latest sql.NullString
+	if err := db.QueryRow("SELECT MAX(published_at) FROM news WHERE ticker = ?", ticker).Scan(&latest); err != nil {
+		return 0, fmt.Errorf("failed to query news: %w", err)
+	}
+	from := to.AddDate(0, 0, -historyDays).Format("2006-01-02")
+	if latest.Valid && len(latest.String) >= 10 {
+		from = latest.String[:10]
+	}
+
+	articles, err := source.GetNews(ticker, from, to.Format("2006-01-02"), fetchLimit)
+	if err != nil {
+		return 0, fmt.Errorf("error getting news for %s: %w", ticker, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, a := range articles {
+		if a.Link == "" {
+			continue
+		}
+		var sentiment *float64
+		if score, err := scorer.Score(a); err != nil {
+			log.Printf("Failed to score %s article %q: %v", ticker, a.Title, err)
+		} else {
+			sentiment = &score
+		}
+		tags, err := json.Marshal(a.Tags)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO news (ticker, link, published_at, title, tags, sentiment, scorer)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(ticker, link) DO UPDATE SET
+				published_at = excluded.published_at, title = excluded.title, tags = excluded.tags,
+				sentiment = COALESCE(excluded.sentiment, news.sentiment), scorer = excluded.scorer`,
+			ticker, a.Link, a.Date, a.Title, string(tags), sentiment, scorer.Name(),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save %s article: %w", ticker, err)
+		}
+	}
+	return len(articles), tx.Commit()
+}
+
+// RollingSentiment averages the sentiment of the ticker's articles published
+// in the days up to asOf
+func RollingSentiment(db *sql.DB, ticker string, asOf time.Time, days int) (Sentiment, error) {
+	var s Sentiment
+	var score sql.NullFloat64
+	err := db.QueryRow(`
+		SELECT AVG(sentiment), COUNT(sentiment) FROM news
+		WHERE ticker = ? AND published_at >= ? AND published_at < ?`,
+		ticker, asOf.AddDate(0, 0, -days).Format("2006-01-02"), asOf.AddDate(0, 0, 1).Format("2006-01-02"),
+	).Scan(&score, &s.Articles)
+	if err != nil {
+		return Sentiment{}, fmt.Errorf("failed to query news sentiment: %w", err)
+	}
+	if !score.Valid {
+		return Sentiment{}, ErrNoData
+	}
+	s.Score = score.Float64
+	return s, nil
+}
+
+// ProviderSentiment averages the source's daily sentiment of the ticker over
+// the days up to asOf, weighting each day by its articles. It stands in for
+// RollingSentiment when none of the stored articles could be scored.
+func ProviderSentiment(source SentimentSource, ticker string, asOf time.Time, days int) (Sentiment, error) {
+	from, to := asOf.AddDate(0, 0, -days).Format("2006-01-02"), asOf.Format("2006-01-02")
+	result, err := source.GetSentiments([]string{ticker}, from, to)
+	if err != nil {
+		return Sentiment{}, fmt.Errorf("error getting sentiment for %s: %w", ticker, err)
+	}
+
+	// The response is keyed by the provider's symbol, which may carry an
+	// exchange suffix, so every entry is the one ticker asked for
+	var s Sentiment
+	var total float64
+	for _, daily := range result {
+		for _, d := range daily {
+			if d.Count <= 0 || d.Date < from || d.Date > to {
+				continue
+			}
+			total += d.Normalized * float64(d.Count)
+			s.Articles += d.Count
+		}
+	}
+	if s.Articles == 0 {
+		return Sentiment{}, ErrNoData
+	}
+	s.Score = total / float64(s.Articles)
+	return s, nil
+}
+
+// Recent returns the ticker's latest articles, newest first
+func Recent(db *sql.DB, ticker string, limit int) ([]Article, error) {
+	rows, err := db.Query(`
+		SELECT ticker, published_at, title, link, COALESCE(tags, '[]'), sentiment, scorer
+		FROM news WHERE ticker = ?
+		ORDER BY published_at DESC LIMIT ?`, ticker, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query news: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Article{}
+	for rows.Next() {
+		var a Article
+		var tags string
+		if err := rows.Scan(&a.Ticker, &a.PublishedAt, &a.Title, &a.Link, &tags, &a.Sentiment, &a.Scorer); err != nil {
+			return nil, fmt.Errorf("failed to scan article: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tags), &a.Tags); err != nil {
+			return nil, fmt.Errorf("invalid article tags: %w", err)
+		}
+		if a.Tags == nil {
+			a.Tags = []string{}
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,221 @@
+package news
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE news (
+			ticker TEXT NOT NULL,
+			link TEXT NOT NULL,
+			published_at TEXT NOT NULL,
+			title TEXT NOT NULL,
+			tags JSON NOT NULL DEFAULT '[]',
+			sentiment REAL,
+			scorer TEXT NOT NULL,
+			PRIMARY KEY (ticker, link)
+		);`)
+	if err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return db
+}
+
+// fakeSource serves fixed articles and records the requested range
+type fakeSource struct {
+	articles []eodhd.NewsArticle
+	from, to string
+}
+
+func (s *fakeSource) GetNews(ticker string, from, to string, limit int) ([]eodhd.NewsArticle, error) {
+	s.from, s.to = from, to
+	return s.articles, nil
+}
+
+// fakeLLM replies with a fixed completion and records the prompt
+type fakeLLM struct {
+	reply  string
+	err    error
+	prompt string
+}
+
+func (f *fakeLLM) Complete(prompt string) (string, error) {
+	f.prompt = prompt
+	return f.reply, f.err
+}
+
+func TestLexiconScorer(t *testing.T) {
+	tests := []struct {
+		name    string
+		article eodhd.NewsArticle
+		want    float64
+	}{
+		{"positive", eodhd.NewsArticle{Title: "Apple beats estimates as iPhone sales surge"}, 1},
+		{"negative", eodhd.NewsArticle{Title: "Shares plunge after guidance cut", Content: "Analysts downgraded the stock."}, -1},
+		// The title's two positive words count twice against one negative
+		{"mixed", eodhd.NewsArticle{Title: "Record profit", Content: "Margins declined."}, 0.6},
+		{"negated", eodhd.NewsArticle{Title: "Results did not beat expectations"}, -1},
+		{"neutral", eodhd.NewsArticle{Title: "Company to hold annual meeting"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LexiconScorer{}.Score(tt.article)
+			if err != nil {
+				t.Fatalf("Score failed: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLLMScorer(t *testing.T) {
+	llm := &fakeLLM{reply: " 0.7\n"}
+	scorer := LLMScorer{Provider: llm, MaxContent: 5}
+	got, err := scorer.Score(eodhd.NewsArticle{Title: "Upbeat guidance", Content: "Long article body"})
+	if err != nil || got != 0.7 {
+		t.Errorf("Expected 0.7, got %v (%v)", got, err)
+	}
+	if !strings.Contains(llm.prompt, "Upbeat guidance") || strings.Contains(llm.prompt, "article body") {
+		t.Errorf("Expected the title and truncated content in the prompt, got %q", llm.prompt)
+	}
+
+	llm.reply = "3"
+	if got, _ := scorer.Score(eodhd.NewsArticle{}); got != 1 {
+		t.Errorf("Expected scores clamped to 1, got %v", got)
+	}
+	llm.reply = "quite positive"
+	if _, err := scorer.Score(eodhd.NewsArticle{}); err == nil {
+		t.Error("Expected an error for a reply that isn't a number")
+	}
+	llm.err = errors.New("unavailable")
+	if _, err := scorer.Score(eodhd.NewsArticle{}); err == nil {
+		t.Error("Expected the provider's error")
+	}
+}
+
+func TestSync(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	source := &fakeSource{articles: []eodhd.NewsArticle{
+		{Date: "2024-01-14T09:30:00+00:00", Title: "Apple beats estimates", Link: "https://news/1", Tags: []string{"earnings"},
+			Sentiment: &eodhd.NewsSentiment{Polarity: 0.9}},
+		{Date: "2024-01-10T12:00:00+00:00", Title: "Apple faces lawsuit", Link: "https://news/2"},
+		{Date: "2024-01-09T12:00:00+00:00", Title: "No link"},
+	}}
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	n, err := Sync(db, source, ProviderScorer{}, "AAPL", now)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if n != 3 || source.from != "2023-12-16" || source.to != "2024-01-15" {
+		t.Errorf("Expected 3 articles over 30 days, got %d from %s to %s", n, source.from, source.to)
+	}
+
+	articles, err := Recent(db, "AAPL", 10)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(articles) != 2 || articles[0].Link != "https://news/1" || articles[0].Tags[0] != "earnings" {
+		t.Fatalf("Expected the two linked articles newest first, got %+v", articles)
+	}
+	// The provider scorer fails without EODHD's sentiment
+	if articles[0].Sentiment == nil || *articles[0].Sentiment != 0.9 || articles[1].Sentiment != nil {
+		t.Errorf("Unexpected sentiments %v and %v", articles[0].Sentiment, articles[1].Sentiment)
+	}
+
+	// The next sync continues from the latest article
+	if _, err := Sync(db, source, LexiconScorer{}, "AAPL", now.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if source.from != "2024-01-14" {
+		t.Errorf("Expected to continue from 2024-01-14, got %s", source.from)
+	}
+	articles, _ = Recent(db, "AAPL", 10)
+	if s := articles[1].Sentiment; s == nil || *s != -1 {
+		t.Errorf("Expected the lexicon to score the lawsuit -1, got %v", s)
+	}
+}
+
+func TestRollingSentiment(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		INSERT INTO news (ticker, link, published_at, title, sentiment, scorer) VALUES
+		('AAPL', 'a', '2024-01-15T20:00:00+00:00', 'Today', 0.8, 'lexicon'),
+		('AAPL', 'b', '2024-01-02T10:00:00+00:00', 'Earlier', 0.2, 'lexicon'),
+		('AAPL', 'c', '2024-01-03T10:00:00+00:00', 'Unscored', NULL, 'llm'),
+		('AAPL', 'd', '2023-11-01T10:00:00+00:00', 'Too old', -1, 'lexicon'),
+		('MSFT', 'e', '2024-01-10T10:00:00+00:00', 'Other ticker', -1, 'lexicon')`)
+	if err != nil {
+		t.Fatalf("Failed to insert articles: %v", err)
+	}
+
+	s, err := RollingSentiment(db, "AAPL", time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), 30)
+	if err != nil {
+		t.Fatalf("RollingSentiment failed: %v", err)
+	}
+	if s.Articles != 2 || math.Abs(s.Score-0.5) > 1e-9 {
+		t.Errorf("Expected 0.5 over two articles, got %+v", s)
+	}
+
+	if _, err := RollingSentiment(db, "IBM", time.Now(), 30); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got %v", err)
+	}
+}
+
+// fakeSentimentSource serves fixed daily sentiment and records the request
+type fakeSentimentSource struct {
+	daily    map[string][]eodhd.DailySentiment
+	tickers  []string
+	from, to string
+}
+
+func (s *fakeSentimentSource) GetSentiments(tickers []string, from, to string) (map[string][]eodhd.DailySentiment, error) {
+	s.tickers, s.from, s.to = tickers, from, to
+	return s.daily, nil
+}
+
+func TestProviderSentiment(t *testing.T) {
+	source := &fakeSentimentSource{daily: map[string][]eodhd.DailySentiment{
+		"AAPL.US": {
+			{Date: "2024-01-15", Count: 3, Normalized: 0.6},
+			{Date: "2024-01-10", Count: 1, Normalized: -0.2},
+			{Date: "2024-01-09", Count: 0, Normalized: 0.9},
+			{Date: "2023-11-01", Count: 5, Normalized: -1},
+		},
+	}}
+
+	s, err := ProviderSentiment(source, "AAPL.US", time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), 30)
+	if err != nil {
+		t.Fatalf("ProviderSentiment failed: %v", err)
+	}
+	// Weighted by articles: (3 × 0.6 − 0.2) / 4
+	if s.Articles != 4 || math.Abs(s.Score-0.4) > 1e-9 {
+		t.Errorf("Expected 0.4 over four articles, got %+v", s)
+	}
+	if len(source.tickers) != 1 || source.from != "2023-12-16" || source.to != "2024-01-15" {
+		t.Errorf("Unexpected request: %v from %s to %s", source.tickers, source.from, source.to)
+	}
+
+	if _, err := ProviderSentiment(&fakeSentimentSource{}, "IBM", time.Now(), 30); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got %v", err)
+	}
+}
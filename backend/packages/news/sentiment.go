@@ -0,0 +1,137 @@
+package news
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// Scorer rates an article's sentiment from -1 (negative) to 1 (positive)
+type Scorer interface {
+	// Name is stored with each article it scored
+	Name() string
+	Score(a eodhd.NewsArticle) (float64, error)
+}
+
+// LexiconScorer counts positive and negative financial words in the title
+// and content. Titles count twice, and a word right after a negation such as
+// "not" counts the opposite way. Articles without any sentiment words are 0.
+type LexiconScorer struct{}
+
+func (LexiconScorer) Name() string { return "lexicon" }
+
+func (LexiconScorer) Score(a eodhd.NewsArticle) (float64, error) {
+	pos, neg := countSentiment(a.Title)
+	pos, neg = 2*pos, 2*neg
+	p, n := countSentiment(a.Content)
+	pos, neg = pos+p, neg+n
+
+	if pos+neg == 0 {
+		return 0, nil
+	}
+	return float64(pos-neg) / float64(pos+neg), nil
+}
+
+func countSentiment(text string) (pos, neg int) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for i, word := range words {
+		polarity := lexicon[word]
+		if polarity == 0 {
+			continue
+		}
+		if i > 0 && negations[words[i-1]] {
+			polarity = -polarity
+		}
+		if polarity > 0 {
+			pos++
+		} else {
+			neg++
+		}
+	}
+	return pos, neg
+}
+
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "without": true, "isn't": true, "wasn't": true, "didn't": true, "won't": true,
+}
+
+// lexicon maps financial news words to their polarity
+var lexicon = func() map[string]int {
+	positive := []string{
+		"beat", "beats", "exceeded", "exceeds", "surge", "surged", "surges", "soar", "soared", "soars",
+		"rally", "rallied", "gain", "gains", "gained", "growth", "grew", "record", "upgrade", "upgraded",
+		"outperform", "outperformed", "strong", "stronger", "robust", "profit", "profitable", "raise", "raised",
+		"raises", "bullish", "optimistic", "boost", "boosted", "expand", "expansion", "improve", "improved",
+		"rebound", "rebounded", "buyback", "win", "wins", "breakthrough", "approval",
+	}
+	negative := []string{
+		"miss", "missed", "misses", "plunge", "plunged", "plunges", "drop", "dropped", "drops", "fall", "fell",
+		"falls", "decline", "declined", "declines", "loss", "losses", "downgrade", "downgraded", "underperform",
+		"weak", "weaker", "cut", "cuts", "slash", "slashed", "bearish", "pessimistic", "lawsuit", "probe",
+		"investigation", "recall", "layoffs", "bankruptcy", "default", "warning", "warns", "slump",
+		"slumped", "fraud", "fined", "concern", "concerns", "delay", "delayed",
+	}
+	m := map[string]int{}
+	for _, w := range positive {
+		m[w] = 1
+	}
+	for _, w := range negative {
+		m[w] = -1
+	}
+	return m
+}()
+
+// ProviderScorer uses the polarity EODHD attaches to its articles
+type ProviderScorer struct{}
+
+func (ProviderScorer) Name() string { return "eodhd" }
+
+func (ProviderScorer) Score(a eodhd.NewsArticle) (float64, error) {
+	if a.Sentiment == nil {
+		return 0, errors.New("article has no sentiment")
+	}
+	return a.Sentiment.Polarity, nil
+}
+
+// LLMProvider completes a prompt with a language model
+type LLMProvider interface {
+	Complete(prompt string) (string, error)
+}
+
+// LLMScorer asks a language model to rate each article
+type LLMScorer struct {
+	Provider LLMProvider
+	// MaxContent truncates the content sent, in bytes; 0 sends all of it
+	MaxContent int
+}
+
+func (LLMScorer) Name() string { return "llm" }
+
+func (s LLMScorer) Score(a eodhd.NewsArticle) (float64, error) {
+	content := a.Content
+	if s.MaxContent > 0 && len(content) > s.MaxContent {
+		content = content[:s.MaxContent]
+	}
+	prompt := fmt.Sprintf(`Rate the sentiment of this financial news article for the companies' shareholders on a scale from -1 (very negative) to 1 (very positive). Reply with the number only.
+
+Title: %s
+
+%s`, a.Title, content)
+
+	reply, err := s.Provider.Complete(prompt)
+	if err != nil {
+		return 0, fmt.Errorf("llm request failed: %w", err)
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(reply), 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, fmt.Errorf("llm replied %q instead of a score", reply)
+	}
+	return math.Max(-1, math.Min(1, score)), nil
+}
@@ -0,0 +1,221 @@
+// Package openapi builds an OpenAPI 3.1 document from Go types and route
+// registrations, and validates query parameters against it
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Document is the root of an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// types remembers which Go type owns each component name
+	types map[string]reflect.Type
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to their operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path or query parameter. Parameters serialized as
+// JSON (such as screener filters) use Content instead of Schema.
+type Parameter struct {
+	Name        string                `json:"name"`
+	In          string                `json:"in"`
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Schema      *Schema               `json:"schema,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+	Style       string                `json:"style,omitempty"`
+	Explode     *bool                 `json:"explode,omitempty"`
+	// ErrorCode is reported when the parameter fails validation
+	ErrorCode string `json:"x-error-code,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Spec describes one method on a route. Body and Response are zero values of
+// the request and response types; a nil Response means no content.
+// AltContent lists other media types the success response may be sent as,
+// such as file downloads.
+type Spec struct {
+	Method     string
+	ID         string
+	Summary    string
+	Tag        string
+	Query      []*Parameter
+	Body       any
+	Status     int
+	Response   any
+	AltContent []string
+}
+
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI: "3.1.0",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+		types: map[string]reflect.Type{},
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Add registers the operation described by spec under path, which uses the
+// same {name} wildcards as http.ServeMux patterns
+func (d *Document) Add(path string, spec Spec) *Operation {
+	op := &Operation{
+		OperationID: spec.ID,
+		Summary:     spec.Summary,
+		Responses:   map[string]*Response{},
+	}
+	if spec.Tag != "" {
+		op.Tags = []string{spec.Tag}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   pathParamSchema(m[1]),
+		})
+	}
+	for _, p := range spec.Query {
+		p.In = "query"
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if spec.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(d.SchemaOf(spec.Body)),
+		}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := &Response{Description: http.StatusText(status)}
+	if spec.Response != nil {
+		resp.Content = jsonContent(d.SchemaOf(spec.Response))
+	}
+	for _, mediaType := range spec.AltContent {
+		if resp.Content == nil {
+			resp.Content = map[string]*MediaType{}
+		}
+		resp.Content[mediaType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(spec.Method)] = op
+	return op
+}
+
+// AddResponse documents an additional response carrying v as JSON
+func (d *Document) AddResponse(op *Operation, status string, description string, v any) {
+	resp := &Response{Description: description}
+	if v != nil {
+		resp.Content = jsonContent(d.SchemaOf(v))
+	}
+	op.Responses[status] = resp
+}
+
+// SchemaOf returns the schema for the dynamic type of v, registering named
+// struct types as components
+func (d *Document) SchemaOf(v any) *Schema {
+	if alternatives, ok := v.(OneOf); ok {
+		s := &Schema{}
+		for _, alt := range alternatives {
+			s.OneOf = append(s.OneOf, d.SchemaOf(alt))
+		}
+		return s
+	}
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+// JSONParameter describes a query parameter whose value is JSON matching schema
+func JSONParameter(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, Description: description, Content: jsonContent(schema)}
+}
+
+// ListParameter describes a query parameter holding a comma-separated list of
+// values matching items, e.g. columns=ticker,close
+func ListParameter(name, description string, items *Schema) *Parameter {
+	return &Parameter{
+		Name:        name,
+		Description: description,
+		Schema:      &Schema{Type: "array", Items: items},
+		Style:       "form",
+		Explode:     Ptr(false),
+	}
+}
+
+// pathParamSchema types id-like wildcards as positive integers
+func pathParamSchema(name string) *Schema {
+	if name == "id" || strings.HasSuffix(name, "ID") {
+		return &Schema{Type: "integer", Format: "int64", Minimum: Ptr(1.0)}
+	}
+	return &Schema{Type: "string"}
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+type testItem struct {
+	Ticker string   `json:"ticker"`
+	Notes  string   `json:"notes,omitempty"`
+	Target *float64 `json:"target_price"`
+	Hidden int64    `json:"-"`
+}
+
+type testList struct {
+	ID    int64      `json:"id"`
+	Items []testItem `json:"items"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	doc := New("Test", "1")
+	s := doc.SchemaOf(testList{})
+	if s.Ref != "#/components/schemas/testList" {
+		t.Fatalf("Expected a component reference, got %+v", s)
+	}
+
+	list := doc.Components.Schemas["testList"]
+	if list.Properties["items"].Items.Ref != "#/components/schemas/testItem" {
+		t.Errorf("Expected items to reference testItem, got %+v", list.Properties["items"])
+	}
+
+	item := doc.Components.Schemas["testItem"]
+	if _, ok := item.Properties["Hidden"]; ok {
+		t.Error("Expected json:\"-\" fields to be skipped")
+	}
+	if !slices.Equal(item.Required, []string{"ticker"}) {
+		t.Errorf("Expected only ticker to be required, got %v", item.Required)
+	}
+	if got, _ := json.Marshal(item.Properties["target_price"].Type); string(got) != `["number","null"]` {
+		t.Errorf("Expected pointer fields to be nullable, got %s", got)
+	}
+}
+
+func TestAddDerivesPathParameters(t *testing.T) {
+	doc := New("Test", "1")
+	op := doc.Add("/api/lists/{id}/items/{ticker}", Spec{Method: "DELETE", ID: "removeItem", Status: 204})
+
+	if len(op.Parameters) != 2 {
+		t.Fatalf("Expected 2 path parameters, got %d", len(op.Parameters))
+	}
+	if op.Parameters[0].Schema.Type != "integer" || op.Parameters[1].Schema.Type != "string" {
+		t.Errorf("Expected id to be an integer and ticker a string, got %v and %v",
+			op.Parameters[0].Schema.Type, op.Parameters[1].Schema.Type)
+	}
+	if _, ok := (*doc.Paths["/api/lists/{id}/items/{ticker}"])["delete"]; !ok {
+		t.Error("Expected the operation to be stored under its lower-case method")
+	}
+	if resp := op.Responses["204"]; resp == nil || resp.Content != nil {
+		t.Errorf("Expected a 204 response without content, got %+v", resp)
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	condition := &Schema{
+		Type:        "array",
+		PrefixItems: []*Schema{StringEnum([]string{"pe_ratio", "roe"}), StringEnum([]string{"<", ">"}), {}},
+		MinItems:    Ptr(3),
+		MaxItems:    Ptr(3),
+	}
+	filters := JSONParameter("filters", "", &Schema{Type: "array", Items: condition})
+	filters.ErrorCode = "INVALID_FILTER"
+
+	doc := New("Test", "1")
+	op := doc.Add("/api/screener", Spec{Method: "GET", ID: "screen", Query: []*Parameter{
+		filters,
+		{Name: "limit", Schema: &Schema{Type: "integer", Minimum: Ptr(1.0), Maximum: Ptr(100.0)}},
+	}})
+
+	tests := []struct {
+		name  string
+		query string
+		param string
+	}{
+		{"valid", `filters=[["pe_ratio","<",15]]&limit=10`, ""},
+		{"absent", ``, ""},
+		{"unknown field", `filters=[["price) OR (1","<",15]]`, "filters[0][0]"},
+		{"unknown operator", `filters=[["roe","~",1]]`, "filters[0][1]"},
+		{"short condition", `filters=[["roe","<"]]`, "filters[0]"},
+		{"malformed JSON", `filters=[`, "filters"},
+		{"limit too large", `limit=500`, "limit"},
+		{"limit not an integer", `limit=ten`, "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = op.ValidateQuery(query)
+			if tt.param == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			if verr.Param != tt.param {
+				t.Errorf("Expected error on %s, got %s (%s)", tt.param, verr.Param, verr.Message)
+			}
+		})
+	}
+}
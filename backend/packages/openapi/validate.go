@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError reports the parameter that failed validation
+type ValidationError struct {
+	Param     string `json:"param"`
+	ErrorCode string `json:"-"`
+	Message   string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Param + ": " + e.Message
+}
+
+// ValidateQuery checks the query parameters declared on op. Parameters that
+// are not declared are ignored; empty values are treated as absent.
+func (op *Operation) ValidateQuery(query url.Values) error {
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+
+		raw := query.Get(p.Name)
+		if raw == "" {
+			if p.Required {
+				return p.invalid(p.Name, "is required")
+			}
+			continue
+		}
+
+		if media, ok := p.Content["application/json"]; ok {
+			var v any
+			if err := json.Unmarshal([]byte(raw), &v); err != nil {
+				return p.invalid(p.Name, "must be valid JSON: "+err.Error())
+			}
+			if err := validateValue(media.Schema, v, p.Name); err != nil {
+				return p.invalid(err.path, err.message)
+			}
+			continue
+		}
+
+		var v any
+		if p.Schema.Type == "array" {
+			items := []any{}
+			for i, item := range strings.Split(raw, ",") {
+				parsed, err := parseScalar(p.Schema.Items, strings.TrimSpace(item))
+				if err != nil {
+					return p.invalid(fmt.Sprintf("%s[%d]", p.Name, i), err.Error())
+				}
+				items = append(items, parsed)
+			}
+			v = items
+		} else {
+			parsed, err := parseScalar(p.Schema, raw)
+			if err != nil {
+				return p.invalid(p.Name, err.Error())
+			}
+			v = parsed
+		}
+		if err := validateValue(p.Schema, v, p.Name); err != nil {
+			return p.invalid(err.path, err.message)
+		}
+	}
+	return nil
+}
+
+func (p *Parameter) invalid(path, message string) *ValidationError {
+	return &ValidationError{Param: path, ErrorCode: p.ErrorCode, Message: message}
+}
+
+// parseScalar converts a raw query value to the Go value JSON decoding would
+// produce for the schema's type
+func parseScalar(s *Schema, raw string) (any, error) {
+	if s == nil {
+		return raw, nil
+	}
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return float64(n), nil
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+type valueError struct {
+	path    string
+	message string
+}
+
+// validateValue checks a decoded JSON value against the schema keywords the
+// generator emits. References are not followed.
+func validateValue(s *Schema, v any, path string) *valueError {
+	if s == nil {
+		return nil
+	}
+
+	if !matchesType(s.Type, v) {
+		return &valueError{path, "must be of type " + typeName(s.Type)}
+	}
+
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = fmt.Sprintf("%v", e)
+		}
+		return &valueError{path, fmt.Sprintf("%v is not one of %s", v, strings.Join(allowed, ", "))}
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return &valueError{path, fmt.Sprintf("must be at least %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return &valueError{path, fmt.Sprintf("must be at most %v", *s.Maximum)}
+		}
+
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return &valueError{path, fmt.Sprintf("must have at least %d items", *s.MinItems)}
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return &valueError{path, fmt.Sprintf("must have at most %d items", *s.MaxItems)}
+		}
+		for i, item := range v {
+			itemSchema := s.Items
+			if i < len(s.PrefixItems) {
+				itemSchema = s.PrefixItems[i]
+			}
+			if err := validateValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return &valueError{path + "." + name, "is required"}
+			}
+		}
+		for name, item := range v {
+			itemSchema, ok := s.Properties[name]
+			if !ok {
+				itemSchema = s.AdditionalProperties
+			}
+			if err := validateValue(itemSchema, item, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func matchesType(t any, v any) bool {
+	switch t := t.(type) {
+	case nil:
+		return true
+	case string:
+		return matchesSingleType(t, v)
+	case []string:
+		return slices.ContainsFunc(t, func(t string) bool { return matchesSingleType(t, v) })
+	}
+	return true
+}
+
+func matchesSingleType(t string, v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && v == float64(int64(v)))
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+func typeName(t any) string {
+	if types, ok := t.([]string); ok {
+		return strings.Join(types, " or ")
+	}
+	return fmt.Sprintf("%v", t)
+}
@@ -0,0 +1,223 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Performance holds the return measures of a portfolio over its lifetime
+type Performance struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// TimeWeightedReturn is the cumulative return with the effect of deposits and withdrawals removed
+	TimeWeightedReturn float64 `json:"time_weighted_return"`
+	// MoneyWeightedReturn is the annualised internal rate of return of all cash flows
+	MoneyWeightedReturn float64 `json:"money_weighted_return"`
+	NetContributions    float64 `json:"net_contributions"`
+	EndValue            float64 `json:"end_value"`
+}
+
+// ValuationPoint is the portfolio value at the close of a day together with that day's external flows
+type ValuationPoint struct {
+	Date          string  `json:"date"`
+	Value         float64 `json:"value"`
+	Contributions float64 `json:"contributions"`
+	Withdrawals   float64 `json:"withdrawals"`
+}
+
+// CashFlow is a dated amount from the investor's point of view
+type CashFlow struct {
+	Date   string
+	Amount float64
+}
+
+// CalculatePerformance values the portfolio on every transaction and price date
+// and derives time- and money-weighted returns. history holds closes per ticker,
+// oldest first.
+func CalculatePerformance(txs []Transaction, history map[string][]PricePoint) Performance {
+	points := ValuationSeries(txs, history)
+	if len(points) == 0 {
+		return Performance{}
+	}
+
+	last := points[len(points)-1]
+	perf := Performance{
+		StartDate:          points[0].Date,
+		EndDate:            last.Date,
+		TimeWeightedReturn: CalculateTWR(points),
+		EndValue:           last.Value,
+	}
+
+	flows := make([]CashFlow, 0, len(txs)+1)
+	for _, t := range txs {
+		flows = append(flows, CashFlow{Date: t.Date, Amount: t.CashFlow()})
+		perf.NetContributions -= t.CashFlow()
+	}
+	flows = append(flows, CashFlow{Date: last.Date, Amount: last.Value})
+
+	if irr, err := CalculateXIRR(flows); err == nil {
+		perf.MoneyWeightedReturn = irr
+	}
+
+	return perf
+}
+
+// ValuationSeries replays transactions against price history and returns one
+// point per date on which a transaction or a price exists. Tickers without a
+// price on or before a date are valued at their last transaction price.
+func ValuationSeries(txs []Transaction, history map[string][]PricePoint) []ValuationPoint {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	ordered := make([]Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Date < ordered[j].Date
+	})
+	start := ordered[0].Date
+
+	dateSet := map[string]bool{}
+	for _, t := range ordered {
+		dateSet[t.Date] = true
+	}
+	for _, points := range history {
+		for _, p := range points {
+			if p.Date >= start {
+				dateSet[p.Date] = true
+			}
+		}
+	}
+	dates := make([]string, 0, len(dateSet))
+	for d := range dateSet {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+
+	holdings := map[string]float64{}
+	lastPrice := map[string]float64{}
+	cursor := map[string]int{}
+	next := 0
+
+	series := make([]ValuationPoint, 0, len(dates))
+	for _, date := range dates {
+		point := ValuationPoint{Date: date}
+
+		for next < len(ordered) && ordered[next].Date == date {
+			t := ordered[next]
+			switch t.Type {
+			case Buy:
+				holdings[t.Ticker] += t.Quantity
+				lastPrice[t.Ticker] = t.Price
+				point.Contributions += -t.CashFlow()
+			case Sell:
+				holdings[t.Ticker] -= t.Quantity
+				lastPrice[t.Ticker] = t.Price
+				point.Withdrawals += t.CashFlow()
+			case Dividend:
+				point.Withdrawals += t.CashFlow()
+			}
+			next++
+		}
+
+		for ticker, points := range history {
+			i := cursor[ticker]
+			for i < len(points) && points[i].Date <= date {
+				lastPrice[ticker] = points[i].Close
+				i++
+			}
+			cursor[ticker] = i
+		}
+
+		for ticker, qty := range holdings {
+			point.Value += qty * lastPrice[ticker]
+		}
+
+		series = append(series, point)
+	}
+
+	return series
+}
+
+// CalculateTWR chains daily holding period returns. Flows are assumed to happen
+// at the day's close, except when the portfolio was empty the day before; then
+// the day's contributions form the starting value.
+func CalculateTWR(points []ValuationPoint) float64 {
+	growth := 1.0
+	prev := 0.0
+	for _, p := range points {
+		if prev > 0 {
+			growth *= (p.Value + p.Withdrawals - p.Contributions) / prev
+		} else if p.Contributions > 0 {
+			growth *= (p.Value + p.Withdrawals) / p.Contributions
+		}
+		prev = p.Value
+	}
+	return growth - 1
+}
+
+// CalculateXIRR finds the annualised rate at which the net present value of the
+// dated cash flows is zero
+func CalculateXIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, errors.New("at least two cash flows are required")
+	}
+
+	t0, err := time.Parse("2006-01-02", flows[0].Date)
+	if err != nil {
+		return 0, err
+	}
+
+	years := make([]float64, len(flows))
+	hasIn, hasOut := false, false
+	for _, f := range flows {
+		d, err := time.Parse("2006-01-02", f.Date)
+		if err != nil {
+			return 0, err
+		}
+		if d.Before(t0) {
+			t0 = d
+		}
+		hasIn = hasIn || f.Amount < 0
+		hasOut = hasOut || f.Amount > 0
+	}
+	if !hasIn || !hasOut {
+		return 0, errors.New("cash flows must contain both deposits and withdrawals")
+	}
+	for i, f := range flows {
+		d, _ := time.Parse("2006-01-02", f.Date)
+		years[i] = d.Sub(t0).Hours() / 24 / 365
+	}
+
+	npv := func(rate float64) float64 {
+		sum := 0.0
+		for i, f := range flows {
+			sum += f.Amount / math.Pow(1+rate, years[i])
+		}
+		return sum
+	}
+
+	// Bisection is slow but cannot diverge, unlike Newton's method on irregular flows
+	low, high := -0.9999, 10.0
+	fLow, fHigh := npv(low), npv(high)
+	if fLow*fHigh > 0 {
+		return 0, errors.New("no rate of return found in range")
+	}
+
+	for range 200 {
+		mid := (low + high) / 2
+		fMid := npv(mid)
+		if math.Abs(fMid) < 1e-9 {
+			return mid, nil
+		}
+		if fLow*fMid < 0 {
+			high = mid
+		} else {
+			low, fLow = mid, fMid
+		}
+	}
+
+	return (low + high) / 2, nil
+}
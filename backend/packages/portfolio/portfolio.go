@@ -0,0 +1,423 @@
+package portfolio
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+var (
+	// ErrNotFound is returned when a portfolio does not exist
+	ErrNotFound = errors.New("portfolio not found")
+	// ErrTransactionNotFound is returned when a transaction does not exist in the portfolio
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrInsufficientQuantity is returned when a sell exceeds the shares held
+	ErrInsufficientQuantity = errors.New("sell exceeds position")
+)
+
+// TransactionType is the kind of a portfolio transaction
+type TransactionType string
+
+const (
+	Buy      TransactionType = "buy"
+	Sell     TransactionType = "sell"
+	Dividend TransactionType = "dividend"
+)
+
+// Portfolio is a named collection of transactions
+type Portfolio struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// Transaction is a single buy, sell or dividend booking.
+// For buys and sells Price is per share; for dividends Price is the dividend per share and
+// Amount the total cash received.
+type Transaction struct {
+	ID          int64           `json:"id"`
+	PortfolioID int64           `json:"portfolio_id"`
+	Ticker      string          `json:"ticker"`
+	Type        TransactionType `json:"type"`
+	Date        string          `json:"date"`
+	Quantity    float64         `json:"quantity"`
+	Price       float64         `json:"price"`
+	Fees        float64         `json:"fees"`
+	Amount      float64         `json:"amount"`
+	Notes       string          `json:"notes"`
+}
+
+// Validate checks a transaction before it is stored and fills in derived fields
+func (t *Transaction) Validate() error {
+	t.Ticker = strings.ToUpper(strings.TrimSpace(t.Ticker))
+	if t.Ticker == "" {
+		return errors.New("ticker is required")
+	}
+	if _, err := time.Parse("2006-01-02", t.Date); err != nil {
+		return errors.New("date must be in YYYY-MM-DD format")
+	}
+	if t.Fees < 0 {
+		return errors.New("fees cannot be negative")
+	}
+
+	switch t.Type {
+	case Buy, Sell:
+		if t.Quantity <= 0 {
+			return errors.New("quantity must be positive")
+		}
+		if t.Price < 0 {
+			return errors.New("price cannot be negative")
+		}
+		t.Amount = t.Quantity * t.Price
+	case Dividend:
+		if t.Amount == 0 {
+			t.Amount = t.Quantity * t.Price
+		}
+		if t.Amount <= 0 {
+			return errors.New("dividend amount must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown transaction type %q", t.Type)
+	}
+
+	return nil
+}
+
+// CashFlow returns the transaction's cash flow from the investor's point of view:
+// negative for money put in, positive for money taken out.
+func (t Transaction) CashFlow() float64 {
+	switch t.Type {
+	case Buy:
+		return -(t.Amount + t.Fees)
+	case Sell:
+		return t.Amount - t.Fees
+	case Dividend:
+		return t.Amount - t.Fees
+	}
+	return 0
+}
+
+// DividendSource provides historical dividends per share, such as *eodhd.Client
+type DividendSource interface {
+	GetDividends(ticker string, from, to string) ([]eodhd.Dividend, error)
+}
+
+func CreatePortfolio(db *sql.DB, userID int64, name, description string) (Portfolio, error) {
+	res, err := db.Exec(`
+		INSERT INTO portfolios (user_id, name, description, created_at)
+		VALUES (?, ?, ?, datetime('now'))`,
+		userID, name, description,
+	)
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("failed to insert portfolio: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Portfolio{}, err
+	}
+
+	return GetPortfolio(db, userID, id)
+}
+
+func GetPortfolio(db *sql.DB, userID, id int64) (Portfolio, error) {
+	var p Portfolio
+	err := db.QueryRow(`
+		SELECT id, name, description, created_at
+		FROM portfolios WHERE id = ? AND user_id = ?`, id, userID,
+	).Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Portfolio{}, ErrNotFound
+	}
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+	return p, nil
+}
+
+func ListPortfolios(db *sql.DB, userID int64) ([]Portfolio, error) {
+	rows, err := db.Query(`
+		SELECT id, name, description, created_at
+		FROM portfolios
+		WHERE user_id = ?
+		ORDER BY name ASC, id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	portfolios := []Portfolio{}
+	for rows.Next() {
+		var p Portfolio
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		portfolios = append(portfolios, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return portfolios, nil
+}
+
+// DeletePortfolio removes a portfolio and all of its transactions
+func DeletePortfolio(db *sql.DB, userID, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM portfolios WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete portfolio: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM portfolio_transactions WHERE portfolio_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete transactions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// AddTransaction validates and records a transaction. Sells that exceed the
+// position held at that date are rejected.
+func AddTransaction(db *sql.DB, userID int64, t Transaction) (Transaction, error) {
+	if err := t.Validate(); err != nil {
+		return Transaction{}, err
+	}
+
+	existing, err := ListTransactions(db, userID, t.PortfolioID)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	if _, err := BuildPositions(append(existing, t)); err != nil {
+		return Transaction{}, err
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO portfolio_transactions (portfolio_id, ticker, type, date, quantity, price, fees, amount, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.PortfolioID, t.Ticker, t.Type, t.Date, t.Quantity, t.Price, t.Fees, t.Amount, t.Notes,
+	)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to insert transaction: %w", err)
+	}
+
+	t.ID, err = res.LastInsertId()
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	return t, nil
+}
+
+// ListTransactions returns a portfolio's transactions in booking order
+func ListTransactions(db *sql.DB, userID, portfolioID int64) ([]Transaction, error) {
+	if _, err := GetPortfolio(db, userID, portfolioID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, portfolio_id, ticker, type, date, quantity, price, fees, amount, notes
+		FROM portfolio_transactions
+		WHERE portfolio_id = ?
+		ORDER BY date ASC, id ASC`, portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	txs := []Transaction{}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.PortfolioID, &t.Ticker, &t.Type, &t.Date, &t.Quantity, &t.Price, &t.Fees, &t.Amount, &t.Notes); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		txs = append(txs, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return txs, nil
+}
+
+// DeleteTransaction removes a transaction unless doing so would leave a later
+// sell without enough shares
+func DeleteTransaction(db *sql.DB, userID, portfolioID, transactionID int64) error {
+	txs, err := ListTransactions(db, userID, portfolioID)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]Transaction, 0, len(txs))
+	for _, t := range txs {
+		if t.ID != transactionID {
+			remaining = append(remaining, t)
+		}
+	}
+	if len(remaining) == len(txs) {
+		return ErrTransactionNotFound
+	}
+	if _, err := BuildPositions(remaining); err != nil {
+		return err
+	}
+
+	res, err := db.Exec(`
+		DELETE FROM portfolio_transactions WHERE portfolio_id = ? AND id = ?`,
+		portfolioID, transactionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
+
+// SyncDividends books dividend transactions for every dividend paid on a position
+// the portfolio held before the ex-date. Dividends already booked for the same
+// ticker and date are skipped. It returns the newly booked transactions.
+func SyncDividends(db *sql.DB, source DividendSource, userID, portfolioID int64) ([]Transaction, error) {
+	txs, err := ListTransactions(db, userID, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	booked := map[string]bool{}
+	firstBuy := map[string]string{}
+	for _, t := range txs {
+		switch t.Type {
+		case Dividend:
+			booked[t.Ticker+"|"+t.Date] = true
+		case Buy:
+			if _, ok := firstBuy[t.Ticker]; !ok {
+				firstBuy[t.Ticker] = t.Date
+			}
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	added := []Transaction{}
+	for ticker, from := range firstBuy {
+		divs, err := source.GetDividends(ticker, from, today)
+		if err != nil {
+			return added, fmt.Errorf("error getting dividends for %s: %w", ticker, err)
+		}
+
+		for _, d := range divs {
+			if booked[ticker+"|"+d.Date] || d.Value <= 0 {
+				continue
+			}
+
+			qty := QuantityHeldBefore(txs, ticker, d.Date)
+			if qty <= 0 {
+				continue
+			}
+
+			t, err := AddTransaction(db, userID, Transaction{
+				PortfolioID: portfolioID,
+				Ticker:      ticker,
+				Type:        Dividend,
+				Date:        d.Date,
+				Quantity:    qty,
+				Price:       d.Value,
+				Amount:      qty * d.Value,
+				Notes:       "imported from EODHD",
+			})
+			if err != nil {
+				return added, err
+			}
+			added = append(added, t)
+		}
+	}
+
+	return added, nil
+}
+
+// latestPrices returns the most recent close for each ticker in the prices table
+func latestPrices(db *sql.DB, tickers []string) (map[string]PricePoint, error) {
+	prices := map[string]PricePoint{}
+	if len(tickers) == 0 {
+		return prices, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(tickers)-1) + "?"
+	args := make([]any, len(tickers))
+	for i, t := range tickers {
+		args[i] = t
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT ticker, date, close
+		FROM prices p1
+		WHERE ticker IN (%s)
+		  AND date = (SELECT MAX(date) FROM prices p2 WHERE p2.ticker = p1.ticker)`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticker string
+		var p PricePoint
+		if err := rows.Scan(&ticker, &p.Date, &p.Close); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		prices[ticker] = p
+	}
+
+	return prices, rows.Err()
+}
+
+// priceHistory returns closes per ticker from the given date on, oldest first
+func priceHistory(db *sql.DB, tickers []string, from string) (map[string][]PricePoint, error) {
+	history := map[string][]PricePoint{}
+	if len(tickers) == 0 {
+		return history, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(tickers)-1) + "?"
+	args := make([]any, 0, len(tickers)+1)
+	for _, t := range tickers {
+		args = append(args, t)
+	}
+	args = append(args, from)
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT ticker, date, close
+		FROM prices
+		WHERE ticker IN (%s) AND date >= ?
+		ORDER BY date ASC`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ticker string
+		var p PricePoint
+		if err := rows.Scan(&ticker, &p.Date, &p.Close); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		history[ticker] = append(history[ticker], p)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,168 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/corporate"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestBuildPositionsFIFO(t *testing.T) {
+	txs := []Transaction{
+		{Ticker: "KO", Type: Buy, Date: "2023-01-10", Quantity: 10, Price: 50, Amount: 500, Fees: 10},
+		{Ticker: "KO", Type: Buy, Date: "2023-06-10", Quantity: 10, Price: 60, Amount: 600},
+		{Ticker: "KO", Type: Dividend, Date: "2023-09-01", Quantity: 20, Price: 0.46, Amount: 9.2},
+		{Ticker: "KO", Type: Sell, Date: "2024-01-10", Quantity: 15, Price: 70, Amount: 1050, Fees: 5},
+	}
+
+	positions, err := BuildPositions(txs)
+	if err != nil {
+		t.Fatalf("BuildPositions failed: %v", err)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("Expected 1 position, got %d", len(positions))
+	}
+
+	ko := positions[0]
+	if !approxEqual(ko.Quantity, 5) {
+		t.Errorf("Expected 5 shares left, got %v", ko.Quantity)
+	}
+	// The first lot (51/share incl. fees) is sold completely, then 5 shares of the second lot
+	if !approxEqual(ko.CostBasis, 300) {
+		t.Errorf("Expected remaining cost basis 300, got %v", ko.CostBasis)
+	}
+	if !approxEqual(ko.RealizedPnL, 1050-5-510-300) {
+		t.Errorf("Expected realised P&L 235, got %v", ko.RealizedPnL)
+	}
+	if !approxEqual(ko.DividendIncome, 9.2) {
+		t.Errorf("Expected dividend income 9.2, got %v", ko.DividendIncome)
+	}
+	if len(ko.Lots) != 1 || ko.Lots[0].Date != "2023-06-10" {
+		t.Errorf("Expected the June lot to remain open, got %+v", ko.Lots)
+	}
+
+	ApplyPrices(positions, map[string]PricePoint{"KO": {Date: "2024-02-01", Close: 62}})
+	if !approxEqual(positions[0].UnrealizedPnL, 10) {
+		t.Errorf("Expected unrealised P&L 10, got %v", positions[0].UnrealizedPnL)
+	}
+}
+
+func TestBuildPositionsOversell(t *testing.T) {
+	txs := []Transaction{
+		{Ticker: "KO", Type: Buy, Date: "2023-01-10", Quantity: 10, Price: 50, Amount: 500},
+		{Ticker: "KO", Type: Sell, Date: "2023-02-10", Quantity: 11, Price: 55, Amount: 605},
+	}
+
+	if _, err := BuildPositions(txs); !errors.Is(err, ErrInsufficientQuantity) {
+		t.Errorf("Expected ErrInsufficientQuantity, got %v", err)
+	}
+}
+
+func TestCalculatePerformance(t *testing.T) {
+	// Buy 10 at 100, price doubles, buy 10 more at 200, price falls back to 150.
+	// TWR: 2.0 * 0.75 - 1 = 0.5 regardless of the second deposit
+	txs := []Transaction{
+		{Ticker: "X", Type: Buy, Date: "2023-01-01", Quantity: 10, Price: 100, Amount: 1000},
+		{Ticker: "X", Type: Buy, Date: "2023-07-01", Quantity: 10, Price: 200, Amount: 2000},
+	}
+	history := map[string][]PricePoint{
+		"X": {
+			{Date: "2023-01-01", Close: 100},
+			{Date: "2023-07-01", Close: 200},
+			{Date: "2024-01-01", Close: 150},
+		},
+	}
+
+	perf := CalculatePerformance(txs, history)
+
+	if !approxEqual(perf.TimeWeightedReturn, 0.5) {
+		t.Errorf("Expected TWR 0.5, got %v", perf.TimeWeightedReturn)
+	}
+	if !approxEqual(perf.EndValue, 3000) {
+		t.Errorf("Expected end value 3000, got %v", perf.EndValue)
+	}
+	if !approxEqual(perf.NetContributions, 3000) {
+		t.Errorf("Expected net contributions 3000, got %v", perf.NetContributions)
+	}
+	// End value equals contributions, so money-weighted return is zero
+	if math.Abs(perf.MoneyWeightedReturn) > 1e-4 {
+		t.Errorf("Expected MWR ~0, got %v", perf.MoneyWeightedReturn)
+	}
+}
+
+func TestCalculateXIRR(t *testing.T) {
+	flows := []CashFlow{
+		{Date: "2023-01-01", Amount: -1000},
+		{Date: "2024-01-01", Amount: 1100},
+	}
+
+	irr, err := CalculateXIRR(flows)
+	if err != nil {
+		t.Fatalf("CalculateXIRR failed: %v", err)
+	}
+	if math.Abs(irr-0.1) > 1e-4 {
+		t.Errorf("Expected IRR 0.10, got %v", irr)
+	}
+
+	if _, err := CalculateXIRR([]CashFlow{{Date: "2023-01-01", Amount: -1000}}); err == nil {
+		t.Error("Expected error for a single cash flow")
+	}
+}
+
+func TestSplitInsideHoldingPeriod(t *testing.T) {
+	// 10 shares bought at 400 before a 4:1 split. Closes are split-adjusted,
+	// so the purchase day's 400 is stored as 100.
+	txs := []Transaction{
+		{Ticker: "AAPL", Type: Buy, Date: "2020-08-20", Quantity: 10, Price: 400, Amount: 4000},
+		{Ticker: "AAPL", Type: Sell, Date: "2020-09-15", Quantity: 8, Price: 120, Amount: 960},
+	}
+	history := map[string][]PricePoint{
+		"AAPL": {
+			{Date: "2020-08-20", Close: 100},
+			{Date: "2020-08-31", Close: 125},
+			{Date: "2020-09-15", Close: 120},
+			{Date: "2020-10-01", Close: 110},
+		},
+	}
+	adjusters := map[string]corporate.Adjuster{
+		"AAPL": corporate.NewAdjuster([]corporate.Split{{Date: "2020-08-31", Ratio: 4}}),
+	}
+
+	restated := RestateForSplits(txs, adjusters)
+	if restated[0].Quantity != 40 || restated[0].Price != 100 || restated[0].Amount != 4000 {
+		t.Errorf("Expected the buy restated to 40 shares at 100, got %+v", restated[0])
+	}
+	if restated[1].Quantity != 8 || txs[0].Quantity != 10 {
+		t.Errorf("Expected the post-split sell unchanged and the input untouched, got %+v and %+v", restated[1], txs[0])
+	}
+
+	positions, err := BuildPositions(restated)
+	if err != nil {
+		t.Fatalf("BuildPositions failed: %v", err)
+	}
+	if !approxEqual(positions[0].Quantity, 32) || !approxEqual(positions[0].AverageCost, 100) {
+		t.Errorf("Expected 32 shares at 100, got %v at %v", positions[0].Quantity, positions[0].AverageCost)
+	}
+	if !approxEqual(positions[0].RealizedPnL, 960-800) {
+		t.Errorf("Expected realised P&L 160, got %v", positions[0].RealizedPnL)
+	}
+
+	// No drop on the split date: 4000 grows to 5000
+	points := ValuationSeries(restated, history)
+	if !approxEqual(points[0].Value, 4000) || !approxEqual(points[1].Value, 5000) {
+		t.Errorf("Expected values 4000 and 5000 around the split, got %+v", points[:2])
+	}
+	perf := CalculatePerformance(restated, history)
+	// 1.25 to the split, 0.96 to the sale, then 110/120 on what is left
+	if !approxEqual(perf.TimeWeightedReturn, 1.25*0.96*110/120-1) {
+		t.Errorf("Expected TWR %v, got %v", 1.25*0.96*110/120-1, perf.TimeWeightedReturn)
+	}
+	if perf.MoneyWeightedReturn <= 0 {
+		t.Errorf("Expected a positive money-weighted return, got %v", perf.MoneyWeightedReturn)
+	}
+}
@@ -0,0 +1,212 @@
+package portfolio
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Lot is an open tax lot created by a buy and reduced FIFO by later sells
+type Lot struct {
+	Date         string  `json:"date"`
+	Quantity     float64 `json:"quantity"`
+	CostPerShare float64 `json:"cost_per_share"`
+}
+
+// Position aggregates all transactions of one ticker
+type Position struct {
+	Ticker         string  `json:"ticker"`
+	Quantity       float64 `json:"quantity"`
+	CostBasis      float64 `json:"cost_basis"`
+	AverageCost    float64 `json:"average_cost"`
+	LastPrice      float64 `json:"last_price"`
+	LastPriceDate  string  `json:"last_price_date"`
+	MarketValue    float64 `json:"market_value"`
+	UnrealizedPnL  float64 `json:"unrealized_pnl"`
+	RealizedPnL    float64 `json:"realized_pnl"`
+	DividendIncome float64 `json:"dividend_income"`
+	Lots           []Lot   `json:"lots"`
+}
+
+// PricePoint is a closing price on a date
+type PricePoint struct {
+	Date  string  `json:"date"`
+	Close float64 `json:"close"`
+}
+
+// Summary is a portfolio with its positions, totals and performance
+type Summary struct {
+	Portfolio      Portfolio   `json:"portfolio"`
+	Positions      []Position  `json:"positions"`
+	CostBasis      float64     `json:"cost_basis"`
+	MarketValue    float64     `json:"market_value"`
+	UnrealizedPnL  float64     `json:"unrealized_pnl"`
+	RealizedPnL    float64     `json:"realized_pnl"`
+	DividendIncome float64     `json:"dividend_income"`
+	Performance    Performance `json:"performance"`
+}
+
+// quantityEpsilon absorbs floating point noise when lots are fully consumed
+const quantityEpsilon = 1e-9
+
+// BuildPositions replays transactions in date order and returns positions
+// with FIFO lots, cost basis, realised P&L and dividend income. Market values
+// are left at zero; see ApplyPrices.
+func BuildPositions(txs []Transaction) ([]Position, error) {
+	ordered := make([]Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Date < ordered[j].Date
+	})
+
+	byTicker := map[string]*Position{}
+	tickers := []string{}
+
+	for _, t := range ordered {
+		pos, ok := byTicker[t.Ticker]
+		if !ok {
+			pos = &Position{Ticker: t.Ticker, Lots: []Lot{}}
+			byTicker[t.Ticker] = pos
+			tickers = append(tickers, t.Ticker)
+		}
+
+		switch t.Type {
+		case Buy:
+			pos.Lots = append(pos.Lots, Lot{
+				Date:         t.Date,
+				Quantity:     t.Quantity,
+				CostPerShare: (t.Amount + t.Fees) / t.Quantity,
+			})
+
+		case Sell:
+			remaining := t.Quantity
+			cost := 0.0
+			for remaining > quantityEpsilon {
+				if len(pos.Lots) == 0 {
+					return nil, fmt.Errorf("%w: selling %g %s on %s", ErrInsufficientQuantity, t.Quantity, t.Ticker, t.Date)
+				}
+				lot := &pos.Lots[0]
+				used := min(lot.Quantity, remaining)
+				cost += used * lot.CostPerShare
+				lot.Quantity -= used
+				remaining -= used
+				if lot.Quantity <= quantityEpsilon {
+					pos.Lots = pos.Lots[1:]
+				}
+			}
+			pos.RealizedPnL += t.Amount - t.Fees - cost
+
+		case Dividend:
+			pos.DividendIncome += t.Amount - t.Fees
+		}
+	}
+
+	positions := make([]Position, 0, len(tickers))
+	sort.Strings(tickers)
+	for _, ticker := range tickers {
+		pos := byTicker[ticker]
+		for _, lot := range pos.Lots {
+			pos.Quantity += lot.Quantity
+			pos.CostBasis += lot.Quantity * lot.CostPerShare
+		}
+		if pos.Quantity > quantityEpsilon {
+			pos.AverageCost = pos.CostBasis / pos.Quantity
+		}
+		positions = append(positions, *pos)
+	}
+
+	return positions, nil
+}
+
+// ApplyPrices values open positions at the given prices. Positions without a
+// known price are valued at cost.
+func ApplyPrices(positions []Position, prices map[string]PricePoint) {
+	for i := range positions {
+		pos := &positions[i]
+		if p, ok := prices[pos.Ticker]; ok {
+			pos.LastPrice = p.Close
+			pos.LastPriceDate = p.Date
+			pos.MarketValue = pos.Quantity * p.Close
+		} else {
+			pos.MarketValue = pos.CostBasis
+		}
+		pos.UnrealizedPnL = pos.MarketValue - pos.CostBasis
+	}
+}
+
+// QuantityHeldBefore returns the number of shares of ticker held at the end of the day before date
+func QuantityHeldBefore(txs []Transaction, ticker, date string) float64 {
+	qty := 0.0
+	for _, t := range txs {
+		if t.Ticker != ticker || t.Date >= date {
+			continue
+		}
+		switch t.Type {
+		case Buy:
+			qty += t.Quantity
+		case Sell:
+			qty -= t.Quantity
+		}
+	}
+	return qty
+}
+
+// GetSummary computes positions, P&L and returns for a portfolio from its
+// transactions, restated for splits, and the prices table
+func GetSummary(db *sql.DB, userID, id int64) (Summary, error) {
+	p, err := GetPortfolio(db, userID, id)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	txs, err := ListTransactions(db, userID, id)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	// Closes are split-adjusted, so transactions are restated to match
+	tickers := []string{}
+	for _, t := range txs {
+		if !slices.Contains(tickers, t.Ticker) {
+			tickers = append(tickers, t.Ticker)
+		}
+	}
+	adjusters, err := loadAdjusters(db, tickers)
+	if err != nil {
+		return Summary{}, err
+	}
+	txs = RestateForSplits(txs, adjusters)
+
+	positions, err := BuildPositions(txs)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	prices, err := latestPrices(db, tickers)
+	if err != nil {
+		return Summary{}, err
+	}
+	ApplyPrices(positions, prices)
+
+	summary := Summary{Portfolio: p, Positions: positions}
+	for _, pos := range positions {
+		summary.CostBasis += pos.CostBasis
+		summary.MarketValue += pos.MarketValue
+		summary.UnrealizedPnL += pos.UnrealizedPnL
+		summary.RealizedPnL += pos.RealizedPnL
+		summary.DividendIncome += pos.DividendIncome
+	}
+
+	if len(txs) == 0 {
+		return summary, nil
+	}
+
+	history, err := priceHistory(db, tickers, txs[0].Date)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary.Performance = CalculatePerformance(txs, history)
+	return summary, nil
+}
@@ -0,0 +1,41 @@
+package portfolio
+
+import (
+	"database/sql"
+
+	"github.com/finsights-ai/backend/packages/corporate"
+)
+
+// RestateForSplits returns the transactions with quantities and per-share
+// prices in today's share count, like the split-adjusted closes in prices,
+// so that holdings bought before a split are valued at what they became.
+// Cash amounts and fees are unchanged.
+func RestateForSplits(txs []Transaction, adjusters map[string]corporate.Adjuster) []Transaction {
+	restated := make([]Transaction, len(txs))
+	for i, t := range txs {
+		restated[i] = t
+		adj, ok := adjusters[t.Ticker]
+		if !ok {
+			continue
+		}
+		factor := adj.Factor(t.Date)
+		restated[i].Quantity = t.Quantity * factor
+		restated[i].Price = t.Price / factor
+	}
+	return restated
+}
+
+// loadAdjusters returns split adjusters for the tickers with stored splits
+func loadAdjusters(db *sql.DB, tickers []string) (map[string]corporate.Adjuster, error) {
+	adjusters := map[string]corporate.Adjuster{}
+	for _, ticker := range tickers {
+		splits, err := corporate.LoadSplits(db, ticker)
+		if err != nil {
+			return nil, err
+		}
+		if len(splits) > 0 {
+			adjusters[ticker] = corporate.NewAdjuster(splits)
+		}
+	}
+	return adjusters, nil
+}
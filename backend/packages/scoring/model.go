@@ -0,0 +1,164 @@
+// Package scoring ranks tickers with composite models: weighted combinations
+// of factors, each averaging one or more screener fields normalised to
+// percentile ranks. Models are defined in JSON, and their 0-100 scores are
+// stored after each update and exposed as screener fields.
+package scoring
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+// Direction tells whether higher or lower values of a metric score better
+type Direction string
+
+const (
+	Higher Direction = "higher"
+	Lower  Direction = "lower"
+)
+
+// Model is a named scoring model, e.g. 40% value, 30% quality, 30% momentum
+type Model struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Description string   `json:"description,omitempty"`
+	Factors     []Factor `json:"factors"`
+}
+
+// Factor averages its metrics. Weights are relative to the model's other
+// factors and need not add up to 1 or 100.
+type Factor struct {
+	Name    string   `json:"name"`
+	Weight  float64  `json:"weight"`
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric is a numeric screener field; Direction defaults to Higher
+type Metric struct {
+	Field     string    `json:"field"`
+	Direction Direction `json:"direction,omitempty"`
+}
+
+// Model names become part of field names and SQL, so they are restricted
+var modelName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+//go:embed models.json
+var defaultModels []byte
+
+// DefaultModels returns the built-in models
+func DefaultModels() []Model {
+	models, err := ParseModels(defaultModels)
+	if err != nil {
+		panic("scoring: invalid built-in models: " + err.Error())
+	}
+	return models
+}
+
+// LoadModels reads a JSON array of models from path
+func LoadModels(path string) ([]Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring models: %w", err)
+	}
+	return ParseModels(data)
+}
+
+// ParseModels decodes and validates a JSON array of models
+func ParseModels(data []byte) ([]Model, error) {
+	var models []Model
+	if err := json.Unmarshal(data, &models); err != nil {
+		return nil, fmt.Errorf("invalid scoring models JSON: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, m := range models {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("duplicate scoring model %q", m.Name)
+		}
+		seen[m.Name] = true
+	}
+	return models, nil
+}
+
+// Validate checks the model's name, weights and metrics
+func (m Model) Validate() error {
+	if !modelName.MatchString(m.Name) {
+		return fmt.Errorf("model name %q must be lower case letters, digits and underscores", m.Name)
+	}
+	if len(m.Factors) == 0 {
+		return fmt.Errorf("model %s: at least one factor is required", m.Name)
+	}
+
+	for _, factor := range m.Factors {
+		if factor.Name == "" {
+			return fmt.Errorf("model %s: factor name is required", m.Name)
+		}
+		if factor.Weight <= 0 {
+			return fmt.Errorf("model %s: factor %s must have a positive weight", m.Name, factor.Name)
+		}
+		if len(factor.Metrics) == 0 {
+			return fmt.Errorf("model %s: factor %s needs at least one metric", m.Name, factor.Name)
+		}
+		for _, metric := range factor.Metrics {
+			field, ok := screener.LookupField(metric.Field)
+			if !ok {
+				return fmt.Errorf("model %s: unknown field %q", m.Name, metric.Field)
+			}
+			if field.Type != screener.TypeNumber {
+				return fmt.Errorf("model %s: field %s is not numeric", m.Name, metric.Field)
+			}
+			if metric.Direction != "" && metric.Direction != Higher && metric.Direction != Lower {
+				return fmt.Errorf("model %s: direction of %s must be higher or lower", m.Name, metric.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// FieldName is the screener field holding the model's score
+func (m Model) FieldName() string {
+	return m.Name + "_score"
+}
+
+// fieldNames lists the distinct fields the model reads
+func (m Model) fieldNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, factor := range m.Factors {
+		for _, metric := range factor.Metrics {
+			if !seen[metric.Field] {
+				seen[metric.Field] = true
+				names = append(names, metric.Field)
+			}
+		}
+	}
+	return names
+}
+
+// RegisterFields makes each model's stored score a screener field, so it can
+// be filtered, sorted and selected like any other. Call it during startup,
+// before routes are registered.
+func RegisterFields(models []Model) error {
+	var errs []error
+	for _, m := range models {
+		errs = append(errs, screener.RegisterField(screener.Field{
+			Name:        m.FieldName(),
+			Label:       m.Label + " score",
+			Description: fmt.Sprintf("%s scoring model, from 0 to 100", m.Label),
+			Table:       "scores",
+			Expr:        fmt.Sprintf("(SELECT s.score FROM scores s WHERE s.ticker = f.ticker AND s.model = '%s')", m.Name),
+			Type:        screener.TypeNumber,
+			Operators:   screener.NumberOperators(),
+		}))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package scoring
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+// Score is a ticker's result under one model. Factors holds each factor's
+// 0-100 score; factors without data for the ticker are left out.
+type Score struct {
+	Ticker  string             `json:"ticker"`
+	Model   string             `json:"model"`
+	Score   float64            `json:"score"`
+	Factors map[string]float64 `json:"factors"`
+}
+
+// Compute scores every row holding a ticker and the model's fields. Each
+// metric is normalised to its percentile rank among the rows that have it,
+// inverted for Lower metrics; a factor is the mean of its available metrics,
+// and the score is the weighted mean of the available factors scaled to
+// 0-100. Rows with none of the metrics get no score.
+func Compute(model Model, rows []screener.Row) []Score {
+	ranks := map[string][]*float64{}
+	for _, name := range model.fieldNames() {
+		ranks[name] = percentileRanks(rows, name)
+	}
+
+	var scores []Score
+	for i, row := range rows {
+		ticker, _ := row["ticker"].(string)
+		total, totalWeight := 0.0, 0.0
+		factors := map[string]float64{}
+
+		for _, factor := range model.Factors {
+			sum, n := 0.0, 0
+			for _, metric := range factor.Metrics {
+				rank := ranks[metric.Field][i]
+				if rank == nil {
+					continue
+				}
+				if metric.Direction == Lower {
+					sum += 1 - *rank
+				} else {
+					sum += *rank
+				}
+				n++
+			}
+			if n == 0 {
+				continue
+			}
+
+			value := sum / float64(n)
+			factors[factor.Name] = 100 * value
+			total += factor.Weight * value
+			totalWeight += factor.Weight
+		}
+
+		if totalWeight == 0 {
+			continue
+		}
+		scores = append(scores, Score{
+			Ticker:  ticker,
+			Model:   model.Name,
+			Score:   100 * total / totalWeight,
+			Factors: factors,
+		})
+	}
+	return scores
+}
+
+// percentileRanks returns each row's rank for field between 0 and 1, with
+// tied values sharing their average rank, or nil where the row has no value.
+// A value with no others to compare against ranks in the middle.
+func percentileRanks(rows []screener.Row, field string) []*float64 {
+	var values []float64
+	for _, row := range rows {
+		if v, ok := row[field].(float64); ok {
+			values = append(values, v)
+		}
+	}
+	sort.Float64s(values)
+
+	ranks := make([]*float64, len(rows))
+	for i, row := range rows {
+		v, ok := row[field].(float64)
+		if !ok {
+			continue
+		}
+		rank := 0.5
+		if len(values) > 1 {
+			lower := sort.SearchFloat64s(values, v)
+			upper := sort.Search(len(values), func(j int) bool { return values[j] > v })
+			rank = (float64(lower) + float64(upper-lower-1)/2) / float64(len(values)-1)
+		}
+		ranks[i] = &rank
+	}
+	return ranks
+}
+
+// Update recomputes and stores every model's scores from the current
+// screener data, replacing the model's previous scores
+func Update(db *sql.DB, models []Model) error {
+	for _, model := range models {
+		rows, err := screener.ScreenRows(db, screener.ScreenerFilter{
+			Fields: append([]string{"ticker"}, model.fieldNames()...),
+		})
+		if err != nil {
+			return fmt.Errorf("reading data for model %s: %w", model.Name, err)
+		}
+
+		if err := saveScores(db, model.Name, Compute(model, rows)); err != nil {
+			return fmt.Errorf("saving scores for model %s: %w", model.Name, err)
+		}
+	}
+	return nil
+}
+
+// UpdateHook returns a hook for screener.RunNightlyUpdate that rescores all
+// tickers once fresh data has been ingested
+func UpdateHook(models []Model) screener.UpdateHook {
+	return func(db *sql.DB) error {
+		return Update(db, models)
+	}
+}
+
+func saveScores(db *sql.DB, model string, scores []Score) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM scores WHERE model = ?`, model); err != nil {
+		return err
+	}
+
+	insert, err := tx.Prepare(`
+		INSERT INTO scores (ticker, model, score, factors, updated_at)
+		VALUES (?, ?, ?, ?, datetime('now'))`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, s := range scores {
+		factors, err := json.Marshal(s.Factors)
+		if err != nil {
+			return err
+		}
+		if _, err := insert.Exec(s.Ticker, model, s.Score, string(factors)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package scoring
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/screener"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	schema := `
+		CREATE TABLE fundamentals (
+			ticker TEXT PRIMARY KEY,
+			pe_ratio REAL,
+			roe REAL,
+			earnings_outlook TEXT,
+			dividend_yield REAL,
+			dividend_growth_5y REAL,
+			intrinsic_value REAL,
+			margin_of_safety REAL
+		);
+		CREATE TABLE prices (ticker TEXT, date TEXT, close REAL, sma50 REAL, sma200 REAL);
+		CREATE TABLE scores (
+			ticker TEXT NOT NULL,
+			model TEXT NOT NULL,
+			score REAL NOT NULL,
+			factors JSON NOT NULL DEFAULT '{}',
+			updated_at TEXT,
+			PRIMARY KEY (ticker, model)
+		);
+
+		INSERT INTO fundamentals (ticker, pe_ratio, roe, dividend_yield) VALUES
+		('AAA', 10, 0.30, 0.04),
+		('BBB', 20, 0.20, 0.02),
+		('CCC', 30, 0.10, NULL),
+		('DDD', NULL, NULL, NULL);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	return db
+}
+
+var testModel = Model{
+	Name:  "test",
+	Label: "Test",
+	Factors: []Factor{
+		{Name: "value", Weight: 3, Metrics: []Metric{{Field: "pe_ratio", Direction: Lower}}},
+		{Name: "income", Weight: 1, Metrics: []Metric{{Field: "dividend_yield"}}},
+	},
+}
+
+func TestParseModels(t *testing.T) {
+	if len(DefaultModels()) == 0 {
+		t.Fatal("Expected built-in models")
+	}
+
+	invalid := []string{
+		`not json`,
+		`[{"name":"Bad Name","factors":[{"name":"a","weight":1,"metrics":[{"field":"roe"}]}]}]`,
+		`[{"name":"empty","factors":[]}]`,
+		`[{"name":"weightless","factors":[{"name":"a","weight":0,"metrics":[{"field":"roe"}]}]}]`,
+		`[{"name":"unknown","factors":[{"name":"a","weight":1,"metrics":[{"field":"volume"}]}]}]`,
+		`[{"name":"text","factors":[{"name":"a","weight":1,"metrics":[{"field":"sector"}]}]}]`,
+		`[{"name":"sideways","factors":[{"name":"a","weight":1,"metrics":[{"field":"roe","direction":"up"}]}]}]`,
+		`[{"name":"twice","factors":[{"name":"a","weight":1,"metrics":[{"field":"roe"}]}]},
+		  {"name":"twice","factors":[{"name":"a","weight":1,"metrics":[{"field":"roe"}]}]}]`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseModels([]byte(data)); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	rows := []screener.Row{
+		{"ticker": "AAA", "pe_ratio": 10.0, "dividend_yield": 0.04},
+		{"ticker": "BBB", "pe_ratio": 20.0, "dividend_yield": 0.02},
+		{"ticker": "CCC", "pe_ratio": 20.0, "dividend_yield": nil},
+		{"ticker": "DDD", "pe_ratio": nil, "dividend_yield": nil},
+	}
+
+	scores := Compute(testModel, rows)
+	if len(scores) != 3 {
+		t.Fatalf("Expected tickers without data to be skipped, got %d scores", len(scores))
+	}
+
+	// AAA has the lowest PE and highest yield
+	if scores[0].Ticker != "AAA" || scores[0].Score != 100 {
+		t.Errorf("Expected AAA to score 100, got %+v", scores[0])
+	}
+	// BBB and CCC tie on PE at rank 0.75, inverted to 0.25; BBB has the lowest yield
+	if math.Abs(scores[1].Score-(3*25.0+0)/4) > 1e-9 {
+		t.Errorf("Expected BBB to score 18.75, got %v", scores[1].Score)
+	}
+	// CCC has no yield, so only the value factor counts
+	if math.Abs(scores[2].Score-25) > 1e-9 || len(scores[2].Factors) != 1 {
+		t.Errorf("Expected CCC to score 25 on value alone, got %+v", scores[2])
+	}
+}
+
+func TestUpdateStoresScreenableScores(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := RegisterFields([]Model{testModel}); err != nil {
+		t.Fatalf("RegisterFields failed: %v", err)
+	}
+	// Rescoring replaces the previous scores
+	for range 2 {
+		if err := Update(db, []Model{testModel}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+
+	filter, err := screener.ParseFilterFromJSON(`[["test_score",">=",30]]`)
+	if err != nil {
+		t.Fatalf("ParseFilterFromJSON failed: %v", err)
+	}
+	filter.Sort = "test_score.desc"
+	filter.Fields = []string{"ticker", "test_score"}
+
+	rows, err := screener.ScreenRows(db, filter)
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	// AAA scores 100, BBB 37.5 and CCC, the most expensive without a yield, 0
+	if len(rows) != 2 || rows[0]["ticker"] != "AAA" || rows[0]["test_score"] != 100.0 {
+		t.Errorf("Expected AAA then BBB, got %v", rows)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM scores WHERE model = 'test'`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count scores: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 stored scores, got %d", count)
+	}
+}
@@ -0,0 +1,49 @@
+package screener
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/finsights-ai/backend/packages/fx"
+)
+
+// SaveCurrency stores the currency the ticker is quoted in, such as EODHD's
+// General::CurrencyCode. A missing or unrecognised code is stored as NULL,
+// which leaves the ticker's amounts out of converted screens.
+func SaveCurrency(db *sql.DB, ticker, currency string) error {
+	var code sql.NullString
+	if currency != "" {
+		c, err := fx.Normalize(currency)
+		if err != nil {
+			log.Printf("Ignoring currency of %s: %v", ticker, err)
+		} else {
+			code = sql.NullString{String: c, Valid: true}
+		}
+	}
+	_, err := db.Exec(`
+		INSERT INTO fundamentals (ticker, currency) VALUES (?, ?)
+		ON CONFLICT(ticker) DO UPDATE SET currency = excluded.currency`,
+		ticker, code,
+	)
+	if err != nil {
+		return fmt.Errorf("saving currency: %w", err)
+	}
+	return nil
+}
+
+// statementPrice converts a price from the currency it is quoted in into the
+// currency the statements are reported in, on date. Statements without a
+// currency are taken to be in the quote's major currency, since none are
+// reported in pence, and a quote without one in the statements' currency.
+func statementPrice(db *sql.DB, price float64, quote, statement, date string) (float64, error) {
+	from, _ := fx.Normalize(quote)
+	to, _ := fx.Normalize(statement)
+	switch {
+	case from == "":
+		return price, nil
+	case to == "":
+		to, _ = fx.Major(from)
+	}
+	return fx.Convert(db, price, from, to, date)
+}
@@ -0,0 +1,140 @@
+package screener
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScreenInCurrency(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for ticker, currency := range map[string]string{"AAPL": "USD", "SAP.XETRA": "eur", "ULVR.LSE": "GBp"} {
+		if err := SaveCurrency(db, ticker, currency); err != nil {
+			t.Fatalf("SaveCurrency failed: %v", err)
+		}
+	}
+	_, err := db.Exec(`
+		UPDATE fundamentals SET intrinsic_value = 200.0 WHERE ticker = 'SAP.XETRA';
+		INSERT INTO prices (ticker, date, close, sma50, sma200) VALUES
+		('SAP.XETRA', '2024-01-15', 160.0, 150.0, 140.0),
+		('ULVR.LSE', '2024-01-15', 3800.0, 3700.0, 3900.0);
+		INSERT INTO fx_rates (currency, date, rate) VALUES
+		('EUR', '2024-01-12', 1.05),
+		('EUR', '2024-01-15', 1.10),
+		('GBP', '2024-01-15', 1.25)`)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "close", Operator: "<", Value: 150.0}},
+		Sort:       "close.desc",
+		Fields:     []string{"ticker", "currency", "close", "intrinsic_vs_price"},
+		Currency:   "eur",
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+
+	// SAP's 160 EUR is above the limit, and tickers without a currency
+	// can't be converted
+	if len(rows) != 2 || rows[0]["ticker"] != "AAPL" || rows[1]["ticker"] != "ULVR.LSE" {
+		t.Fatalf("Expected AAPL and ULVR.LSE, got %v", rows)
+	}
+	if close := rows[0]["close"].(float64); math.Abs(close-150.25/1.10) > 1e-9 {
+		t.Errorf("Expected AAPL's close at the latest EUR rate, got %v", close)
+	}
+	// 3800 pence is 38 GBP
+	if close := rows[1]["close"].(float64); math.Abs(close-38*1.25/1.10) > 1e-9 {
+		t.Errorf("Expected ULVR's pence converted to EUR, got %v", close)
+	}
+	if rows[1]["currency"] != "GBX" {
+		t.Errorf("Expected the listing currency GBX, got %v", rows[1]["currency"])
+	}
+
+	// Ratios of two amounts in the same currency are unchanged
+	rows, err = ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: "SAP.XETRA"}},
+		Fields:     []string{"close", "intrinsic_value", "intrinsic_vs_price"},
+		Currency:   "EUR",
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["close"] != 160.0 || rows[0]["intrinsic_value"] != 200.0 || rows[0]["intrinsic_vs_price"] != 1.25 {
+		t.Errorf("Expected SAP's EUR amounts unchanged, got %v", rows)
+	}
+
+	if _, err := ScreenRows(db, ScreenerFilter{Fields: []string{"close"}, Currency: "euro"}); err == nil {
+		t.Error("Expected an error for an invalid currency")
+	}
+}
+
+func TestScreenValuationsInCurrency(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	saved := valuationModels
+	defer func() { valuationModels = saved }()
+	valuationModels = []ValuationModel{EVEBITDAModel{Multiple: 10}}
+
+	if err := SaveCurrency(db, "ULVR.LSE", "GBp"); err != nil {
+		t.Fatalf("SaveCurrency failed: %v", err)
+	}
+	_, err := db.Exec(`INSERT INTO fx_rates (currency, date, rate) VALUES ('EUR', '2024-01-15', 1.10), ('GBP', '2024-01-15', 1.25)`)
+	if err != nil {
+		t.Fatalf("Failed to insert rates: %v", err)
+	}
+
+	// Statements in pounds value the shares at 40 GBP, quoted at 3800 pence
+	_, err = RunValuations(db, "ULVR.LSE", "2024-01-15", ValuationInputs{Price: 3800, MarketPrice: 38,
+		Years: []FinancialYear{{EBIT: 90, Depreciation: -10, ShortTermDebt: 50, LongTermDebt: 250, Cash: 100, SharesOutstanding: 20}}})
+	if err != nil {
+		t.Fatalf("RunValuations failed: %v", err)
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: "ULVR.LSE"}},
+		Fields:     []string{"ev_ebitda_intrinsic_value"},
+		Currency:   "EUR",
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || math.Abs(rows[0]["ev_ebitda_intrinsic_value"].(float64)-40*1.25/1.10) > 1e-9 {
+		t.Errorf("Expected 40 GBP in EUR, got %v", rows)
+	}
+}
+
+func TestStatementPrice(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO fx_rates (currency, date, rate) VALUES ('EUR', '2024-01-15', 1.10)`)
+	if err != nil {
+		t.Fatalf("Failed to insert rates: %v", err)
+	}
+
+	tests := []struct {
+		quote, statement string
+		want             float64
+	}{
+		{"USD", "USD", 150},
+		{"USD", "EUR", 150 / 1.10},
+		{"GBp", "GBP", 1.5},
+		{"GBX", "", 1.5},
+		{"", "EUR", 150},
+	}
+	for _, tt := range tests {
+		got, err := statementPrice(db, 150, tt.quote, tt.statement, "2024-01-15")
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("statementPrice(150 %s in %s) = %v, %v; want %v", tt.quote, tt.statement, got, err, tt.want)
+		}
+	}
+
+	// Without a rate the price can't be compared with the statements
+	if got, err := statementPrice(db, 150, "USD", "JPY", "2024-01-15"); err == nil || got != 0 {
+		t.Errorf("Expected no JPY price, got %v, %v", got, err)
+	}
+}
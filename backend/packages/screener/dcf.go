@@ -0,0 +1,277 @@
+package screener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// DCFStage is a run of years growing free cash flow at one rate. A nil Growth
+// uses the company's estimated growth rate. A fading stage instead moves
+// linearly from the previous stage's rate to the terminal growth rate.
+type DCFStage struct {
+	Years  int      `json:"years"`
+	Growth *float64 `json:"growth,omitempty"`
+	Fade   bool     `json:"fade,omitempty"`
+}
+
+// DCFParams configures the discounted cash flow model. Rates are fractions.
+type DCFParams struct {
+	// DiscountRate replaces the WACC estimated from beta and debt when set
+	DiscountRate      float64 `json:"discount_rate,omitempty"`
+	RiskFreeRate      float64 `json:"risk_free_rate"`
+	EquityRiskPremium float64 `json:"equity_risk_premium"`
+	// DebtSpread is added to the risk-free rate when the cost of debt can't
+	// be derived from interest expense
+	DebtSpread float64 `json:"debt_spread"`
+	// TaxRate is used when the effective tax rate can't be derived
+	TaxRate float64 `json:"tax_rate"`
+	// MaxGrowth caps the estimated growth rate
+	MaxGrowth      float64    `json:"max_growth"`
+	TerminalGrowth float64    `json:"terminal_growth"`
+	Stages         []DCFStage `json:"stages"`
+}
+
+// DefaultDCFParams grows cash flow at the estimated rate for five years, then
+// fades to 2.5% terminal growth over the next five
+func DefaultDCFParams() DCFParams {
+	return DCFParams{
+		RiskFreeRate:      0.044,
+		EquityRiskPremium: 0.055,
+		DebtSpread:        0.015,
+		TaxRate:           0.21,
+		MaxGrowth:         0.15,
+		TerminalGrowth:    0.025,
+		Stages:            []DCFStage{{Years: 5}, {Years: 5, Fade: true}},
+	}
+}
+
+// Validate checks the parameters can produce a valuation
+func (p DCFParams) Validate() error {
+	if len(p.Stages) == 0 {
+		return errors.New("at least one growth stage is required")
+	}
+	for i, s := range p.Stages {
+		if s.Years <= 0 {
+			return fmt.Errorf("stage %d: years must be positive", i)
+		}
+	}
+	if p.DiscountRate < 0 || p.RiskFreeRate < 0 || p.EquityRiskPremium < 0 {
+		return errors.New("rates must not be negative")
+	}
+	if p.DiscountRate != 0 && p.DiscountRate <= p.TerminalGrowth {
+		return errors.New("discount rate must exceed terminal growth")
+	}
+	return nil
+}
+
+// LoadDCFParams reads a JSON object from path. Settings it omits keep their
+// default values.
+func LoadDCFParams(path string) (DCFParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DCFParams{}, fmt.Errorf("failed to read DCF parameters: %w", err)
+	}
+	p := DefaultDCFParams()
+	if err := json.Unmarshal(data, &p); err != nil {
+		return DCFParams{}, fmt.Errorf("invalid DCF parameters JSON: %w", err)
+	}
+	return p, p.Validate()
+}
+
+// DCFInputs are the company figures the model values
+type DCFInputs struct {
+	FreeCashFlow      float64 `json:"free_cash_flow"`
+	SharesOutstanding float64 `json:"shares_outstanding"`
+	TotalDebt         float64 `json:"total_debt"`
+	Cash              float64 `json:"cash"`
+	InterestExpense   float64 `json:"interest_expense"`
+	TaxRate           float64 `json:"tax_rate"`
+	Beta              float64 `json:"beta"`
+	// Price is in the statements' currency, to weigh market equity
+	// against their debt
+	Price      float64 `json:"price"`
+	GrowthRate float64 `json:"growth_rate"`
+}
+
+// DCFInputsFromYear takes the inputs from a fiscal year's statements
+func DCFInputsFromYear(y FinancialYear, price, beta, growth float64) DCFInputs {
+	return DCFInputs{
+		FreeCashFlow:      y.FCF(),
+		SharesOutstanding: y.SharesOutstanding,
+		TotalDebt:         y.TotalDebt(),
+		Cash:              y.Cash,
+		InterestExpense:   math.Abs(y.InterestExpense),
+		TaxRate:           y.TaxRate(),
+		Beta:              beta,
+		Price:             price,
+		GrowthRate:        growth,
+	}
+}
+
+// EstimateWACC weights the CAPM cost of equity and the after-tax cost of
+// debt by market equity and book debt. A missing beta counts as 1.
+func EstimateWACC(in DCFInputs, p DCFParams) float64 {
+	beta := in.Beta
+	if beta <= 0 {
+		beta = 1
+	}
+	costOfEquity := p.RiskFreeRate + beta*p.EquityRiskPremium
+
+	equity := in.Price * in.SharesOutstanding
+	if in.TotalDebt <= 0 || equity <= 0 {
+		return costOfEquity
+	}
+
+	costOfDebt := p.RiskFreeRate + p.DebtSpread
+	if in.InterestExpense > 0 {
+		costOfDebt = in.InterestExpense / in.TotalDebt
+	}
+	taxRate := in.TaxRate
+	if taxRate <= 0 || taxRate >= 1 {
+		taxRate = p.TaxRate
+	}
+
+	total := equity + in.TotalDebt
+	return equity/total*costOfEquity + in.TotalDebt/total*costOfDebt*(1-taxRate)
+}
+
+// DCFSensitivity is the value per share over a grid of discount rates (rows)
+// and terminal growth rates (columns). Cells where the discount rate doesn't
+// exceed growth are null.
+type DCFSensitivity struct {
+	DiscountRates  []float64    `json:"discount_rates"`
+	TerminalGrowth []float64    `json:"terminal_growth"`
+	ValuesPerShare [][]*float64 `json:"values_per_share"`
+}
+
+// DCFResult is a DCF valuation with the figures behind it
+type DCFResult struct {
+	ValuePerShare         float64        `json:"value_per_share"`
+	EnterpriseValue       float64        `json:"enterprise_value"`
+	EquityValue           float64        `json:"equity_value"`
+	PresentValueCashFlows float64        `json:"present_value_cash_flows"`
+	PresentValueTerminal  float64        `json:"present_value_terminal"`
+	DiscountRate          float64        `json:"discount_rate"`
+	GrowthRate            float64        `json:"growth_rate"`
+	TerminalGrowth        float64        `json:"terminal_growth"`
+	ProjectedCashFlows    []float64      `json:"projected_cash_flows"`
+	Inputs                DCFInputs      `json:"inputs"`
+	Sensitivity           DCFSensitivity `json:"sensitivity"`
+}
+
+// Offsets from the base case shown in the sensitivity table
+var (
+	sensitivityRateSteps   = []float64{-0.01, -0.005, 0, 0.005, 0.01}
+	sensitivityGrowthSteps = []float64{-0.005, 0, 0.005}
+)
+
+// CalculateDCF values the company's equity per share by projecting free cash
+// flow through the growth stages, adding a Gordon growth terminal value and
+// subtracting net debt
+func CalculateDCF(in DCFInputs, p DCFParams) (DCFResult, error) {
+	if err := p.Validate(); err != nil {
+		return DCFResult{}, err
+	}
+	if in.FreeCashFlow <= 0 {
+		return DCFResult{}, errors.New("free cash flow must be positive")
+	}
+	if in.SharesOutstanding <= 0 {
+		return DCFResult{}, errors.New("shares outstanding are required")
+	}
+
+	rate := p.DiscountRate
+	if rate == 0 {
+		rate = EstimateWACC(in, p)
+	}
+	growth := math.Max(0, math.Min(in.GrowthRate, p.MaxGrowth))
+
+	projected := projectCashFlows(in.FreeCashFlow, growth, p)
+	ev, pvCashFlows, pvTerminal, err := discountCashFlows(projected, rate, p.TerminalGrowth)
+	if err != nil {
+		return DCFResult{}, err
+	}
+	equity := ev - (in.TotalDebt - in.Cash)
+	if equity <= 0 {
+		return DCFResult{}, errors.New("net debt exceeds enterprise value")
+	}
+
+	return DCFResult{
+		ValuePerShare:         equity / in.SharesOutstanding,
+		EnterpriseValue:       ev,
+		EquityValue:           equity,
+		PresentValueCashFlows: pvCashFlows,
+		PresentValueTerminal:  pvTerminal,
+		DiscountRate:          rate,
+		GrowthRate:            growth,
+		TerminalGrowth:        p.TerminalGrowth,
+		ProjectedCashFlows:    projected,
+		Inputs:                in,
+		Sensitivity:           dcfSensitivity(in, growth, rate, p),
+	}, nil
+}
+
+// projectCashFlows grows fcf through each stage, one value per year
+func projectCashFlows(fcf, growth float64, p DCFParams) []float64 {
+	var projected []float64
+	prevRate := growth
+	for _, stage := range p.Stages {
+		startRate := prevRate
+		for year := 1; year <= stage.Years; year++ {
+			rate := growth
+			switch {
+			case stage.Fade:
+				rate = startRate + (p.TerminalGrowth-startRate)*float64(year)/float64(stage.Years)
+			case stage.Growth != nil:
+				rate = *stage.Growth
+			}
+			fcf *= 1 + rate
+			projected = append(projected, fcf)
+			prevRate = rate
+		}
+	}
+	return projected
+}
+
+// discountCashFlows returns the enterprise value of the projected cash flows
+// and a terminal value growing from the last of them
+func discountCashFlows(projected []float64, rate, terminalGrowth float64) (ev, pvCashFlows, pvTerminal float64, err error) {
+	if rate <= terminalGrowth {
+		return 0, 0, 0, errors.New("discount rate must exceed terminal growth")
+	}
+	for i, fcf := range projected {
+		pvCashFlows += fcf / math.Pow(1+rate, float64(i+1))
+	}
+	last := projected[len(projected)-1]
+	terminal := last * (1 + terminalGrowth) / (rate - terminalGrowth)
+	pvTerminal = terminal / math.Pow(1+rate, float64(len(projected)))
+	return pvCashFlows + pvTerminal, pvCashFlows, pvTerminal, nil
+}
+
+func dcfSensitivity(in DCFInputs, growth, rate float64, p DCFParams) DCFSensitivity {
+	s := DCFSensitivity{}
+	for _, step := range sensitivityRateSteps {
+		s.DiscountRates = append(s.DiscountRates, rate+step)
+	}
+	for _, step := range sensitivityGrowthSteps {
+		s.TerminalGrowth = append(s.TerminalGrowth, p.TerminalGrowth+step)
+	}
+
+	for _, r := range s.DiscountRates {
+		row := make([]*float64, len(s.TerminalGrowth))
+		for j, g := range s.TerminalGrowth {
+			variant := p
+			variant.TerminalGrowth = g
+			ev, _, _, err := discountCashFlows(projectCashFlows(in.FreeCashFlow, growth, variant), r, g)
+			if err != nil {
+				continue
+			}
+			value := (ev - (in.TotalDebt - in.Cash)) / in.SharesOutstanding
+			row[j] = &value
+		}
+		s.ValuesPerShare = append(s.ValuesPerShare, row)
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package screener
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateDCF(t *testing.T) {
+	zero := 0.0
+	params := DCFParams{DiscountRate: 0.10, Stages: []DCFStage{{Years: 1, Growth: &zero}}}
+	in := DCFInputs{FreeCashFlow: 100, SharesOutstanding: 10, TotalDebt: 250, Cash: 50, Price: 60}
+
+	result, err := CalculateDCF(in, params)
+	if err != nil {
+		t.Fatalf("CalculateDCF failed: %v", err)
+	}
+	// 100/1.1 now plus a terminal value of 1000 discounted one year, less
+	// net debt of 200
+	if math.Abs(result.EnterpriseValue-1000) > 1e-6 || math.Abs(result.ValuePerShare-80) > 1e-6 {
+		t.Errorf("Expected EV 1000 and 80 per share, got %v and %v", result.EnterpriseValue, result.ValuePerShare)
+	}
+
+	in.TotalDebt = 2000
+	if _, err := CalculateDCF(in, params); err == nil {
+		t.Error("Expected an error when net debt exceeds enterprise value")
+	}
+	in.FreeCashFlow = -5
+	if _, err := CalculateDCF(in, params); err == nil {
+		t.Error("Expected an error for negative free cash flow")
+	}
+}
+
+func TestProjectCashFlowsStages(t *testing.T) {
+	params := DCFParams{TerminalGrowth: 0.02, Stages: []DCFStage{{Years: 2}, {Years: 2, Fade: true}}}
+	projected := projectCashFlows(100, 0.10, params)
+
+	want := []float64{110, 121, 121 * 1.06, 121 * 1.06 * 1.02}
+	if len(projected) != len(want) {
+		t.Fatalf("Expected %d years, got %v", len(want), projected)
+	}
+	for i := range want {
+		if math.Abs(projected[i]-want[i]) > 1e-9 {
+			t.Errorf("year %d: expected %v, got %v", i+1, want[i], projected[i])
+		}
+	}
+}
+
+func TestEstimateWACC(t *testing.T) {
+	in := DCFInputs{Price: 10, SharesOutstanding: 100, TotalDebt: 1000, InterestExpense: 50, TaxRate: 0.2, Beta: 1.2}
+	p := DCFParams{RiskFreeRate: 0.04, EquityRiskPremium: 0.05}
+
+	// Half equity at 4% + 1.2*5%, half debt at 5% after 20% tax
+	if wacc := EstimateWACC(in, p); math.Abs(wacc-0.07) > 1e-9 {
+		t.Errorf("Expected WACC 0.07, got %v", wacc)
+	}
+
+	in.TotalDebt, in.Beta = 0, 0
+	if wacc := EstimateWACC(in, p); math.Abs(wacc-0.09) > 1e-9 {
+		t.Errorf("Expected the cost of equity with beta 1 for an unlevered company, got %v", wacc)
+	}
+}
+
+func TestDCFModelWeighsEquityInStatementCurrency(t *testing.T) {
+	year := FinancialYear{OperatingCashFlow: 120, CapitalExpenditures: -20, SharesOutstanding: 100,
+		ShortTermDebt: 200, LongTermDebt: 800, InterestExpense: -50, IncomeBeforeTax: 100, IncomeTaxExpense: 20}
+	m := DCFModel{Params: DefaultDCFParams()}
+
+	// Quoted at 1000 pence with statements in pounds, the equity is 1000 GBP
+	pounds, _, err := m.Value(ValuationInputs{Price: 10, MarketPrice: 10, Beta: 1, GrowthRate: 0.05, Years: []FinancialYear{year}})
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	pence, inputs, err := m.Value(ValuationInputs{Price: 1000, MarketPrice: 10, Beta: 1, GrowthRate: 0.05, Years: []FinancialYear{year}})
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if math.Abs(pence-pounds) > 1e-9 {
+		t.Errorf("Expected the same value for either quote, got %v and %v", pence, pounds)
+	}
+	if result := inputs.(DCFResult); result.Inputs.Price != 10 {
+		t.Errorf("Expected the WACC from the statement-currency price, got %+v", result.Inputs)
+	}
+}
+
+func TestDCFSensitivity(t *testing.T) {
+	params := DefaultDCFParams()
+	params.DiscountRate = 0.03
+	in := DCFInputs{FreeCashFlow: 100, SharesOutstanding: 10, GrowthRate: 0.05}
+
+	result, err := CalculateDCF(in, params)
+	if err != nil {
+		t.Fatalf("CalculateDCF failed: %v", err)
+	}
+	s := result.Sensitivity
+	if len(s.ValuesPerShare) != len(s.DiscountRates) || len(s.ValuesPerShare[0]) != len(s.TerminalGrowth) {
+		t.Fatalf("Unexpected table shape: %+v", s)
+	}
+	if base := s.ValuesPerShare[2][1]; base == nil || math.Abs(*base-result.ValuePerShare) > 1e-6 {
+		t.Errorf("Expected the centre cell to be the base case %v, got %v", result.ValuePerShare, base)
+	}
+	// A 2% discount rate can't value 2% or more terminal growth
+	for j, v := range s.ValuesPerShare[0] {
+		if v != nil {
+			t.Errorf("Expected no value at rate %v and growth %v, got %v", s.DiscountRates[0], s.TerminalGrowth[j], *v)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package screener
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/fx"
+)
+
+// FieldType is the type of a field's values
+type FieldType string
+
+const (
+	TypeString FieldType = "string"
+	TypeNumber FieldType = "number"
+)
+
+// Unit describes how a numeric field's values should be displayed
+type Unit string
+
+const (
+	UnitNone Unit = ""
+	// UnitPercent values are fractions, so 0.25 is 25%
+	UnitPercent Unit = "percent"
+	// UnitRatio values are multiples, such as a PE of 14.5x
+	UnitRatio Unit = "ratio"
+	// UnitCurrency values are amounts in the currency the security is quoted
+	// in, converted when a screen asks for another. Values derived from the
+	// statements must be converted into it before they are stored.
+	UnitCurrency Unit = "currency"
+)
+
+// Operators allowed in conditions on each type of field
+var (
+	numberOperators = []string{"=", "!=", ">", "<", ">=", "<=", "BETWEEN", "IS NULL", "IS NOT NULL", "top_pct", "bottom_pct"}
+	stringOperators = []string{"=", "!=", "LIKE", "NOT LIKE", "ILIKE", "IN", "NOT IN", "IS NULL", "IS NOT NULL"}
+)
+
+// Field describes a column the screener can filter, sort and return. Expr is
+// a SQL expression over the fundamentals (f) and latest prices (p) tables and
+// may be NULL. Table is empty for computed fields.
+type Field struct {
+	Name        string    `json:"name"`
+	Label       string    `json:"label"`
+	Description string    `json:"description"`
+	Table       string    `json:"table,omitempty"`
+	Expr        string    `json:"-"`
+	Type        FieldType `json:"type"`
+	Unit        Unit      `json:"unit,omitempty"`
+	Operators   []string  `json:"operators"`
+	// Alias is the field's name in EODHD screener filters, if it differs
+	Alias string `json:"eodhd_alias,omitempty"`
+}
+
+// fields is the registry of every named field. Adding a metric here makes it
+// filterable, sortable, selectable and listed by the fields endpoint.
+var fields = slices.Concat(baseFields, ratioFields(), growthFields(), peerFields(), valuationFields(valuationModels))
+
+// baseFields lists the stored columns first and computed ones after
+var baseFields = []Field{
+	{Name: "ticker", Label: "Ticker", Description: "Exchange ticker symbol",
+		Table: "fundamentals", Expr: "f.ticker", Type: TypeString, Operators: stringOperators, Alias: "code"},
+	{Name: "pe_ratio", Label: "P/E", Description: "Price to trailing earnings",
+		Table: "fundamentals", Expr: "f.pe_ratio", Type: TypeNumber, Unit: UnitRatio, Operators: numberOperators},
+	{Name: "roe", Label: "ROE", Description: "Return on equity: net income over shareholders' equity",
+		Table: "fundamentals", Expr: "f.roe", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "close", Label: "Price", Description: "Latest closing price",
+		Table: "prices", Expr: "p.close", Type: TypeNumber, Unit: UnitCurrency, Operators: numberOperators},
+	{Name: "sma50", Label: "SMA 50", Description: "50-day simple moving average of the close",
+		Table: "prices", Expr: "p.sma50", Type: TypeNumber, Unit: UnitCurrency, Operators: numberOperators},
+	{Name: "sma200", Label: "SMA 200", Description: "200-day simple moving average of the close",
+		Table: "prices", Expr: "p.sma200", Type: TypeNumber, Unit: UnitCurrency, Operators: numberOperators},
+	{Name: "earnings_outlook", Label: "Earnings outlook", Description: "Analysts' earnings trend: positive, neutral, negative or stable",
+		Table: "fundamentals", Expr: "f.earnings_outlook", Type: TypeString, Operators: stringOperators},
+	{Name: "dividend_yield", Label: "Dividend yield", Description: "Dividends per share over price",
+		Table: "fundamentals", Expr: "f.dividend_yield", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "dividend_growth_5y", Label: "Dividend growth (5y)", Description: "Annualized dividend growth over five years",
+		Table: "fundamentals", Expr: "f.dividend_growth_5y", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "intrinsic_value", Label: "Intrinsic value", Description: "Graham intrinsic value per share",
+		Table: "fundamentals", Expr: "f.intrinsic_value", Type: TypeNumber, Unit: UnitCurrency, Operators: numberOperators},
+	{Name: "margin_of_safety", Label: "Margin of safety", Description: "Discount of the price to intrinsic value",
+		Table: "fundamentals", Expr: "f.margin_of_safety", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "sector", Label: "Sector", Description: "Sector the company operates in",
+		Table: "fundamentals", Expr: "f.sector", Type: TypeString, Operators: stringOperators},
+	{Name: "industry", Label: "Industry", Description: "Industry within the sector",
+		Table: "fundamentals", Expr: "f.industry", Type: TypeString, Operators: stringOperators},
+	{Name: "currency", Label: "Currency", Description: "Currency the security is quoted in, such as USD, EUR or GBX for pence",
+		Table: "fundamentals", Expr: "f.currency", Type: TypeString, Operators: stringOperators},
+	{Name: "piotroski_f_score", Label: "Piotroski F-Score", Description: "Financial strength signals met, from 0 to 9",
+		Table: "fundamentals", Expr: "f.piotroski_f_score", Type: TypeNumber, Operators: numberOperators},
+	{Name: "altman_z_score", Label: "Altman Z-Score", Description: "Bankruptcy risk score; above 2.99 is safe, below 1.81 distressed",
+		Table: "fundamentals", Expr: "f.altman_z_score", Type: TypeNumber, Operators: numberOperators},
+	{Name: "news_sentiment_30d", Label: "News sentiment (30d)", Description: "Mean sentiment of the last 30 days' news articles, from -1 to 1",
+		Table: "fundamentals", Expr: "f.news_sentiment_30d", Type: TypeNumber, Operators: numberOperators},
+	{Name: "news_articles_30d", Label: "News articles (30d)", Description: "News articles scored over the last 30 days",
+		Table: "fundamentals", Expr: "f.news_articles_30d", Type: TypeNumber, Operators: numberOperators},
+
+	{Name: "price_vs_sma50", Label: "Price / SMA 50", Description: "Close over the 50-day average; below 1 trades under it",
+		Expr: "p.close / NULLIF(p.sma50, 0)", Type: TypeNumber, Unit: UnitRatio, Operators: numberOperators},
+	{Name: "price_vs_sma200", Label: "Price / SMA 200", Description: "Close over the 200-day average; below 1 trades under it",
+		Expr: "p.close / NULLIF(p.sma200, 0)", Type: TypeNumber, Unit: UnitRatio, Operators: numberOperators},
+	{Name: "intrinsic_vs_price", Label: "Intrinsic value / price", Description: "Intrinsic value over the close; above 1 is undervalued",
+		Expr: "f.intrinsic_value / NULLIF(p.close, 0)", Type: TypeNumber, Unit: UnitRatio, Operators: numberOperators},
+	{Name: "earnings_yield", Label: "Earnings yield", Description: "Inverse of the P/E",
+		Expr: "1.0 / NULLIF(f.pe_ratio, 0)", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "upside", Label: "Upside", Description: "Gain if the price reached intrinsic value",
+		Expr: "f.intrinsic_value / NULLIF(p.close, 0) - 1", Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+	{Name: "altman_zone", Label: "Altman zone", Description: "Altman Z-Score band: safe, grey or distress",
+		Expr: "CASE WHEN f.altman_z_score IS NULL THEN NULL WHEN f.altman_z_score > 2.99 THEN 'safe' WHEN f.altman_z_score >= 1.81 THEN 'grey' ELSE 'distress' END",
+		Type: TypeString, Operators: stringOperators},
+}
+
+// Fields returns the field registry
+func Fields() []Field {
+	return slices.Clone(fields)
+}
+
+// LookupField returns the named field
+func LookupField(name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// RegisterField adds a field defined outside this package, such as a scoring
+// model's stored score. Registering the same field twice is a no-op; it must
+// happen during startup, before any screening.
+func RegisterField(f Field) error {
+	if existing, ok := LookupField(f.Name); ok {
+		if existing.Expr == f.Expr {
+			return nil
+		}
+		return fmt.Errorf("field %s is already registered", f.Name)
+	}
+	if f.Expr == "" || (f.Type != TypeNumber && f.Type != TypeString) {
+		return fmt.Errorf("field %s needs an expression and a type", f.Name)
+	}
+	fields = append(fields, f)
+	return nil
+}
+
+// NumberOperators returns the operators allowed on numeric fields
+func NumberOperators() []string {
+	return slices.Clone(numberOperators)
+}
+
+// lookupFilterField resolves a field name or EODHD alias used in a filter
+func lookupFilterField(name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name || (f.Alias != "" && f.Alias == name) {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// inCurrency returns the field with amounts converted from each security's
+// currency into currency at the latest rates. Other fields, and every field
+// when currency is empty, are returned unchanged.
+func (f Field) inCurrency(currency string) Field {
+	if currency == "" || f.Unit != UnitCurrency {
+		return f
+	}
+	f.Expr = fmt.Sprintf("(%s) * %s", f.Expr, fx.ConversionExpr("f.currency", currency))
+	return f
+}
+
+// FieldNames lists the names of all named fields
+func FieldNames() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ParseFields resolves requested field names. Besides named fields, a ratio
+// of two numeric fields may be requested as "a/b", e.g. "close/sma200". Only
+// registered expressions reach the SQL, so the select list is always safe.
+func ParseFields(names []string) ([]Field, error) {
+	result := make([]Field, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if f, ok := LookupField(name); ok {
+			result = append(result, f)
+			continue
+		}
+
+		num, den, isRatio := strings.Cut(name, "/")
+		if !isRatio {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		a, aok := LookupField(strings.TrimSpace(num))
+		b, bok := LookupField(strings.TrimSpace(den))
+		if !aok || !bok || a.Type != TypeNumber || b.Type != TypeNumber {
+			return nil, fmt.Errorf("invalid ratio %q: both sides must be numeric fields", name)
+		}
+		result = append(result, Field{
+			Name:        name,
+			Label:       a.Label + " / " + b.Label,
+			Description: fmt.Sprintf("%s divided by %s", a.Label, b.Label),
+			Expr:        fmt.Sprintf("CAST((%s) AS REAL) / NULLIF(%s, 0)", a.Expr, b.Expr),
+			Type:        TypeNumber,
+			Unit:        UnitRatio,
+			Operators:   numberOperators,
+		})
+	}
+	return result, nil
+}
+
+// Row is a screener result holding the requested fields by name. Missing
+// metrics are nil.
+type Row map[string]any
+
+// scanRow reads the current row into a Row keyed by field name
+func scanRow(rows *sql.Rows, selected []Field) (Row, error) {
+	dest := make([]any, len(selected))
+	for i, f := range selected {
+		if f.Type == TypeString {
+			dest[i] = new(sql.NullString)
+		} else {
+			dest[i] = new(sql.NullFloat64)
+		}
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("row scanning failed: %w", err)
+	}
+
+	row := make(Row, len(selected))
+	for i, f := range selected {
+		switch v := dest[i].(type) {
+		case *sql.NullString:
+			if v.Valid {
+				row[f.Name] = v.String
+			} else {
+				row[f.Name] = nil
+			}
+		case *sql.NullFloat64:
+			if v.Valid {
+				row[f.Name] = v.Float64
+			} else {
+				row[f.Name] = nil
+			}
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,95 @@
+package screener
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields([]string{"ticker", "upside", " close/sma200 ", "ticker"})
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if len(fields) != 3 {
+		t.Fatalf("Expected duplicates to be dropped, got %d fields", len(fields))
+	}
+	if fields[2].Name != "close/sma200" || fields[2].Type != TypeNumber {
+		t.Errorf("Expected a numeric close/sma200 ratio, got %+v", fields[2])
+	}
+
+	for _, invalid := range []string{"volume", "ticker/close", "close/", "close/sma200; DROP TABLE prices"} {
+		if _, err := ParseFields([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestScreenRowsComputedFields(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	filter := ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: "AAPL"}},
+		Fields:     []string{"ticker", "earnings_yield", "upside", "close/sma200"},
+	}
+	rows, err := ScreenRows(db, filter)
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(rows))
+	}
+
+	row := rows[0]
+	if len(row) != 4 {
+		t.Errorf("Expected only the requested fields, got %v", row)
+	}
+	if row["ticker"] != "AAPL" {
+		t.Errorf("Expected AAPL, got %v", row["ticker"])
+	}
+
+	expected := map[string]float64{
+		"earnings_yield": 1 / 14.5,
+		"upside":         180.50/150.25 - 1,
+		"close/sma200":   150.25 / 140.30,
+	}
+	for name, want := range expected {
+		got, ok := row[name].(float64)
+		if !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", name, want, row[name])
+		}
+	}
+}
+
+func TestScreenRowsNullMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO fundamentals (ticker) VALUES ('NEW')`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: "NEW"}},
+		Fields:     []string{"pe_ratio", "earnings_yield", "close"},
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	for name, v := range rows[0] {
+		if v != nil {
+			t.Errorf("Expected %s to be nil, got %v", name, v)
+		}
+	}
+
+	// ScreenStocks keeps reporting missing metrics as zero
+	results, err := ScreenStocks(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: "NEW"}},
+	})
+	if err != nil {
+		t.Fatalf("ScreenStocks failed: %v", err)
+	}
+	if results[0].PE != 0 || results[0].Close != 0 {
+		t.Errorf("Expected zero metrics, got %+v", results[0])
+	}
+}
@@ -0,0 +1,110 @@
+package screener
+
+import (
+	"math"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// FinancialYear holds the statement lines the quality scores and valuation
+// models need for one fiscal year
+type FinancialYear struct {
+	Period              string
+	TotalAssets         float64
+	CurrentAssets       float64
+	Inventory           float64
+	CurrentLiabilities  float64
+	TotalLiabilities    float64
+	Equity              float64
+	ShortTermDebt       float64
+	LongTermDebt        float64
+	Cash                float64
+	RetainedEarnings    float64
+	SharesOutstanding   float64
+	Revenue             float64
+	GrossProfit         float64
+	OperatingIncome     float64
+	EBIT                float64
+	InterestExpense     float64
+	IncomeBeforeTax     float64
+	IncomeTaxExpense    float64
+	NetIncome           float64
+	OperatingCashFlow   float64
+	CapitalExpenditures float64
+	Depreciation        float64
+	DividendsPaid       float64
+	FreeCashFlow        float64
+}
+
+// FinancialYears reads the yearly statements, newest first, of every fiscal
+// year with a balance sheet
+func FinancialYears(fund *eodhd.Fundamentals) []FinancialYear {
+	var years []FinancialYear
+	for _, period := range fund.GetPeriods("Financials::Balance_Sheet::yearly") {
+		balance := "Financials::Balance_Sheet::yearly::" + period + "::"
+		income := "Financials::Income_Statement::yearly::" + period + "::"
+		cashFlow := "Financials::Cash_Flow::yearly::" + period + "::"
+
+		cash := fund.GetFloat(balance + "cashAndShortTermInvestments")
+		if cash == 0 {
+			cash = fund.GetFloat(balance + "cash")
+		}
+
+		years = append(years, FinancialYear{
+			Period:              period,
+			TotalAssets:         fund.GetFloat(balance + "totalAssets"),
+			CurrentAssets:       fund.GetFloat(balance + "totalCurrentAssets"),
+			Inventory:           fund.GetFloat(balance + "inventory"),
+			CurrentLiabilities:  fund.GetFloat(balance + "totalCurrentLiabilities"),
+			TotalLiabilities:    fund.GetFloat(balance + "totalLiab"),
+			Equity:              fund.GetFloat(balance + "totalStockholderEquity"),
+			ShortTermDebt:       fund.GetFloat(balance + "shortTermDebt"),
+			LongTermDebt:        fund.GetFloat(balance + "longTermDebt"),
+			Cash:                cash,
+			RetainedEarnings:    fund.GetFloat(balance + "retainedEarnings"),
+			SharesOutstanding:   fund.GetFloat(balance + "commonStockSharesOutstanding"),
+			Revenue:             fund.GetFloat(income + "totalRevenue"),
+			GrossProfit:         fund.GetFloat(income + "grossProfit"),
+			OperatingIncome:     fund.GetFloat(income + "operatingIncome"),
+			EBIT:                fund.GetFloat(income + "ebit"),
+			InterestExpense:     fund.GetFloat(income + "interestExpense"),
+			IncomeBeforeTax:     fund.GetFloat(income + "incomeBeforeTax"),
+			IncomeTaxExpense:    fund.GetFloat(income + "incomeTaxExpense"),
+			NetIncome:           fund.GetFloat(income + "netIncome"),
+			OperatingCashFlow:   fund.GetFloat(cashFlow + "totalCashFromOperatingActivities"),
+			CapitalExpenditures: fund.GetFloat(cashFlow + "capitalExpenditures"),
+			Depreciation:        fund.GetFloat(cashFlow + "depreciation"),
+			DividendsPaid:       fund.GetFloat(cashFlow + "dividendsPaid"),
+			FreeCashFlow:        fund.GetFloat(cashFlow + "freeCashFlow"),
+		})
+	}
+	return years
+}
+
+// TotalDebt is short and long-term debt
+func (y FinancialYear) TotalDebt() float64 {
+	return y.ShortTermDebt + y.LongTermDebt
+}
+
+// EBITDA adds depreciation and amortisation back to EBIT
+func (y FinancialYear) EBITDA() float64 {
+	return y.EBIT + math.Abs(y.Depreciation)
+}
+
+// FCF is the reported free cash flow, or operating cash flow less capital
+// expenditures when it isn't reported. EODHD reports capex as negative or
+// positive depending on the filing, so its magnitude is used.
+func (y FinancialYear) FCF() float64 {
+	if y.FreeCashFlow != 0 {
+		return y.FreeCashFlow
+	}
+	return y.OperatingCashFlow - math.Abs(y.CapitalExpenditures)
+}
+
+// TaxRate is the effective tax rate, or 0 when pre-tax income isn't positive
+func (y FinancialYear) TaxRate() float64 {
+	if y.IncomeBeforeTax <= 0 || y.IncomeTaxExpense < 0 {
+		return 0
+	}
+	return y.IncomeTaxExpense / y.IncomeBeforeTax
+}
@@ -0,0 +1,180 @@
+package screener
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// GrowthPoint is an income statement line for one period with its growth
+// over the same period a year earlier. Growth is nil without a comparable
+// earlier period or when the earlier value isn't positive.
+type GrowthPoint struct {
+	Period string   `json:"period"`
+	Value  float64  `json:"value"`
+	Growth *float64 `json:"growth"`
+}
+
+// GrowthSeries is the year-over-year history of an income statement line,
+// newest first, stored as fundamentals' yoy_profit and yoy_turnover. The
+// derived values are stored with it so they can be screened on.
+type GrowthSeries struct {
+	Yearly    []GrowthPoint `json:"yearly"`
+	Quarterly []GrowthPoint `json:"quarterly"`
+	// Streak counts the consecutive latest fiscal years of growth
+	Streak int      `json:"streak"`
+	CAGR3y *float64 `json:"cagr_3y"`
+	CAGR5y *float64 `json:"cagr_5y"`
+}
+
+// IncomeGrowth reads the yearly and quarterly history of an income statement
+// line such as totalRevenue or netIncome
+func IncomeGrowth(fund *eodhd.Fundamentals, item string) GrowthSeries {
+	series := GrowthSeries{
+		Yearly:    growthPoints(fund, "Financials::Income_Statement::yearly", item, 1),
+		Quarterly: growthPoints(fund, "Financials::Income_Statement::quarterly", item, 4),
+	}
+
+	for _, p := range series.Yearly {
+		if p.Growth == nil || *p.Growth <= 0 {
+			break
+		}
+		series.Streak++
+	}
+	series.CAGR3y = seriesCAGR(series.Yearly, 3)
+	series.CAGR5y = seriesCAGR(series.Yearly, 5)
+	return series
+}
+
+// growthPoints compares each period with the one lag periods older, if that
+// ended about a year before. Fiscal periods may end on different days each
+// year, e.g. the last Saturday of September.
+func growthPoints(fund *eodhd.Fundamentals, path, item string, lag int) []GrowthPoint {
+	periods := fund.GetPeriods(path)
+	points := make([]GrowthPoint, 0, len(periods))
+	for _, period := range periods {
+		points = append(points, GrowthPoint{Period: period, Value: fund.GetFloat(path + "::" + period + "::" + item)})
+	}
+
+	for i := range points {
+		if i+lag >= len(points) || !aboutAYearApart(points[i+lag].Period, points[i].Period) {
+			continue
+		}
+		if prev := points[i+lag].Value; prev > 0 {
+			growth := points[i].Value/prev - 1
+			points[i].Growth = &growth
+		}
+	}
+	return points
+}
+
+func aboutAYearApart(from, to string) bool {
+	a, errA := time.Parse("2006-01-02", from)
+	b, errB := time.Parse("2006-01-02", to)
+	if errA != nil || errB != nil {
+		return false
+	}
+	days := b.Sub(a).Hours() / 24
+	return days >= 350 && days <= 380
+}
+
+// seriesCAGR is the compound annual growth over the latest years, or nil
+// without enough positive history
+func seriesCAGR(yearly []GrowthPoint, years int) *float64 {
+	if len(yearly) <= years {
+		return nil
+	}
+	end, start := yearly[0].Value, yearly[years].Value
+	if start <= 0 || end <= 0 {
+		return nil
+	}
+	cagr := math.Pow(end/start, 1/float64(years)) - 1
+	return &cagr
+}
+
+// growthFields derives screener fields from the stored series, e.g.
+// revenue_cagr_3y and profit_growth_streak
+func growthFields() []Field {
+	var result []Field
+	for _, s := range []struct{ Prefix, Label, Column string }{
+		{"revenue", "Revenue", "f.yoy_turnover"},
+		{"profit", "Net income", "f.yoy_profit"},
+	} {
+		extract := func(path string) string {
+			return fmt.Sprintf("json_extract(%s, '%s')", s.Column, path)
+		}
+		result = append(result,
+			Field{Name: s.Prefix + "_growth", Label: s.Label + " growth",
+				Description: s.Label + " growth over the previous fiscal year",
+				Expr:        extract("$.yearly[0].growth"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+			Field{Name: s.Prefix + "_growth_quarterly", Label: s.Label + " growth (quarter)",
+				Description: s.Label + " growth of the latest quarter over the same quarter a year earlier",
+				Expr:        extract("$.quarterly[0].growth"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+			Field{Name: s.Prefix + "_growth_streak", Label: s.Label + " growth streak",
+				Description: "Consecutive latest fiscal years of " + s.Label + " growth; 5 means it grew in each of the last five",
+				Expr:        extract("$.streak"), Type: TypeNumber, Operators: numberOperators},
+			Field{Name: s.Prefix + "_cagr_3y", Label: s.Label + " CAGR (3y)",
+				Description: "Annualized " + s.Label + " growth over three fiscal years",
+				Expr:        extract("$.cagr_3y"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+			Field{Name: s.Prefix + "_cagr_5y", Label: s.Label + " CAGR (5y)",
+				Description: "Annualized " + s.Label + " growth over five fiscal years",
+				Expr:        extract("$.cagr_5y"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+		)
+	}
+	return result
+}
+
+// SaveGrowthSeries stores the net income and revenue series
+func SaveGrowthSeries(db *sql.DB, ticker string, profit, turnover GrowthSeries) error {
+	profitJSON, err := json.Marshal(profit)
+	if err != nil {
+		return err
+	}
+	turnoverJSON, err := json.Marshal(turnover)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE fundamentals SET yoy_profit = ?, yoy_turnover = ?
+		WHERE ticker = ?`,
+		string(profitJSON), string(turnoverJSON), ticker,
+	)
+	if err != nil {
+		return fmt.Errorf("saving growth series: %w", err)
+	}
+	return nil
+}
+
+// loadGrowthSeries returns the stored net income and revenue series, nil
+// where none has been stored
+func loadGrowthSeries(db *sql.DB, ticker string) (profit, turnover *GrowthSeries, err error) {
+	var profitJSON, turnoverJSON sql.NullString
+	err = db.QueryRow("SELECT yoy_profit, yoy_turnover FROM fundamentals WHERE ticker = ?", ticker).
+		Scan(&profitJSON, &turnoverJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying growth series: %w", err)
+	}
+
+	decode := func(s sql.NullString) (*GrowthSeries, error) {
+		if !s.Valid || s.String == "" {
+			return nil, nil
+		}
+		var series GrowthSeries
+		if err := json.Unmarshal([]byte(s.String), &series); err != nil {
+			return nil, fmt.Errorf("invalid growth series: %w", err)
+		}
+		return &series, nil
+	}
+	if profit, err = decode(profitJSON); err != nil {
+		return nil, nil, err
+	}
+	if turnover, err = decode(turnoverJSON); err != nil {
+		return nil, nil, err
+	}
+	return profit, turnover, nil
+}
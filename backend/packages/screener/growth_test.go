@@ -0,0 +1,125 @@
+package screener
+
+import (
+	"math"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+func incomeStatements(yearly, quarterly map[string]float64) *eodhd.Fundamentals {
+	periods := func(values map[string]float64) map[string]any {
+		result := map[string]any{}
+		for period, v := range values {
+			result[period] = map[string]any{"totalRevenue": v}
+		}
+		return result
+	}
+	return eodhd.NewFundamentals(map[string]any{
+		"Financials": map[string]any{"Income_Statement": map[string]any{
+			"yearly":    periods(yearly),
+			"quarterly": periods(quarterly),
+		}},
+	})
+}
+
+func TestIncomeGrowth(t *testing.T) {
+	fund := incomeStatements(
+		map[string]float64{
+			"2017-12-31": 120, "2018-12-31": 100, "2019-12-31": 110, "2020-12-31": 121,
+			"2021-12-31": 133.1, "2022-12-31": 146.41, "2023-12-31": 161.051,
+		},
+		map[string]float64{
+			// Quarters end on the last Saturday, so they drift by a few days
+			"2022-09-24": 90, "2022-12-31": 117, "2023-04-01": 95, "2023-07-01": 81,
+			"2023-09-30": 89, "2023-12-30": 120, "2024-03-30": 91,
+		},
+	)
+
+	series := IncomeGrowth(fund, "totalRevenue")
+	if len(series.Yearly) != 7 || series.Yearly[0].Period != "2023-12-31" {
+		t.Fatalf("Expected seven years newest first, got %+v", series.Yearly)
+	}
+	if g := series.Yearly[0].Growth; g == nil || math.Abs(*g-0.1) > 1e-9 {
+		t.Errorf("Expected 10%% growth in 2023, got %v", g)
+	}
+	if series.Yearly[6].Growth != nil {
+		t.Errorf("Expected no growth for the oldest year, got %v", *series.Yearly[6].Growth)
+	}
+	// 2018 fell, so the streak covers 2019 to 2023
+	if series.Streak != 5 {
+		t.Errorf("Expected a five year streak, got %d", series.Streak)
+	}
+	if series.CAGR3y == nil || math.Abs(*series.CAGR3y-0.1) > 1e-9 {
+		t.Errorf("Expected a 10%% three year CAGR, got %v", series.CAGR3y)
+	}
+	if series.CAGR5y == nil || math.Abs(*series.CAGR5y-(math.Pow(1.61051, 0.2)-1)) > 1e-9 {
+		t.Errorf("Unexpected five year CAGR %v", series.CAGR5y)
+	}
+
+	q := series.Quarterly
+	if len(q) != 7 || q[0].Period != "2024-03-30" {
+		t.Fatalf("Expected seven quarters newest first, got %+v", q)
+	}
+	// Q1 2024 against Q1 2023, and Q3 2023 against Q3 2022 despite the drift
+	if q[0].Growth == nil || math.Abs(*q[0].Growth-(91.0/95-1)) > 1e-9 {
+		t.Errorf("Unexpected Q1 growth %v", q[0].Growth)
+	}
+	if q[2].Growth == nil || math.Abs(*q[2].Growth-(89.0/90-1)) > 1e-9 {
+		t.Errorf("Unexpected Q3 growth %v", q[2].Growth)
+	}
+	if q[3].Growth != nil {
+		t.Errorf("Expected no growth without a quarter a year earlier, got %v", *q[3].Growth)
+	}
+
+	empty := IncomeGrowth(eodhd.NewFundamentals(map[string]any{}), "netIncome")
+	if len(empty.Yearly) != 0 || empty.Streak != 0 || empty.CAGR3y != nil {
+		t.Errorf("Expected an empty series, got %+v", empty)
+	}
+}
+
+func TestScreenGrowth(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	steady := incomeStatements(map[string]float64{
+		"2018-12-31": 100, "2019-12-31": 110, "2020-12-31": 121, "2021-12-31": 133.1, "2022-12-31": 146.41, "2023-12-31": 161.051,
+	}, nil)
+	choppy := incomeStatements(map[string]float64{
+		"2018-12-31": 100, "2019-12-31": 130, "2020-12-31": 120, "2021-12-31": 150, "2022-12-31": 170, "2023-12-31": 190,
+	}, nil)
+	for ticker, fund := range map[string]*eodhd.Fundamentals{"KO": steady, "MSFT": choppy} {
+		if err := SaveGrowthSeries(db, ticker, GrowthSeries{}, IncomeGrowth(fund, "totalRevenue")); err != nil {
+			t.Fatalf("SaveGrowthSeries failed: %v", err)
+		}
+	}
+
+	screen := func(conditions ...FilterCondition) []Row {
+		t.Helper()
+		rows, err := ScreenRows(db, ScreenerFilter{Conditions: conditions, Fields: []string{"ticker"}, Sort: "ticker.asc", Limit: 10})
+		if err != nil {
+			t.Fatalf("ScreenRows failed: %v", err)
+		}
+		return rows
+	}
+
+	// Revenue grew in each of the last five years
+	if rows := screen(FilterCondition{Field: "revenue_growth_streak", Operator: ">=", Value: 5.0}); len(rows) != 1 || rows[0]["ticker"] != "KO" {
+		t.Errorf("Expected only KO, got %v", rows)
+	}
+	// 3-year revenue CAGR above 12%
+	if rows := screen(FilterCondition{Field: "revenue_cagr_3y", Operator: ">", Value: 0.12}); len(rows) != 1 || rows[0]["ticker"] != "MSFT" {
+		t.Errorf("Expected only MSFT, got %v", rows)
+	}
+
+	stock, err := GetStock(db, "KO")
+	if err != nil {
+		t.Fatalf("GetStock failed: %v", err)
+	}
+	if stock.YoYRevenue == nil || len(stock.YoYRevenue.Yearly) != 6 || stock.YoYProfit == nil {
+		t.Errorf("Expected the stored series in the detail, got %+v", stock)
+	}
+	if stock, _ := GetStock(db, "IBM"); stock.YoYRevenue != nil {
+		t.Errorf("Expected no series for IBM, got %+v", stock.YoYRevenue)
+	}
+}
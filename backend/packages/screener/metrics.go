@@ -7,9 +7,11 @@ import (
 	"sort"
 )
 
+// EOD is a day's close, adjusted for later splits, and the close as traded
 type EOD struct {
-	Date  string
-	Close float64
+	Date     string
+	Close    float64
+	RawClose float64
 }
 
 // CalculateSMA computes SMA over given N periods
@@ -30,11 +32,11 @@ func CalculateSMA(data []EOD, days int) (float64, error) {
 	return sum / float64(days), nil
 }
 
-func SaveSMA(db *sql.DB, ticker, date string, close, sma50, sma200 float64) error {
+func SaveSMA(db *sql.DB, ticker string, latest EOD, sma50, sma200 float64) error {
 	_, err := db.Exec(`
-		INSERT OR REPLACE INTO prices (ticker, date, close, sma50, sma200)
-		VALUES (?, ?, ?, ?, ?)`,
-		ticker, date, close, sma50, sma200,
+		INSERT OR REPLACE INTO prices (ticker, date, close, sma50, sma200, raw_close)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		ticker, latest.Date, latest.Close, sma50, sma200, latest.RawClose,
 	)
 	return err
 }
@@ -48,8 +50,11 @@ func CalculateROE(netIncome, equity float64) (float64, error) {
 
 func SaveROE(db *sql.DB, ticker string, roe, pe float64, outlook string) error {
 	_, err := db.Exec(`
-		INSERT OR REPLACE INTO fundamentals (ticker, roe, pe_ratio, earnings_outlook, updated_at)
-		VALUES (?, ?, ?, ?, datetime('now'))`,
+		INSERT INTO fundamentals (ticker, roe, pe_ratio, earnings_outlook, updated_at)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(ticker) DO UPDATE SET
+			roe = excluded.roe, pe_ratio = excluded.pe_ratio,
+			earnings_outlook = excluded.earnings_outlook, updated_at = excluded.updated_at`,
 		ticker, roe, pe, outlook,
 	)
 	return err
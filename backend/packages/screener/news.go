@@ -0,0 +1,83 @@
+package screener
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/news"
+)
+
+const (
+	// newsWindowDays is the window of the rolling news sentiment
+	newsWindowDays = 30
+	// minOutlookArticles are needed for news sentiment to count towards the
+	// earnings outlook
+	minOutlookArticles = 3
+	// stockHeadlines are the latest articles shown in a stock's detail
+	stockHeadlines = 10
+)
+
+// newsScorer rates the sentiment of articles ingested by ProcessTicker
+var newsScorer news.Scorer = news.LexiconScorer{}
+
+// SetNewsScorer replaces the lexicon scorer, such as with a news.LLMScorer.
+// It must happen during startup, before any update.
+func SetNewsScorer(s news.Scorer) {
+	newsScorer = s
+}
+
+// newsSource provides articles and the provider's sentiment, e.g.
+// *eodhd.Client
+type newsSource interface {
+	news.Source
+	news.SentimentSource
+}
+
+// syncNewsSentiment syncs the ticker's news and returns its rolling
+// sentiment. Without scored articles in the window the provider's daily
+// sentiment is used instead, and nil returned when it has none either.
+func syncNewsSentiment(db *sql.DB, source newsSource, ticker string, now time.Time) (*news.Sentiment, error) {
+	if _, err := news.Sync(db, source, newsScorer, ticker, now); err != nil {
+		return nil, err
+	}
+	s, err := news.RollingSentiment(db, ticker, now, newsWindowDays)
+	if errors.Is(err, news.ErrNoData) {
+		s, err = news.ProviderSentiment(source, ticker, now, newsWindowDays)
+	}
+	if errors.Is(err, news.ErrNoData) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveNewsSentiment stores the rolling news sentiment, clearing it when nil
+func SaveNewsSentiment(db *sql.DB, ticker string, s *news.Sentiment) error {
+	var score, articles any
+	if s != nil {
+		score, articles = s.Score, s.Articles
+	}
+	_, err := db.Exec(`
+		UPDATE fundamentals SET news_sentiment_30d = ?, news_articles_30d = ?
+		WHERE ticker = ?`,
+		score, articles, ticker,
+	)
+	if err != nil {
+		return fmt.Errorf("saving news sentiment: %w", err)
+	}
+	return nil
+}
+
+// withNewsSentiment adds the news sentiment to the outlook's signals when
+// enough articles were scored
+func withNewsSentiment(signals EarningsSignals, s *news.Sentiment) EarningsSignals {
+	if s != nil && s.Articles >= minOutlookArticles {
+		score := s.Score
+		signals.NewsSentiment, signals.NewsArticles = &score, s.Articles
+	}
+	return signals
+}
@@ -0,0 +1,101 @@
+package screener
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+	"github.com/finsights-ai/backend/packages/news"
+)
+
+type fakeNewsSource []eodhd.NewsArticle
+
+func (s fakeNewsSource) GetNews(ticker string, from, to string, limit int) ([]eodhd.NewsArticle, error) {
+	return s, nil
+}
+
+// GetSentiments rates every requested day positive across two articles
+func (s fakeNewsSource) GetSentiments(tickers []string, from, to string) (map[string][]eodhd.DailySentiment, error) {
+	return map[string][]eodhd.DailySentiment{tickers[0]: {{Date: to, Count: 2, Normalized: 0.5}}}, nil
+}
+
+func TestNewsSentimentFeedsOutlookAndDetail(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	source := fakeNewsSource{
+		{Date: "2024-01-15T09:00:00+00:00", Title: "Tesla deliveries surge to a record", Link: "https://news/1"},
+		{Date: "2024-01-12T09:00:00+00:00", Title: "Tesla shares rally on upgrade", Link: "https://news/2"},
+		{Date: "2024-01-10T09:00:00+00:00", Title: "Tesla gains after strong quarter", Link: "https://news/3"},
+	}
+	sentiment, err := syncNewsSentiment(db, source, "TSLA", time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("syncNewsSentiment failed: %v", err)
+	}
+	if sentiment == nil || sentiment.Articles != 3 || sentiment.Score != 1 {
+		t.Fatalf("Expected three positive articles, got %+v", sentiment)
+	}
+	if err := SaveNewsSentiment(db, "TSLA", sentiment); err != nil {
+		t.Fatalf("SaveNewsSentiment failed: %v", err)
+	}
+
+	// Together with growth estimates, the news makes the outlook positive
+	signals := withNewsSentiment(EarningsSignals{CurrentYearGrowth: ptr(0.1)}, sentiment)
+	if outlook := ClassifyOutlook(signals); outlook.Rating != OutlookPositive || outlook.Evidence[1].Rule != "news_sentiment" {
+		t.Errorf("Expected a positive outlook from growth and news, got %+v", outlook)
+	}
+	// Too few articles are left out
+	if s := withNewsSentiment(EarningsSignals{}, &news.Sentiment{Score: 1, Articles: 2}); s.NewsSentiment != nil {
+		t.Errorf("Expected sentiment from two articles to be ignored, got %v", *s.NewsSentiment)
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "news_sentiment_30d", Operator: ">", Value: 0.5}},
+		Fields:     []string{"ticker", "news_articles_30d"},
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["ticker"] != "TSLA" || rows[0]["news_articles_30d"] != 3.0 {
+		t.Errorf("Expected TSLA to screen on news sentiment, got %v", rows)
+	}
+
+	stock, err := GetStock(db, "TSLA")
+	if err != nil {
+		t.Fatalf("GetStock failed: %v", err)
+	}
+	if len(stock.Headlines) != 3 || stock.Headlines[0].Title != "Tesla deliveries surge to a record" {
+		t.Errorf("Expected the headlines newest first, got %+v", stock.Headlines)
+	}
+	if stock, _ := GetStock(db, "IBM"); stock.Headlines == nil || len(stock.Headlines) != 0 {
+		t.Errorf("Expected no headlines for IBM, got %+v", stock.Headlines)
+	}
+}
+
+// failingScorer can't rate any article
+type failingScorer struct{}
+
+func (failingScorer) Name() string { return "failing" }
+func (failingScorer) Score(eodhd.NewsArticle) (float64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestNewsSentimentFallsBackToProvider(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	saved := newsScorer
+	defer SetNewsScorer(saved)
+	SetNewsScorer(failingScorer{})
+
+	// None of the articles can be scored, so EODHD's daily sentiment is used
+	source := fakeNewsSource{{Date: "2024-01-15T09:00:00+00:00", Title: "Tesla deliveries surge to a record", Link: "https://news/1"}}
+	sentiment, err := syncNewsSentiment(db, source, "TSLA", time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("syncNewsSentiment failed: %v", err)
+	}
+	if sentiment == nil || sentiment.Articles != 2 || sentiment.Score != 0.5 {
+		t.Errorf("Expected the provider's sentiment, got %+v", sentiment)
+	}
+}
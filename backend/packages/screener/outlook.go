@@ -0,0 +1,285 @@
+package screener
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// Earnings outlook classifications stored as fundamentals' earnings_outlook.
+// An outlook is empty when there are no estimates or news to judge.
+const (
+	OutlookPositive = "positive"
+	OutlookNeutral  = "neutral"
+	OutlookNegative = "negative"
+	// OutlookStable is a flat outlook: no growth or decline expected and
+	// estimates holding steady
+	OutlookStable = "stable"
+)
+
+// Thresholds above which a signal counts as improving or deteriorating
+const (
+	outlookGrowthThreshold   = 0.05
+	outlookRevisionThreshold = 0.02
+	// outlookSentimentThreshold is on the -1 to 1 news sentiment scale
+	outlookSentimentThreshold = 0.2
+	// outlookSurpriseQuarters are the latest reported quarters judged
+	outlookSurpriseQuarters = 4
+)
+
+// EarningsSignals are the analyst estimates, revisions, surprises and news
+// sentiment the outlook is derived from. Nil values weren't reported.
+type EarningsSignals struct {
+	// Estimated EPS growth for the current and next fiscal year
+	CurrentYearGrowth *float64 `json:"current_year_growth"`
+	NextYearGrowth    *float64 `json:"next_year_growth"`
+	// EstimateChange90d is the change of the current fiscal year's consensus
+	// EPS over the last 90 days
+	EstimateChange90d *float64 `json:"estimate_change_90d"`
+	// Analysts raising and cutting current and next year EPS estimates over
+	// the last 30 days
+	RevisionsUp30d   *int `json:"revisions_up_30d"`
+	RevisionsDown30d *int `json:"revisions_down_30d"`
+	// Surprises are the latest reported quarters, newest first
+	Surprises []EarningsSurprise `json:"surprises"`
+	// NewsSentiment is the mean sentiment of recent news articles, set when
+	// there were enough of them
+	NewsSentiment *float64 `json:"news_sentiment"`
+	NewsArticles  int      `json:"news_articles"`
+}
+
+// EarningsSurprise is a reported quarter's EPS against the consensus
+type EarningsSurprise struct {
+	Period   string  `json:"period"`
+	Actual   float64 `json:"actual"`
+	Estimate float64 `json:"estimate"`
+}
+
+// OutlookEvidence is one rule's verdict: 1 for an improving signal, -1 for a
+// deteriorating one and 0 for a flat one
+type OutlookEvidence struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// EarningsOutlook is a classification with the evidence behind it
+type EarningsOutlook struct {
+	Rating   string            `json:"rating"`
+	Score    int               `json:"score"`
+	Signals  EarningsSignals   `json:"signals"`
+	Evidence []OutlookEvidence `json:"evidence"`
+}
+
+// outlookRules are evaluated by ClassifyOutlook in order. Evaluate returns
+// false when the signals it needs weren't reported.
+var outlookRules = []struct {
+	Name     string
+	Evaluate func(s EarningsSignals) (int, string, bool)
+}{
+	{Name: "current_year_growth", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		return growthVerdict(s.CurrentYearGrowth, "this fiscal year")
+	}},
+	{Name: "next_year_growth", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		return growthVerdict(s.NextYearGrowth, "next fiscal year")
+	}},
+	{Name: "estimate_trend", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		if s.EstimateChange90d == nil {
+			return 0, "", false
+		}
+		change := *s.EstimateChange90d
+		detail := fmt.Sprintf("Consensus EPS for this fiscal year changed %+.1f%% over 90 days", change*100)
+		return threshold(change, outlookRevisionThreshold), detail, true
+	}},
+	{Name: "revision_breadth", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		if s.RevisionsUp30d == nil || s.RevisionsDown30d == nil {
+			return 0, "", false
+		}
+		up, down := *s.RevisionsUp30d, *s.RevisionsDown30d
+		detail := fmt.Sprintf("%d upward and %d downward EPS revisions over 30 days", up, down)
+		switch {
+		case up > down:
+			return 1, detail, true
+		case down > up:
+			return -1, detail, true
+		}
+		return 0, detail, true
+	}},
+	{Name: "earnings_surprises", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		if len(s.Surprises) < 2 {
+			return 0, "", false
+		}
+		beats, misses := 0, 0
+		for _, q := range s.Surprises {
+			switch {
+			case q.Actual > q.Estimate:
+				beats++
+			case q.Actual < q.Estimate:
+				misses++
+			}
+		}
+		detail := fmt.Sprintf("Beat estimates in %d and missed in %d of the last %d quarters", beats, misses, len(s.Surprises))
+		// Most of the quarters must agree
+		majority := len(s.Surprises)/2 + 1
+		switch {
+		case beats >= majority:
+			return 1, detail, true
+		case misses >= majority:
+			return -1, detail, true
+		}
+		return 0, detail, true
+	}},
+	{Name: "news_sentiment", Evaluate: func(s EarningsSignals) (int, string, bool) {
+		if s.NewsSentiment == nil {
+			return 0, "", false
+		}
+		detail := fmt.Sprintf("Mean sentiment of %d recent news articles is %+.2f", s.NewsArticles, *s.NewsSentiment)
+		return threshold(*s.NewsSentiment, outlookSentimentThreshold), detail, true
+	}},
+}
+
+func growthVerdict(growth *float64, when string) (int, string, bool) {
+	if growth == nil {
+		return 0, "", false
+	}
+	detail := fmt.Sprintf("Analysts expect EPS growth of %+.1f%% %s", *growth*100, when)
+	return threshold(*growth, outlookGrowthThreshold), detail, true
+}
+
+// threshold is 1 above limit, -1 below -limit and 0 in between
+func threshold(value, limit float64) int {
+	switch {
+	case value > limit:
+		return 1
+	case value < -limit:
+		return -1
+	}
+	return 0
+}
+
+// ClassifyOutlook runs every rule over the signals. A net score of 2 or more
+// is positive and -2 or less negative. Otherwise it is stable when every
+// signal is flat and neutral when they are mixed or only lean one way.
+func ClassifyOutlook(s EarningsSignals) EarningsOutlook {
+	outlook := EarningsOutlook{Signals: s, Evidence: []OutlookEvidence{}}
+	flat := true
+	for _, rule := range outlookRules {
+		score, detail, ok := rule.Evaluate(s)
+		if !ok {
+			continue
+		}
+		outlook.Evidence = append(outlook.Evidence, OutlookEvidence{Rule: rule.Name, Score: score, Detail: detail})
+		outlook.Score += score
+		flat = flat && score == 0
+	}
+
+	switch {
+	case len(outlook.Evidence) == 0:
+		// Nothing to judge
+	case outlook.Score >= 2:
+		outlook.Rating = OutlookPositive
+	case outlook.Score <= -2:
+		outlook.Rating = OutlookNegative
+	case flat:
+		outlook.Rating = OutlookStable
+	default:
+		outlook.Rating = OutlookNeutral
+	}
+	return outlook
+}
+
+// ExtractEarningsSignals reads the latest Earnings::Trend estimates for the
+// current (0y) and next (+1y) fiscal year and the Earnings::History surprises
+func ExtractEarningsSignals(fund *eodhd.Fundamentals) EarningsSignals {
+	var s EarningsSignals
+
+	// Trend entries are keyed by period end date and labelled 0q, +1q, 0y
+	// and +1y; the newest of each label is the current estimate
+	trend := map[string]string{}
+	for _, date := range fund.GetPeriods("Earnings::Trend") {
+		path := "Earnings::Trend::" + date
+		if label := fund.GetString(path + "::period"); label != "" && trend[label] == "" {
+			trend[label] = path
+		}
+	}
+	lookup := func(label, item string) *float64 {
+		if trend[label] == "" {
+			return nil
+		}
+		if v, ok := fund.LookupFloat(trend[label] + "::" + item); ok {
+			return &v
+		}
+		return nil
+	}
+
+	s.CurrentYearGrowth = lookup("0y", "growth")
+	s.NextYearGrowth = lookup("+1y", "growth")
+	if current, past := lookup("0y", "epsTrendCurrent"), lookup("0y", "epsTrend90daysAgo"); current != nil && past != nil && *past != 0 {
+		change := (*current - *past) / math.Abs(*past)
+		s.EstimateChange90d = &change
+	}
+
+	for _, label := range []string{"0y", "+1y"} {
+		up, down := lookup(label, "epsRevisionsUpLast30days"), lookup(label, "epsRevisionsDownLast30days")
+		if up == nil || down == nil {
+			continue
+		}
+		if s.RevisionsUp30d == nil {
+			s.RevisionsUp30d, s.RevisionsDown30d = new(int), new(int)
+		}
+		*s.RevisionsUp30d += int(*up)
+		*s.RevisionsDown30d += int(*down)
+	}
+
+	// History includes upcoming quarters, which have no actual EPS yet
+	for _, date := range fund.GetPeriods("Earnings::History") {
+		path := "Earnings::History::" + date
+		actual, okActual := fund.LookupFloat(path + "::epsActual")
+		estimate, okEstimate := fund.LookupFloat(path + "::epsEstimate")
+		if !okActual || !okEstimate {
+			continue
+		}
+		s.Surprises = append(s.Surprises, EarningsSurprise{Period: date, Actual: actual, Estimate: estimate})
+		if len(s.Surprises) == outlookSurpriseQuarters {
+			break
+		}
+	}
+	return s
+}
+
+// SaveOutlook stores the classification and its evidence
+func SaveOutlook(db *sql.DB, ticker string, outlook EarningsOutlook) error {
+	evidence, err := json.Marshal(outlook)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		UPDATE fundamentals SET earnings_outlook = ?, earnings_outlook_evidence = ?
+		WHERE ticker = ?`,
+		outlook.Rating, string(evidence), ticker,
+	)
+	if err != nil {
+		return fmt.Errorf("saving earnings outlook: %w", err)
+	}
+	return nil
+}
+
+// loadOutlook returns the stored outlook, nil if none has been classified
+func loadOutlook(db *sql.DB, ticker string) (*EarningsOutlook, error) {
+	var evidence sql.NullString
+	err := db.QueryRow("SELECT earnings_outlook_evidence FROM fundamentals WHERE ticker = ?", ticker).Scan(&evidence)
+	if err != nil {
+		return nil, fmt.Errorf("querying earnings outlook: %w", err)
+	}
+	if !evidence.Valid || evidence.String == "" {
+		return nil, nil
+	}
+	var outlook EarningsOutlook
+	if err := json.Unmarshal([]byte(evidence.String), &outlook); err != nil {
+		return nil, fmt.Errorf("invalid earnings outlook: %w", err)
+	}
+	return &outlook, nil
+}
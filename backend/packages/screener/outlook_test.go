@@ -0,0 +1,157 @@
+package screener
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+// earningsFundamentals mimics EODHD's Earnings section, where trend figures
+// are strings and upcoming quarters have a null epsActual
+func earningsFundamentals() *eodhd.Fundamentals {
+	return eodhd.NewFundamentals(map[string]any{
+		"Earnings": map[string]any{
+			"Trend": map[string]any{
+				"2024-03-31": map[string]any{"period": "0q", "growth": "0.0400"},
+				"2024-06-30": map[string]any{"period": "+1q", "growth": "0.0300"},
+				"2024-12-31": map[string]any{
+					"period": "0y", "growth": "0.1200",
+					"epsTrendCurrent": "6.60", "epsTrend90daysAgo": "6.30",
+					"epsRevisionsUpLast30days": "8", "epsRevisionsDownLast30days": "2",
+				},
+				"2025-12-31": map[string]any{
+					"period": "+1y", "growth": "0.0900",
+					"epsRevisionsUpLast30days": "5", "epsRevisionsDownLast30days": "1",
+				},
+			},
+			"History": map[string]any{
+				"2024-03-31": map[string]any{"epsActual": nil, "epsEstimate": 1.5},
+				"2023-12-31": map[string]any{"epsActual": 2.18, "epsEstimate": 2.1},
+				"2023-09-30": map[string]any{"epsActual": 1.46, "epsEstimate": 1.39},
+				"2023-06-30": map[string]any{"epsActual": 1.26, "epsEstimate": 1.19},
+				"2023-03-31": map[string]any{"epsActual": 1.52, "epsEstimate": 1.43},
+				"2022-12-31": map[string]any{"epsActual": 1.88, "epsEstimate": 1.94},
+			},
+		},
+	})
+}
+
+func TestExtractEarningsSignals(t *testing.T) {
+	s := ExtractEarningsSignals(earningsFundamentals())
+
+	if s.CurrentYearGrowth == nil || *s.CurrentYearGrowth != 0.12 {
+		t.Errorf("Expected 12%% current year growth, got %v", s.CurrentYearGrowth)
+	}
+	if s.NextYearGrowth == nil || *s.NextYearGrowth != 0.09 {
+		t.Errorf("Expected 9%% next year growth, got %v", s.NextYearGrowth)
+	}
+	if s.EstimateChange90d == nil || math.Abs(*s.EstimateChange90d-0.3/6.3) > 1e-9 {
+		t.Errorf("Unexpected 90 day estimate change %v", s.EstimateChange90d)
+	}
+	if s.RevisionsUp30d == nil || *s.RevisionsUp30d != 13 || *s.RevisionsDown30d != 3 {
+		t.Errorf("Expected 13 up and 3 down revisions, got %v and %v", s.RevisionsUp30d, s.RevisionsDown30d)
+	}
+
+	// The upcoming quarter is skipped and only the latest four are kept
+	periods := make([]string, len(s.Surprises))
+	for i, q := range s.Surprises {
+		periods[i] = q.Period
+	}
+	if want := []string{"2023-12-31", "2023-09-30", "2023-06-30", "2023-03-31"}; !slices.Equal(periods, want) {
+		t.Errorf("Expected surprises for %v, got %v", want, periods)
+	}
+}
+
+func TestExtractEarningsSignalsMissing(t *testing.T) {
+	s := ExtractEarningsSignals(eodhd.NewFundamentals(map[string]any{}))
+	if s.CurrentYearGrowth != nil || s.EstimateChange90d != nil || s.RevisionsUp30d != nil || len(s.Surprises) != 0 {
+		t.Errorf("Expected no signals, got %+v", s)
+	}
+	if outlook := ClassifyOutlook(s); outlook.Rating != "" || len(outlook.Evidence) != 0 {
+		t.Errorf("Expected no classification without estimates, got %+v", outlook)
+	}
+}
+
+func TestClassifyOutlook(t *testing.T) {
+	n := func(v int) *int { return &v }
+	surprises := func(diffs ...float64) []EarningsSurprise {
+		result := make([]EarningsSurprise, len(diffs))
+		for i, d := range diffs {
+			result[i] = EarningsSurprise{Actual: 1 + d, Estimate: 1}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		signals EarningsSignals
+		rating  string
+		score   int
+	}{
+		{"positive", ExtractEarningsSignals(earningsFundamentals()), OutlookPositive, 5},
+		{"negative", EarningsSignals{
+			CurrentYearGrowth: ptr(-0.15), EstimateChange90d: ptr(-0.08),
+			RevisionsUp30d: n(1), RevisionsDown30d: n(6),
+			Surprises: surprises(-0.1, -0.05, 0.02, -0.03),
+		}, OutlookNegative, -4},
+		{"stable", EarningsSignals{
+			CurrentYearGrowth: ptr(0.02), NextYearGrowth: ptr(0.03), EstimateChange90d: ptr(0.001),
+			RevisionsUp30d: n(0), RevisionsDown30d: n(0),
+			Surprises: surprises(0.01, -0.01, 0.02, -0.02),
+		}, OutlookStable, 0},
+		{"mixed", EarningsSignals{
+			CurrentYearGrowth: ptr(0.2), EstimateChange90d: ptr(-0.05),
+		}, OutlookNeutral, 0},
+		{"leaning", EarningsSignals{
+			CurrentYearGrowth: ptr(0.2), NextYearGrowth: ptr(0.01),
+		}, OutlookNeutral, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outlook := ClassifyOutlook(tt.signals)
+			if outlook.Rating != tt.rating || outlook.Score != tt.score {
+				t.Errorf("Expected %s with score %d, got %s with %d: %+v", tt.rating, tt.score, outlook.Rating, outlook.Score, outlook.Evidence)
+			}
+			for _, e := range outlook.Evidence {
+				if e.Detail == "" {
+					t.Errorf("Rule %s gave no detail", e.Rule)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveOutlookMatchesGrowthStocks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	outlook := ClassifyOutlook(ExtractEarningsSignals(earningsFundamentals()))
+	if err := SaveROE(db, "NVDA", 0.35, 40, outlook.Rating); err != nil {
+		t.Fatalf("SaveROE failed: %v", err)
+	}
+	if err := SaveOutlook(db, "NVDA", outlook); err != nil {
+		t.Fatalf("SaveOutlook failed: %v", err)
+	}
+
+	results, err := ScreenStocks(db, GrowthStocks.Build())
+	if err != nil {
+		t.Fatalf("ScreenStocks failed: %v", err)
+	}
+	if !slices.ContainsFunc(results, func(r ScreenerResult) bool { return r.Ticker == "NVDA" }) {
+		t.Errorf("Expected NVDA among growth stocks, got %+v", results)
+	}
+
+	stored, err := loadOutlook(db, "NVDA")
+	if err != nil {
+		t.Fatalf("loadOutlook failed: %v", err)
+	}
+	if stored == nil || stored.Rating != OutlookPositive || len(stored.Evidence) != len(outlook.Evidence) {
+		t.Errorf("Expected the stored evidence back, got %+v", stored)
+	}
+	if got, _ := loadOutlook(db, "AAPL"); got != nil {
+		t.Errorf("Expected no evidence for an unclassified ticker, got %+v", got)
+	}
+}
@@ -0,0 +1,182 @@
+package screener
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// peerMetrics are the fundamentals compared against each stock's sector and
+// industry peers
+var peerMetrics = []struct {
+	Name  string
+	Label string
+	Unit  Unit
+	// Positive excludes values at or below zero, e.g. PEs of loss makers
+	Positive bool
+}{
+	{Name: "pe_ratio", Label: "P/E", Unit: UnitRatio, Positive: true},
+	{Name: "roe", Label: "ROE", Unit: UnitPercent},
+	{Name: "dividend_yield", Label: "Dividend yield", Unit: UnitPercent},
+	{Name: "margin_of_safety", Label: "Margin of safety", Unit: UnitPercent},
+}
+
+// peerGroups are the fundamentals columns peers are grouped by
+var peerGroups = []string{"sector", "industry"}
+
+// peerFields registers the relative metrics stored by UpdatePeerMetrics, e.g.
+// pe_ratio_sector_pct, as screener fields
+func peerFields() []Field {
+	var result []Field
+	for _, m := range peerMetrics {
+		for _, group := range peerGroups {
+			stat := func(column string) string {
+				return fmt.Sprintf("(SELECT pm.%s FROM peer_metrics pm WHERE pm.ticker = f.ticker AND pm.metric = '%s' AND pm.peer_group = '%s')",
+					column, m.Name, group)
+			}
+			prefix := m.Name + "_" + group
+			result = append(result,
+				Field{Name: prefix + "_median", Label: fmt.Sprintf("%s %s median", m.Label, group),
+					Description: fmt.Sprintf("Median %s of the stock's %s", m.Label, group),
+					Table:       "peer_metrics", Expr: stat("median"), Type: TypeNumber, Unit: m.Unit, Operators: numberOperators},
+				Field{Name: prefix + "_z", Label: fmt.Sprintf("%s %s z-score", m.Label, group),
+					Description: fmt.Sprintf("Standard deviations of %s above the %s mean", m.Label, group),
+					Table:       "peer_metrics", Expr: stat("zscore"), Type: TypeNumber, Operators: numberOperators},
+				Field{Name: prefix + "_pct", Label: fmt.Sprintf("%s %s percentile", m.Label, group),
+					Description: fmt.Sprintf("Share of %s peers with a lower %s, from 0 to 1", group, m.Label),
+					Table:       "peer_metrics", Expr: stat("pct_rank"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+			)
+		}
+	}
+	return result
+}
+
+// PeerStats describes one value relative to its peer group. ZScore and
+// PctRank are nil for groups too small or uniform to rank within.
+type PeerStats struct {
+	Median  float64
+	ZScore  *float64
+	PctRank *float64
+	Peers   int
+}
+
+// ComputePeerStats ranks each value against the others in values: the
+// median, the z-score using the population standard deviation, and the
+// percent rank, the share of the other values that are strictly lower
+func ComputePeerStats(values []float64) []PeerStats {
+	n := len(values)
+	if n == 0 {
+		return nil
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(n)
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(variance / float64(n))
+
+	stats := make([]PeerStats, n)
+	for i, v := range values {
+		stats[i] = PeerStats{Median: median, Peers: n}
+		if sd > 0 {
+			z := (v - mean) / sd
+			stats[i].ZScore = &z
+		}
+		if n > 1 {
+			lower := sort.SearchFloat64s(sorted, v)
+			pct := float64(lower) / float64(n-1)
+			stats[i].PctRank = &pct
+		}
+	}
+	return stats
+}
+
+// UpdatePeerMetrics recomputes peer_metrics from fundamentals. It is an
+// UpdateHook, to run once a nightly update has refreshed all tickers; stocks
+// without a sector or industry, or without the metric, get no row for that
+// group.
+func UpdatePeerMetrics(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM peer_metrics`); err != nil {
+		return fmt.Errorf("clearing peer metrics: %w", err)
+	}
+
+	insert, err := tx.Prepare(`
+		INSERT INTO peer_metrics (ticker, metric, peer_group, median, zscore, pct_rank, peers)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, m := range peerMetrics {
+		for _, group := range peerGroups {
+			condition := m.Name + " IS NOT NULL"
+			if m.Positive {
+				condition = m.Name + " > 0"
+			}
+			rows, err := tx.Query(fmt.Sprintf(`
+				SELECT ticker, %[1]s, %[2]s FROM fundamentals
+				WHERE %[2]s IS NOT NULL AND %[2]s != '' AND %[3]s`, m.Name, group, condition))
+			if err != nil {
+				return fmt.Errorf("reading %s by %s: %w", m.Name, group, err)
+			}
+
+			tickers := map[string][]string{}
+			values := map[string][]float64{}
+			for rows.Next() {
+				var ticker, peerGroup string
+				var value float64
+				if err := rows.Scan(&ticker, &value, &peerGroup); err != nil {
+					rows.Close()
+					return err
+				}
+				tickers[peerGroup] = append(tickers[peerGroup], ticker)
+				values[peerGroup] = append(values[peerGroup], value)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for peerGroup, groupValues := range values {
+				for i, s := range ComputePeerStats(groupValues) {
+					if _, err := insert.Exec(tickers[peerGroup][i], m.Name, group, s.Median, s.ZScore, s.PctRank, s.Peers); err != nil {
+						return fmt.Errorf("saving peer metrics: %w", err)
+					}
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveClassification stores a stock's sector and industry, e.g. from EODHD's
+// General fundamentals, leaving its other fundamentals untouched
+func SaveClassification(db *sql.DB, ticker, sector, industry string) error {
+	_, err := db.Exec(`
+		INSERT INTO fundamentals (ticker, sector, industry) VALUES (?, ?, ?)
+		ON CONFLICT(ticker) DO UPDATE SET sector = excluded.sector, industry = excluded.industry`,
+		ticker, sector, industry,
+	)
+	return err
+}
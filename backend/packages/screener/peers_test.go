@@ -0,0 +1,93 @@
+package screener
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestComputePeerStats(t *testing.T) {
+	stats := ComputePeerStats([]float64{30, 10, 40, 20})
+	if stats[0].Median != 25 || stats[0].Peers != 4 {
+		t.Errorf("Expected median 25 of 4 peers, got %+v", stats[0])
+	}
+	wantPct := []float64{2.0 / 3, 0, 1, 1.0 / 3}
+	for i, s := range stats {
+		if s.PctRank == nil || math.Abs(*s.PctRank-wantPct[i]) > 1e-9 {
+			t.Errorf("value %d: expected percent rank %v, got %v", i, wantPct[i], s.PctRank)
+		}
+	}
+	if z := *stats[1].ZScore; math.Abs(z-(-15/math.Sqrt(125))) > 1e-9 {
+		t.Errorf("Expected z-score %v, got %v", -15/math.Sqrt(125), z)
+	}
+
+	ties := ComputePeerStats([]float64{5, 9, 5})
+	if *ties[0].PctRank != 0 || *ties[2].PctRank != 0 || *ties[1].PctRank != 1 {
+		t.Errorf("Expected tied values to share the lowest rank, got %v %v %v", *ties[0].PctRank, *ties[1].PctRank, *ties[2].PctRank)
+	}
+
+	single := ComputePeerStats([]float64{7})
+	if single[0].Median != 7 || single[0].ZScore != nil || single[0].PctRank != nil {
+		t.Errorf("Expected only a median for a single peer, got %+v", single[0])
+	}
+}
+
+func TestUpdatePeerMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	classifications := [][3]string{
+		{"AAPL", "Technology", "Consumer Electronics"},
+		{"MSFT", "Technology", "Software - Infrastructure"},
+		{"IBM", "Technology", "Information Technology Services"},
+		{"JNJ", "Healthcare", "Drug Manufacturers - General"},
+		{"PFE", "Healthcare", "Drug Manufacturers - General"},
+		{"KO", "Consumer Defensive", "Beverages - Non-Alcoholic"},
+	}
+	for _, c := range classifications {
+		if err := SaveClassification(db, c[0], c[1], c[2]); err != nil {
+			t.Fatalf("SaveClassification failed: %v", err)
+		}
+	}
+
+	// Running twice must replace rather than duplicate the stored metrics
+	for range 2 {
+		if err := UpdatePeerMetrics(db); err != nil {
+			t.Fatalf("UpdatePeerMetrics failed: %v", err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM peer_metrics WHERE metric = 'pe_ratio' AND peer_group = 'sector'`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count peer metrics: %v", err)
+	}
+	if count != len(classifications) {
+		t.Errorf("Expected %d sector PE rows, got %d", len(classifications), count)
+	}
+
+	// The cheaper half of each sector by PE; KO has no peers to rank against
+	filter, err := ParseFilterFromJSON(`[["pe_ratio_sector_pct","<=",0.5]]`)
+	if err != nil {
+		t.Fatalf("ParseFilterFromJSON failed: %v", err)
+	}
+	filter.Sort = "ticker.asc"
+	filter.Fields = []string{"ticker", "sector", "roe_sector_median", "pe_ratio_industry_pct"}
+
+	rows, err := ScreenRows(db, filter)
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	var tickers []string
+	for _, row := range rows {
+		tickers = append(tickers, row["ticker"].(string))
+	}
+	if !slices.Equal(tickers, []string{"IBM", "MSFT", "PFE"}) {
+		t.Errorf("Expected IBM, MSFT and PFE, got %v", tickers)
+	}
+	if rows[0]["sector"] != "Technology" || rows[0]["roe_sector_median"] != 0.22 {
+		t.Errorf("Unexpected IBM peer metrics: %v", rows[0])
+	}
+	if rows[0]["pe_ratio_industry_pct"] != nil {
+		t.Errorf("Expected no industry rank for IBM's single-member industry, got %v", rows[0]["pe_ratio_industry_pct"])
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/finsights-ai/backend/packages/corporate"
 	"github.com/finsights-ai/backend/packages/eodhd"
+	"github.com/finsights-ai/backend/packages/macro"
 )
 
 func ProcessTicker(db *sql.DB, client *eodhd.Client, ticker string) error {
@@ -18,17 +20,28 @@ func ProcessTicker(db *sql.DB, client *eodhd.Client, ticker string) error {
 		return fmt.Errorf("not enough EOD data: %v", err)
 	}
 
-	// 2. Prepare for SMA calculation
+	// Prices, EPS, dividends and share counts are restated for later splits
+	splits, err := corporate.SyncSplits(db, client, ticker, time.Now())
+	if err != nil {
+		return err
+	}
+	adj := corporate.NewAdjuster(splits)
+
+	// 2. Prepare for SMA calculation. EODHD's adjusted close also accounts
+	// for dividends, so the close as traded is adjusted for splits alone.
 	eod := []EOD{}
 	for _, p := range prices {
-		eod = append(eod, EOD{Date: p.Date, Close: p.AdjustedClose})
+		eod = append(eod, EOD{Date: p.Date, Close: adj.Adjust(p.Date, p.Close), RawClose: p.Close})
 	}
 
 	sma50, _ := CalculateSMA(eod, 50)
 	sma200, _ := CalculateSMA(eod, 200)
 
 	latest := eod[0]
-	_ = SaveSMA(db, ticker, latest.Date, latest.Close, sma50, sma200)
+	_ = SaveSMA(db, ticker, latest, sma50, sma200)
+	if err := corporate.Restate(db, ticker, adj); err != nil {
+		return err
+	}
 
 	// 3. Get fundamentals
 	fund, err := client.GetFundamentalsRaw(ticker)
@@ -37,7 +50,7 @@ func ProcessTicker(db *sql.DB, client *eodhd.Client, ticker string) error {
 	}
 
 	// 4. Calculate PE and ROE
-	eps := fund.GetFloat("Earnings::History::2023-12-31::epsActual")
+	eps := adj.Adjust("2023-12-31", fund.GetFloat("Earnings::History::2023-12-31::epsActual"))
 	price := latest.Close
 	pe := price / eps
 
@@ -51,19 +64,23 @@ func ProcessTicker(db *sql.DB, client *eodhd.Client, ticker string) error {
 	roe, _ := CalculateROE(netIncome, equity)
 
 	// Calculate EPS growth rate (CAGR) from EPS 5 years ago to latest
-	epsPast := fund.GetFloat("Earnings::History::2018-12-31::epsActual")
+	epsPast := adj.Adjust("2018-12-31", fund.GetFloat("Earnings::History::2018-12-31::epsActual"))
 	growthRate := calculateCAGR(epsPast, eps, 5)
 	if growthRate == 0 {
 		growthRate = 0.05 // Fallback to 5% conservative estimate
 	}
 
-	bondYield := 4.4 // Conservative fixed value. Can be dynamic if needed
+	bondYield := bondYieldAsOf(db, latest.Date)
 
 	today := time.Now().Format("2006-01-02")
 	divs, err := client.GetDividends(ticker, "2014-01-01", today)
 	if err != nil {
 		return fmt.Errorf("error getting dividends: %v", err)
 	}
+	if err := corporate.SaveDividends(db, ticker, divs, adj); err != nil {
+		return err
+	}
+	divs = corporate.AdjustDividends(divs, adj)
 
 	divPerShareLast := sumOfDividendsForYear(divs, 2023) // TODO: years need to be dynamic
 	divPerSharePast := sumOfDividendsForYear(divs, 2018)
@@ -71,14 +88,114 @@ func ProcessTicker(db *sql.DB, client *eodhd.Client, ticker string) error {
 	divYield := CalculateDividendYield(divPerShareLast, price)
 	divGrowth := CalculateDividendCAGR(divPerSharePast, divPerShareLast, 5)
 
-	intrinsic, _ := CalculateIntrinsicValue(eps, growthRate, bondYield)
-	safetyMargin := CalculateMarginOfSafety(intrinsic, price)
+	// Market values are compared with the statements, which may be reported
+	// in another currency than the price is quoted in
+	statementCurrency := fund.GetString(fmt.Sprintf("Financials::Balance_Sheet::yearly::%s::currency_symbol", period))
+	if statementCurrency == "" {
+		statementCurrency = fund.GetString("Financials::Balance_Sheet::currency_symbol")
+	}
+	marketPrice, err := statementPrice(db, price, fund.GetString("General::CurrencyCode"), statementCurrency, latest.Date)
+	if err != nil {
+		log.Printf("Leaving out market values of %s: %v", ticker, err)
+	}
+
+	// Run every valuation model. Graham's value is also kept as the
+	// fundamentals' intrinsic value and margin of safety. Dividends are paid
+	// in the price's currency and valued in the statements'.
+	statementDividend := 0.0
+	if marketPrice > 0 {
+		statementDividend = divPerShareLast * marketPrice / price
+	}
+	years := adjustShares(FinancialYears(fund), adj)
+	valuations, err := RunValuations(db, ticker, latest.Date, ValuationInputs{
+		Price:          price,
+		MarketPrice:    marketPrice,
+		EPS:            eps,
+		GrowthRate:     growthRate,
+		BondYield:      bondYield,
+		Dividend:       statementDividend,
+		DividendGrowth: divGrowth,
+		Beta:           fund.GetFloat("Technicals::Beta"),
+		Years:          years,
+	})
+	if err != nil {
+		return err
+	}
+
+	intrinsic, safetyMargin := 0.0, 0.0
+	for _, v := range valuations {
+		if v.Model == (GrahamModel{}).Name() && v.IntrinsicValue != nil {
+			intrinsic, safetyMargin = *v.IntrinsicValue, *v.MarginOfSafety
+		}
+	}
 
 	SaveValuationMetrics(db, ticker, divYield, divGrowth, intrinsic, safetyMargin)
 
-	// 5. Save ROE and PE
-	// outlook := ExtractOutlookFromNews(ticker) // optionally
-	return SaveROE(db, ticker, roe, pe, "")
+	// 5. Save ROE and PE, and the earnings outlook from analyst estimates
+	// and news sentiment. News is optional, so failing to sync it only
+	// leaves the sentiment out.
+	sentiment, err := syncNewsSentiment(db, client, ticker, time.Now())
+	if err != nil {
+		log.Printf("Error syncing news for %s: %v", ticker, err)
+	}
+	outlook := ClassifyOutlook(withNewsSentiment(ExtractEarningsSignals(fund), sentiment))
+	if err := SaveROE(db, ticker, roe, pe, outlook.Rating); err != nil {
+		return err
+	}
+	if err := SaveOutlook(db, ticker, outlook); err != nil {
+		return err
+	}
+	if err := SaveNewsSentiment(db, ticker, sentiment); err != nil {
+		return err
+	}
+
+	// 6. Save sector and industry for peer comparisons, and the currency
+	// prices are quoted in for conversions
+	if err := SaveClassification(db, ticker, fund.GetString("General::Sector"), fund.GetString("General::Industry")); err != nil {
+		return err
+	}
+	if err := SaveCurrency(db, ticker, fund.GetString("General::CurrencyCode")); err != nil {
+		return err
+	}
+
+	// 7. Piotroski F-Score and Altman Z-Score from the latest fiscal years
+	piotroski, altman := calculateQualityScores(years, marketPrice)
+	if err := SaveQualityScores(db, ticker, piotroski, altman); err != nil {
+		return err
+	}
+
+	// 8. Financial ratios from the latest fiscal year
+	ratios := CalculateRatios(RatioInputs{
+		Price: price, EPS: eps, GrowthRate: growthRate, MarketPrice: marketPrice, Years: years,
+	})
+	if err := SaveRatios(db, ticker, period, ratios); err != nil {
+		return err
+	}
+
+	// 9. Year-over-year net income and revenue growth
+	return SaveGrowthSeries(db, ticker, IncomeGrowth(fund, "netIncome"), IncomeGrowth(fund, "totalRevenue"))
+}
+
+// fallbackBondYield is the AAA corporate yield Graham's formula was calibrated
+// on, used until a bond yield series has been synced
+const fallbackBondYield = 4.4
+
+// bondYieldAsOf returns the bond yield in percent on or before date
+func bondYieldAsOf(db *sql.DB, date string) float64 {
+	o, err := macro.ValueAsOf(db, macro.BondYieldSeries, date)
+	if err != nil {
+		log.Printf("No %s yield on %s, using %.1f%%: %v", macro.BondYieldSeries, date, fallbackBondYield, err)
+		return fallbackBondYield
+	}
+	return o.Value
+}
+
+// adjustShares restates each year's shares outstanding in today's shares
+func adjustShares(years []FinancialYear, adj corporate.Adjuster) []FinancialYear {
+	for i := range years {
+		years[i].SharesOutstanding *= adj.Factor(years[i].Period)
+	}
+	return years
 }
 
 func sumOfDividendsForYear(divs []eodhd.Dividend, year int) float64 {
@@ -0,0 +1,163 @@
+package screener
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PiotroskiComponents are the nine binary signals of the Piotroski F-Score,
+// comparing a fiscal year with the one before
+type PiotroskiComponents struct {
+	// Profitability
+	PositiveROA         bool `json:"positive_roa"`
+	PositiveCashFlow    bool `json:"positive_operating_cash_flow"`
+	HigherROA           bool `json:"higher_roa"`
+	CashFlowAboveIncome bool `json:"cash_flow_above_net_income"`
+	// Leverage, liquidity and source of funds
+	LowerLeverage      bool `json:"lower_leverage"`
+	HigherCurrentRatio bool `json:"higher_current_ratio"`
+	NoNewShares        bool `json:"no_new_shares"`
+	// Operating efficiency
+	HigherGrossMargin   bool `json:"higher_gross_margin"`
+	HigherAssetTurnover bool `json:"higher_asset_turnover"`
+}
+
+// Score counts the signals met, from 0 to 9
+func (c PiotroskiComponents) Score() int {
+	score := 0
+	for _, signal := range []bool{
+		c.PositiveROA, c.PositiveCashFlow, c.HigherROA, c.CashFlowAboveIncome,
+		c.LowerLeverage, c.HigherCurrentRatio, c.NoNewShares,
+		c.HigherGrossMargin, c.HigherAssetTurnover,
+	} {
+		if signal {
+			score++
+		}
+	}
+	return score
+}
+
+// CalculatePiotroski compares the current fiscal year with the previous one.
+// Ratios use year-end total assets.
+func CalculatePiotroski(cur, prev FinancialYear) (PiotroskiComponents, error) {
+	if cur.TotalAssets == 0 || prev.TotalAssets == 0 {
+		return PiotroskiComponents{}, errors.New("total assets are required for both years")
+	}
+
+	roa := func(y FinancialYear) float64 { return y.NetIncome / y.TotalAssets }
+	leverage := func(y FinancialYear) float64 { return y.LongTermDebt / y.TotalAssets }
+	turnover := func(y FinancialYear) float64 { return y.Revenue / y.TotalAssets }
+
+	return PiotroskiComponents{
+		PositiveROA:         cur.NetIncome > 0,
+		PositiveCashFlow:    cur.OperatingCashFlow > 0,
+		HigherROA:           roa(cur) > roa(prev),
+		CashFlowAboveIncome: cur.OperatingCashFlow > cur.NetIncome,
+		LowerLeverage:       leverage(cur) < leverage(prev),
+		HigherCurrentRatio:  ratio(cur.CurrentAssets, cur.CurrentLiabilities) > ratio(prev.CurrentAssets, prev.CurrentLiabilities),
+		NoNewShares:         cur.SharesOutstanding > 0 && cur.SharesOutstanding <= prev.SharesOutstanding,
+		HigherGrossMargin:   ratio(cur.GrossProfit, cur.Revenue) > ratio(prev.GrossProfit, prev.Revenue),
+		HigherAssetTurnover: turnover(cur) > turnover(prev),
+	}, nil
+}
+
+// ratio divides a by b, treating division by zero as 0
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// AltmanComponents are the five ratios of the original Altman Z-Score
+type AltmanComponents struct {
+	WorkingCapitalToAssets    float64 `json:"working_capital_to_assets"`
+	RetainedEarningsToAssets  float64 `json:"retained_earnings_to_assets"`
+	EBITToAssets              float64 `json:"ebit_to_assets"`
+	MarketEquityToLiabilities float64 `json:"market_equity_to_liabilities"`
+	SalesToAssets             float64 `json:"sales_to_assets"`
+}
+
+// Score weights the ratios into the Z-Score. Above 2.99 is considered safe
+// and below 1.81 distressed.
+func (c AltmanComponents) Score() float64 {
+	return 1.2*c.WorkingCapitalToAssets +
+		1.4*c.RetainedEarningsToAssets +
+		3.3*c.EBITToAssets +
+		0.6*c.MarketEquityToLiabilities +
+		1.0*c.SalesToAssets
+}
+
+// CalculateAltman computes the Z-Score ratios for a fiscal year, valuing
+// equity at the given market capitalisation
+func CalculateAltman(y FinancialYear, marketCap float64) (AltmanComponents, error) {
+	if y.TotalAssets == 0 || y.TotalLiabilities == 0 {
+		return AltmanComponents{}, errors.New("total assets and liabilities are required")
+	}
+	return AltmanComponents{
+		WorkingCapitalToAssets:    (y.CurrentAssets - y.CurrentLiabilities) / y.TotalAssets,
+		RetainedEarningsToAssets:  y.RetainedEarnings / y.TotalAssets,
+		EBITToAssets:              y.EBIT / y.TotalAssets,
+		MarketEquityToLiabilities: marketCap / y.TotalLiabilities,
+		SalesToAssets:             y.Revenue / y.TotalAssets,
+	}, nil
+}
+
+// SaveQualityScores stores the F-Score and Z-Score with their components.
+// A nil argument clears that score.
+func SaveQualityScores(db *sql.DB, ticker string, piotroski *PiotroskiComponents, altman *AltmanComponents) error {
+	var fScore, fComponents, zScore, zComponents any
+	if piotroski != nil {
+		data, err := json.Marshal(piotroski)
+		if err != nil {
+			return err
+		}
+		fScore, fComponents = piotroski.Score(), string(data)
+	}
+	if altman != nil {
+		data, err := json.Marshal(altman)
+		if err != nil {
+			return err
+		}
+		zScore, zComponents = altman.Score(), string(data)
+	}
+
+	_, err := db.Exec(`
+		UPDATE fundamentals
+		SET piotroski_f_score = ?, piotroski_components = ?,
+		    altman_z_score = ?, altman_components = ?
+		WHERE ticker = ?`,
+		fScore, fComponents, zScore, zComponents, ticker,
+	)
+	if err != nil {
+		return fmt.Errorf("saving quality scores: %w", err)
+	}
+	return nil
+}
+
+// calculateQualityScores derives both scores from the latest two fiscal
+// years. Either is nil when the statements don't support it, and the Z-Score
+// without a price in the statements' currency.
+func calculateQualityScores(years []FinancialYear, price float64) (*PiotroskiComponents, *AltmanComponents) {
+	if len(years) == 0 {
+		return nil, nil
+	}
+
+	var piotroski *PiotroskiComponents
+	if len(years) >= 2 {
+		if c, err := CalculatePiotroski(years[0], years[1]); err == nil {
+			piotroski = &c
+		}
+	}
+
+	var altman *AltmanComponents
+	if price <= 0 {
+		return piotroski, nil
+	}
+	if c, err := CalculateAltman(years[0], price*years[0].SharesOutstanding); err == nil {
+		altman = &c
+	}
+	return piotroski, altman
+}
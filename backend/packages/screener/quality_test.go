@@ -0,0 +1,137 @@
+package screener
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/eodhd"
+)
+
+func TestFinancialYears(t *testing.T) {
+	fund := eodhd.NewFundamentals(map[string]any{
+		"Financials": map[string]any{
+			"Balance_Sheet": map[string]any{"yearly": map[string]any{
+				"2022-12-31": map[string]any{"totalAssets": "1000", "commonStockSharesOutstanding": "50"},
+				"2023-12-31": map[string]any{"totalAssets": 1200.0, "totalLiab": "700.5"},
+			}},
+			"Income_Statement": map[string]any{"yearly": map[string]any{
+				"2023-12-31": map[string]any{"netIncome": "120", "totalRevenue": nil},
+			}},
+			"Cash_Flow": map[string]any{"yearly": map[string]any{
+				"2023-12-31": map[string]any{"totalCashFromOperatingActivities": "150"},
+			}},
+		},
+	})
+
+	years := FinancialYears(fund)
+	if len(years) != 2 || years[0].Period != "2023-12-31" || years[1].Period != "2022-12-31" {
+		t.Fatalf("Expected 2023 then 2022, got %+v", years)
+	}
+	cur := years[0]
+	if cur.TotalAssets != 1200 || cur.TotalLiabilities != 700.5 || cur.NetIncome != 120 || cur.OperatingCashFlow != 150 || cur.Revenue != 0 {
+		t.Errorf("Unexpected 2023 values: %+v", cur)
+	}
+	if years[1].SharesOutstanding != 50 {
+		t.Errorf("Expected 50 shares in 2022, got %v", years[1].SharesOutstanding)
+	}
+}
+
+func TestCalculatePiotroski(t *testing.T) {
+	prev := FinancialYear{
+		TotalAssets: 1000, CurrentAssets: 300, CurrentLiabilities: 200, LongTermDebt: 400,
+		SharesOutstanding: 100, Revenue: 800, GrossProfit: 320, NetIncome: 50, OperatingCashFlow: 70,
+	}
+	cur := FinancialYear{
+		TotalAssets: 1000, CurrentAssets: 330, CurrentLiabilities: 200, LongTermDebt: 350,
+		SharesOutstanding: 100, Revenue: 900, GrossProfit: 380, NetIncome: 80, OperatingCashFlow: 110,
+	}
+
+	c, err := CalculatePiotroski(cur, prev)
+	if err != nil {
+		t.Fatalf("CalculatePiotroski failed: %v", err)
+	}
+	if c.Score() != 9 {
+		t.Errorf("Expected every signal for an improving company, got %d: %+v", c.Score(), c)
+	}
+
+	// Losses funded by new shares and debt fail most signals
+	cur.NetIncome, cur.OperatingCashFlow = -20, -30
+	cur.LongTermDebt, cur.SharesOutstanding = 500, 120
+	c, _ = CalculatePiotroski(cur, prev)
+	if c.PositiveROA || c.PositiveCashFlow || c.HigherROA || c.CashFlowAboveIncome || c.LowerLeverage || c.NoNewShares {
+		t.Errorf("Expected profitability and leverage signals to fail, got %+v", c)
+	}
+	if c.Score() != 3 {
+		t.Errorf("Expected score 3, got %d", c.Score())
+	}
+
+	if _, err := CalculatePiotroski(cur, FinancialYear{}); err == nil {
+		t.Error("Expected an error without prior year assets")
+	}
+}
+
+func TestCalculateAltman(t *testing.T) {
+	year := FinancialYear{
+		TotalAssets: 1000, CurrentAssets: 400, CurrentLiabilities: 200, TotalLiabilities: 500,
+		RetainedEarnings: 300, EBIT: 150, Revenue: 1100,
+	}
+	c, err := CalculateAltman(year, 1500)
+	if err != nil {
+		t.Fatalf("CalculateAltman failed: %v", err)
+	}
+
+	// 1.2*0.2 + 1.4*0.3 + 3.3*0.15 + 0.6*3 + 1.0*1.1
+	if want := 4.055; math.Abs(c.Score()-want) > 1e-9 {
+		t.Errorf("Expected Z-Score %v, got %v", want, c.Score())
+	}
+
+	if _, err := CalculateAltman(FinancialYear{TotalAssets: 1000}, 1500); err == nil {
+		t.Error("Expected an error without total liabilities")
+	}
+}
+
+func TestScreenQualityScores(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	strong := PiotroskiComponents{PositiveROA: true, PositiveCashFlow: true, HigherROA: true, CashFlowAboveIncome: true,
+		LowerLeverage: true, HigherCurrentRatio: true, NoNewShares: true, HigherGrossMargin: true}
+	if err := SaveQualityScores(db, "AAPL", &strong, &AltmanComponents{SalesToAssets: 3.5}); err != nil {
+		t.Fatalf("SaveQualityScores failed: %v", err)
+	}
+	if err := SaveQualityScores(db, "IBM", &PiotroskiComponents{PositiveROA: true}, &AltmanComponents{SalesToAssets: 1.5}); err != nil {
+		t.Fatalf("SaveQualityScores failed: %v", err)
+	}
+
+	var components string
+	if err := db.QueryRow("SELECT piotroski_components FROM fundamentals WHERE ticker = 'AAPL'").Scan(&components); err != nil {
+		t.Fatalf("Failed to read components: %v", err)
+	}
+	if components == "" || !json.Valid([]byte(components)) {
+		t.Errorf("Expected stored JSON components, got %q", components)
+	}
+
+	filter := ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "piotroski_f_score", Operator: ">=", Value: 7.0}},
+		Fields:     []string{"ticker", "piotroski_f_score", "altman_z_score", "altman_zone"},
+		Sort:       "ticker.asc",
+		Limit:      10,
+	}
+	rows, err := ScreenRows(db, filter)
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["ticker"] != "AAPL" || rows[0]["piotroski_f_score"] != 8.0 || rows[0]["altman_zone"] != "safe" {
+		t.Errorf("Expected AAPL with score 8 in the safe zone, got %v", rows)
+	}
+
+	filter.Conditions = []FilterCondition{{Field: "altman_zone", Operator: "=", Value: "distress"}}
+	rows, err = ScreenRows(db, filter)
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["ticker"] != "IBM" {
+		t.Errorf("Expected only IBM in distress, got %v", rows)
+	}
+}
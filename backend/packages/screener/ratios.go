@@ -0,0 +1,185 @@
+package screener
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// RatioInputs are the figures financial ratios are computed from
+type RatioInputs struct {
+	Price      float64
+	EPS        float64
+	GrowthRate float64
+	// MarketPrice is Price converted into the statements' reporting
+	// currency, which market capitalisation is computed from. The ratios
+	// needing it are left out when it is zero.
+	MarketPrice float64
+	// Years are the fiscal years' statements, newest first
+	Years []FinancialYear
+}
+
+// ratioInputs is what each ratio's Compute receives. Prev is the zero value
+// without a prior year.
+type ratioInputs struct {
+	RatioInputs
+	Cur, Prev FinancialYear
+	MarketCap float64
+}
+
+// financialRatios are computed from the latest fiscal year by
+// CalculateRatios, stored by SaveRatios and exposed as screener fields
+var financialRatios = []struct {
+	Name        string
+	Label       string
+	Description string
+	Unit        Unit
+	// Compute returns false when the statements can't support the ratio
+	Compute func(in ratioInputs) (float64, bool)
+}{
+	// Valuation
+	{Name: "pb_ratio", Label: "P/B", Description: "Market capitalisation over shareholders' equity", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			if in.MarketCap <= 0 {
+				return 0, false
+			}
+			return divPositive(in.MarketCap, in.Cur.Equity)
+		}},
+	{Name: "ps_ratio", Label: "P/S", Description: "Market capitalisation over revenue", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			if in.MarketCap <= 0 {
+				return 0, false
+			}
+			return divPositive(in.MarketCap, in.Cur.Revenue)
+		}},
+	{Name: "ev_ebitda", Label: "EV/EBITDA", Description: "Enterprise value over EBITDA", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			if in.MarketCap <= 0 {
+				return 0, false
+			}
+			return divPositive(in.MarketCap+in.Cur.TotalDebt()-in.Cur.Cash, in.Cur.EBITDA())
+		}},
+	{Name: "peg_ratio", Label: "PEG", Description: "P/E over the estimated earnings growth rate in percent", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			pe, ok := divPositive(in.Price, in.EPS)
+			if !ok {
+				return 0, false
+			}
+			return divPositive(pe, in.GrowthRate*100)
+		}},
+	{Name: "fcf_yield", Label: "FCF yield", Description: "Free cash flow over market capitalisation", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.FCF(), in.MarketCap) }},
+
+	// Profitability
+	{Name: "roa", Label: "ROA", Description: "Return on assets: net income over total assets", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.NetIncome, in.Cur.TotalAssets) }},
+	{Name: "roic", Label: "ROIC", Description: "Return on invested capital: after-tax EBIT over equity plus net debt", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) {
+			nopat := in.Cur.EBIT * (1 - in.Cur.TaxRate())
+			return divPositive(nopat, in.Cur.Equity+in.Cur.TotalDebt()-in.Cur.Cash)
+		}},
+	{Name: "gross_margin", Label: "Gross margin", Description: "Gross profit over revenue", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.GrossProfit, in.Cur.Revenue) }},
+	{Name: "operating_margin", Label: "Operating margin", Description: "Operating income over revenue", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.OperatingIncome, in.Cur.Revenue) }},
+	{Name: "net_margin", Label: "Net margin", Description: "Net income over revenue", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.NetIncome, in.Cur.Revenue) }},
+
+	// Leverage and liquidity
+	{Name: "debt_to_equity", Label: "Debt/equity", Description: "Short and long-term debt over shareholders' equity", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) { return divPositive(in.Cur.TotalDebt(), in.Cur.Equity) }},
+	{Name: "net_debt_to_ebitda", Label: "Net debt/EBITDA", Description: "Debt less cash over EBITDA; negative with net cash", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			return divPositive(in.Cur.TotalDebt()-in.Cur.Cash, in.Cur.EBITDA())
+		}},
+	{Name: "current_ratio", Label: "Current ratio", Description: "Current assets over current liabilities", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			return divPositive(in.Cur.CurrentAssets, in.Cur.CurrentLiabilities)
+		}},
+	{Name: "quick_ratio", Label: "Quick ratio", Description: "Current assets less inventory over current liabilities", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			return divPositive(in.Cur.CurrentAssets-in.Cur.Inventory, in.Cur.CurrentLiabilities)
+		}},
+	{Name: "interest_coverage", Label: "Interest coverage", Description: "EBIT over interest expense", Unit: UnitRatio,
+		Compute: func(in ratioInputs) (float64, bool) {
+			return divPositive(in.Cur.EBIT, math.Abs(in.Cur.InterestExpense))
+		}},
+
+	// Shareholder returns
+	{Name: "payout_ratio", Label: "Payout ratio", Description: "Dividends paid over net income", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) {
+			return divPositive(math.Abs(in.Cur.DividendsPaid), in.Cur.NetIncome)
+		}},
+	{Name: "share_change", Label: "Share count change", Description: "Change in shares outstanding over the fiscal year; negative with buybacks", Unit: UnitPercent,
+		Compute: func(in ratioInputs) (float64, bool) {
+			change, ok := divPositive(in.Cur.SharesOutstanding, in.Prev.SharesOutstanding)
+			if !ok || in.Cur.SharesOutstanding <= 0 {
+				return 0, false
+			}
+			return change - 1, true
+		}},
+}
+
+// divPositive divides a by b, failing unless b is positive
+func divPositive(a, b float64) (float64, bool) {
+	if b <= 0 {
+		return 0, false
+	}
+	return a / b, true
+}
+
+// ratioFields exposes the stored ratios as screener fields
+func ratioFields() []Field {
+	result := make([]Field, len(financialRatios))
+	for i, r := range financialRatios {
+		result[i] = Field{Name: r.Name, Label: r.Label, Description: r.Description,
+			Table: "financial_ratios",
+			Expr:  fmt.Sprintf("(SELECT fr.value FROM financial_ratios fr WHERE fr.ticker = f.ticker AND fr.ratio = '%s')", r.Name),
+			Type:  TypeNumber, Unit: r.Unit, Operators: numberOperators}
+	}
+	return result
+}
+
+// CalculateRatios computes every ratio the latest fiscal year supports, keyed
+// by name
+func CalculateRatios(in RatioInputs) map[string]float64 {
+	result := map[string]float64{}
+	if len(in.Years) == 0 {
+		return result
+	}
+
+	ri := ratioInputs{RatioInputs: in, Cur: in.Years[0], MarketCap: in.MarketPrice * in.Years[0].SharesOutstanding}
+	if len(in.Years) > 1 {
+		ri.Prev = in.Years[1]
+	}
+	for _, r := range financialRatios {
+		if value, ok := r.Compute(ri); ok && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			result[r.Name] = value
+		}
+	}
+	return result
+}
+
+// SaveRatios replaces the ticker's stored ratios
+func SaveRatios(db *sql.DB, ticker, period string, ratios map[string]float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM financial_ratios WHERE ticker = ?", ticker); err != nil {
+		return fmt.Errorf("failed to clear ratios: %w", err)
+	}
+	for name, value := range ratios {
+		_, err := tx.Exec(`
+			INSERT INTO financial_ratios (ticker, ratio, value, period)
+			VALUES (?, ?, ?, ?)`,
+			ticker, name, value, period,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save ratio %s: %w", name, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,115 @@
+package screener
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateRatios(t *testing.T) {
+	cur := FinancialYear{
+		TotalAssets: 2000, CurrentAssets: 600, Inventory: 200, CurrentLiabilities: 400,
+		Equity: 1000, ShortTermDebt: 100, LongTermDebt: 400, Cash: 200, SharesOutstanding: 95,
+		Revenue: 1500, GrossProfit: 600, OperatingIncome: 300, EBIT: 280, Depreciation: -120,
+		InterestExpense: -40, IncomeBeforeTax: 240, IncomeTaxExpense: 60, NetIncome: 180,
+		OperatingCashFlow: 330, CapitalExpenditures: -130, DividendsPaid: -72,
+	}
+	prev := FinancialYear{SharesOutstanding: 100}
+
+	ratios := CalculateRatios(RatioInputs{Price: 20, EPS: 1.9, GrowthRate: 0.08, MarketPrice: 20, Years: []FinancialYear{cur, prev}})
+
+	// Market cap is 20 × 95 = 1900 and EBITDA 280 + 120 = 400
+	want := map[string]float64{
+		"pb_ratio":           1.9,
+		"ps_ratio":           1900.0 / 1500,
+		"ev_ebitda":          (1900.0 + 500 - 200) / 400,
+		"peg_ratio":          20 / 1.9 / 8,
+		"fcf_yield":          200.0 / 1900,
+		"roa":                0.09,
+		"roic":               280 * 0.75 / 1300,
+		"gross_margin":       0.4,
+		"operating_margin":   0.2,
+		"net_margin":         0.12,
+		"debt_to_equity":     0.5,
+		"net_debt_to_ebitda": 0.75,
+		"current_ratio":      1.5,
+		"quick_ratio":        1,
+		"interest_coverage":  7,
+		"payout_ratio":       0.4,
+		"share_change":       -0.05,
+	}
+	if len(ratios) != len(financialRatios) {
+		t.Errorf("Expected every ratio, got %v", ratios)
+	}
+	for name, w := range want {
+		if got, ok := ratios[name]; !ok || math.Abs(got-w) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", name, w, got)
+		}
+	}
+
+	// Without a prior year, equity or earnings the dependent ratios are absent
+	cur.Equity, cur.NetIncome = 0, -10
+	ratios = CalculateRatios(RatioInputs{Price: 20, EPS: -0.1, GrowthRate: 0.08, MarketPrice: 20, Years: []FinancialYear{cur}})
+	for _, name := range []string{"pb_ratio", "debt_to_equity", "peg_ratio", "payout_ratio", "share_change"} {
+		if _, ok := ratios[name]; ok {
+			t.Errorf("Expected no %s, got %v", name, ratios[name])
+		}
+	}
+	if ratios["roa"] >= 0 || ratios["net_margin"] >= 0 {
+		t.Errorf("Expected negative returns and margins, got %v", ratios)
+	}
+
+	// Without a price in the statements' currency the market value ratios
+	// are absent
+	ratios = CalculateRatios(RatioInputs{Price: 20, EPS: 1.9, GrowthRate: 0.08, Years: []FinancialYear{cur}})
+	for _, name := range []string{"pb_ratio", "ps_ratio", "ev_ebitda", "fcf_yield"} {
+		if _, ok := ratios[name]; ok {
+			t.Errorf("Expected no %s, got %v", name, ratios[name])
+		}
+	}
+	if _, ok := ratios["peg_ratio"]; !ok {
+		t.Errorf("Expected the PEG ratio from the quoted price, got %v", ratios)
+	}
+
+	if ratios := CalculateRatios(RatioInputs{Price: 20}); len(ratios) != 0 {
+		t.Errorf("Expected no ratios without statements, got %v", ratios)
+	}
+}
+
+func TestScreenRatios(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	saved := map[string]map[string]float64{
+		"KO":   {"roa": 0.09, "current_ratio": 1.1, "payout_ratio": 0.7},
+		"MSFT": {"roa": 0.15, "current_ratio": 1.8},
+		"TSLA": {"roa": 0.05, "current_ratio": 1.7},
+	}
+	for ticker, ratios := range saved {
+		if err := SaveRatios(db, ticker, "2023-12-31", ratios); err != nil {
+			t.Fatalf("SaveRatios failed: %v", err)
+		}
+	}
+	// Saving again replaces every ratio
+	if err := SaveRatios(db, "KO", "2024-12-31", map[string]float64{"roa": 0.1, "current_ratio": 1.2}); err != nil {
+		t.Fatalf("SaveRatios failed: %v", err)
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{
+			{Field: "roa", Operator: ">=", Value: 0.08},
+			{Field: "current_ratio", Operator: "BETWEEN", Value: [2]float64{1, 2}},
+		},
+		Fields: []string{"ticker", "roa", "payout_ratio"},
+		Sort:   "roa.desc",
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["ticker"] != "MSFT" || rows[1]["ticker"] != "KO" {
+		t.Fatalf("Expected MSFT then KO, got %v", rows)
+	}
+	if rows[1]["roa"] != 0.1 || rows[1]["payout_ratio"] != nil {
+		t.Errorf("Expected KO's replaced ratios, got %v", rows[1])
+	}
+}
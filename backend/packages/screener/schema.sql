@@ -10,23 +10,279 @@ CREATE TABLE IF NOT EXISTS fundamentals (
 	dividend_yield REAL,
 	dividend_growth_5y REAL,
 	intrinsic_value REAL,
-	margin_of_safety REAL
+	margin_of_safety REAL,
+	sector TEXT,
+	industry TEXT,
+	piotroski_f_score INTEGER,
+	piotroski_components JSON,
+	altman_z_score REAL,
+	altman_components JSON,
+	earnings_outlook_evidence JSON,
+	news_sentiment_30d REAL,
+	news_articles_30d INTEGER,
+	currency TEXT
 );
 
+-- close is adjusted for later splits and raw_close as traded
 CREATE TABLE IF NOT EXISTS prices (
 	ticker TEXT,
 	date TEXT,
 	close REAL,
 	sma50 REAL,
 	sma200 REAL,
+	raw_close REAL,
 	PRIMARY KEY (ticker, date)
 );
 
+-- Each stock's metrics relative to its sector and industry peers, refreshed
+-- from fundamentals by screener.UpdatePeerMetrics
+CREATE TABLE IF NOT EXISTS peer_metrics (
+	ticker TEXT NOT NULL,
+	metric TEXT NOT NULL,
+	peer_group TEXT NOT NULL CHECK (peer_group IN ('sector', 'industry')),
+	median REAL NOT NULL,
+	zscore REAL,
+	pct_rank REAL,
+	peers INTEGER NOT NULL,
+	PRIMARY KEY (ticker, metric, peer_group)
+);
+
+-- Composite 0-100 scores per ticker and scoring model, refreshed by scoring.Update
+CREATE TABLE IF NOT EXISTS scores (
+	ticker TEXT NOT NULL,
+	model TEXT NOT NULL,
+	score REAL NOT NULL,
+	factors JSON NOT NULL DEFAULT '{}',
+	updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (ticker, model)
+);
+
+-- Financial ratios computed from each ticker's latest fiscal year by
+-- screener.SaveRatios. Ratios the statements can't support are absent.
+CREATE TABLE IF NOT EXISTS financial_ratios (
+	ticker TEXT NOT NULL,
+	ratio TEXT NOT NULL,
+	value REAL NOT NULL,
+	period TEXT,
+	PRIMARY KEY (ticker, ratio)
+);
+
+-- Intrinsic values per ticker, valuation model and price date, written during
+-- the update by screener.RunValuations. Models that can't value a ticker
+-- store the error instead of a value.
+CREATE TABLE IF NOT EXISTS valuations (
+	ticker TEXT NOT NULL,
+	model TEXT NOT NULL,
+	as_of TEXT NOT NULL,
+	price REAL,
+	intrinsic_value REAL,
+	margin_of_safety REAL,
+	inputs JSON,
+	error TEXT,
+	PRIMARY KEY (ticker, model, as_of)
+);
+
+-- Daily macroeconomic series such as bond yields, synced by macro.Sync
+CREATE TABLE IF NOT EXISTS macro_series (
+	series TEXT NOT NULL,
+	date TEXT NOT NULL,
+	value REAL NOT NULL,
+	PRIMARY KEY (series, date)
+);
+
+-- Splits and dividends per ticker, synced by corporate.SyncSplits and
+-- corporate.SaveDividends. A split's value is the new shares per old share;
+-- a dividend's value is as paid and adjusted_value restated for later splits.
+CREATE TABLE IF NOT EXISTS corporate_actions (
+	ticker TEXT NOT NULL,
+	date TEXT NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('split', 'dividend')),
+	value REAL NOT NULL,
+	adjusted_value REAL,
+	currency TEXT,
+	PRIMARY KEY (ticker, date, kind)
+);
+
+-- US dollars per unit of each currency by day, from EODHD forex closes,
+-- synced by fx.Sync
+CREATE TABLE IF NOT EXISTS fx_rates (
+	currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	rate REAL NOT NULL,
+	PRIMARY KEY (currency, date)
+);
+
+-- News articles per ticker with their sentiment from -1 to 1, synced by
+-- news.Sync
+CREATE TABLE IF NOT EXISTS news (
+	ticker TEXT NOT NULL,
+	link TEXT NOT NULL,
+	published_at TEXT NOT NULL,
+	title TEXT NOT NULL,
+	tags JSON NOT NULL DEFAULT '[]',
+	sentiment REAL,
+	scorer TEXT NOT NULL,
+	PRIMARY KEY (ticker, link)
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	email TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	plan TEXT NOT NULL DEFAULT 'free',
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Login sessions; only the SHA-256 of the bearer token is stored
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	expires_at TEXT NOT NULL,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+-- API keys for scripted access; only the SHA-256 of the key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	last_used_at TEXT,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS screens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	filters JSON NOT NULL DEFAULT '',
+	sort TEXT NOT NULL DEFAULT '',
+	columns JSON NOT NULL DEFAULT '[]',
+	version INTEGER NOT NULL DEFAULT 1,
+	share_token TEXT UNIQUE,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS screen_versions (
+	screen_id INTEGER NOT NULL,
+	version INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	filters JSON NOT NULL DEFAULT '',
+	sort TEXT NOT NULL DEFAULT '',
+	columns JSON NOT NULL DEFAULT '[]',
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (screen_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS watchlists (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS watchlist_items (
+	watchlist_id INTEGER NOT NULL,
+	ticker TEXT NOT NULL,
+	notes TEXT NOT NULL DEFAULT '',
+	target_price REAL,
+	added_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (watchlist_id, ticker)
+);
+
+CREATE TABLE IF NOT EXISTS portfolios (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_transactions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	portfolio_id INTEGER NOT NULL,
+	ticker TEXT NOT NULL,
+	type TEXT NOT NULL CHECK (type IN ('buy', 'sell', 'dividend')),
+	date TEXT NOT NULL,
+	quantity REAL NOT NULL DEFAULT 0,
+	price REAL NOT NULL DEFAULT 0,
+	fees REAL NOT NULL DEFAULT 0,
+	amount REAL NOT NULL DEFAULT 0,
+	notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS alerts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('metric', 'screen')),
+	ticker TEXT NOT NULL DEFAULT '',
+	field TEXT NOT NULL DEFAULT '',
+	operator TEXT NOT NULL DEFAULT '',
+	value JSON NOT NULL DEFAULT 'null',
+	screen_id INTEGER NOT NULL DEFAULT 0,
+	channel TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	active INTEGER NOT NULL DEFAULT 1,
+	triggered INTEGER NOT NULL DEFAULT 0,
+	last_fired_at TEXT,
+	last_evaluated_at TEXT,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tickers matching a screen alert at its last evaluation
+CREATE TABLE IF NOT EXISTS alert_matches (
+	alert_id INTEGER NOT NULL,
+	ticker TEXT NOT NULL,
+	PRIMARY KEY (alert_id, ticker)
+);
+
+CREATE TABLE IF NOT EXISTS alert_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	alert_id INTEGER NOT NULL,
+	fired_at TEXT NOT NULL,
+	message TEXT NOT NULL,
+	tickers JSON NOT NULL DEFAULT '[]',
+	delivered INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	alert_id INTEGER NOT NULL,
+	subject TEXT NOT NULL,
+	message TEXT NOT NULL,
+	tickers JSON NOT NULL DEFAULT '[]',
+	read INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_fundamentals_pe_ratio ON fundamentals(pe_ratio);
 CREATE INDEX IF NOT EXISTS idx_fundamentals_roe ON fundamentals(roe);
 CREATE INDEX IF NOT EXISTS idx_fundamentals_dividend_yield ON fundamentals(dividend_yield);
 CREATE INDEX IF NOT EXISTS idx_fundamentals_margin_of_safety ON fundamentals(margin_of_safety);
 CREATE INDEX IF NOT EXISTS idx_fundamentals_earnings_outlook ON fundamentals(earnings_outlook);
+CREATE INDEX IF NOT EXISTS idx_fundamentals_sector ON fundamentals(sector);
+CREATE INDEX IF NOT EXISTS idx_fundamentals_industry ON fundamentals(industry);
+CREATE INDEX IF NOT EXISTS idx_scores_model_score ON scores(model, score);
+CREATE INDEX IF NOT EXISTS idx_valuations_model ON valuations(model, as_of);
+CREATE INDEX IF NOT EXISTS idx_news_ticker_published ON news(ticker, published_at);
 CREATE INDEX IF NOT EXISTS idx_prices_ticker_date ON prices(ticker, date);
 CREATE INDEX IF NOT EXISTS idx_prices_close ON prices(close);
+CREATE INDEX IF NOT EXISTS idx_portfolio_transactions_portfolio ON portfolio_transactions(portfolio_id, date);
+CREATE INDEX IF NOT EXISTS idx_alert_events_alert ON alert_events(alert_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_screens_user ON screens(user_id);
+CREATE INDEX IF NOT EXISTS idx_watchlists_user ON watchlists(user_id);
+CREATE INDEX IF NOT EXISTS idx_portfolios_user ON portfolios(user_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_user ON alerts(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id);
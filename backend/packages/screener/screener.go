@@ -4,8 +4,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"slices"
 	"strings"
+
+	"github.com/finsights-ai/backend/packages/fx"
 )
 
 // ScreenerResult represents a stock result from screening
@@ -23,6 +24,41 @@ type ScreenerResult struct {
 	MarginOfSafety   float64 `json:"margin_of_safety"`
 }
 
+// ResultColumns lists the JSON names of ScreenerResult's fields in output order
+var ResultColumns = []string{
+	"ticker", "pe_ratio", "roe", "close", "sma50", "sma200", "earnings_outlook",
+	"dividend_yield", "dividend_growth_5y", "intrinsic_value", "margin_of_safety",
+}
+
+// Value returns the field with the given JSON name, or nil for unknown columns
+func (r ScreenerResult) Value(column string) any {
+	switch column {
+	case "ticker":
+		return r.Ticker
+	case "pe_ratio":
+		return r.PE
+	case "roe":
+		return r.ROE
+	case "close":
+		return r.Close
+	case "sma50":
+		return r.SMA50
+	case "sma200":
+		return r.SMA200
+	case "earnings_outlook":
+		return r.EarningsOutlook
+	case "dividend_yield":
+		return r.DividendYield
+	case "dividend_growth_5y":
+		return r.DividendGrowth5Y
+	case "intrinsic_value":
+		return r.IntrinsicValue
+	case "margin_of_safety":
+		return r.MarginOfSafety
+	}
+	return nil
+}
+
 // FilterCondition represents a single filter condition
 type FilterCondition struct {
 	Field    string `json:"field"`
@@ -36,6 +72,13 @@ type ScreenerFilter struct {
 	Sort       string            `json:"sort"`
 	Limit      int               `json:"limit"`
 	Offset     int               `json:"offset"`
+	// Fields selects the columns returned by ScreenRows and StreamRows
+	Fields []string `json:"fields,omitempty"`
+	// Currency converts prices, moving averages and intrinsic values in
+	// results, conditions and sorting into this currency, such as EUR. They
+	// are NULL for securities whose currency has no exchange rate. Empty
+	// leaves every amount in its security's own currency.
+	Currency string `json:"currency,omitempty"`
 }
 
 // FilterBuilder provides an idiomatic way to build filters
@@ -143,7 +186,7 @@ func (fb *FilterBuilder) TickerIn(tickers []string) *FilterBuilder {
 func (fb *FilterBuilder) Build() ScreenerFilter {
 	return ScreenerFilter{
 		Conditions: fb.conditions,
-		Sort:       "pe_ratio ASC", // Default sort
+		Sort:       "pe_ratio.asc", // Default sort
 		Limit:      50,             // Default limit
 		Offset:     0,              // Default offset
 	}
@@ -159,12 +202,14 @@ func (fb *FilterBuilder) BuildWithPagination(sort string, limit, offset int) Scr
 	}
 }
 
-// ParseFilterFromJSON parses a JSON filter string (compatible with EODHD format)
+// ParseFilterFromJSON parses a JSON filter string (compatible with EODHD format).
+// Each condition is checked against the field registry; invalid ones are
+// reported as a *FilterError.
 func ParseFilterFromJSON(filterJSON string) (ScreenerFilter, error) {
 	if filterJSON == "" {
 		return ScreenerFilter{
 			Conditions: []FilterCondition{},
-			Sort:       "pe_ratio ASC",
+			Sort:       "pe_ratio.asc",
 			Limit:      50,
 			Offset:     0,
 		}, nil
@@ -176,114 +221,150 @@ func ParseFilterFromJSON(filterJSON string) (ScreenerFilter, error) {
 	}
 
 	conditions := make([]FilterCondition, 0, len(rawConditions))
-	for _, raw := range rawConditions {
+	for i, raw := range rawConditions {
 		if len(raw) != 3 {
-			return ScreenerFilter{}, fmt.Errorf("invalid condition format: expected [field, operator, value]")
+			return ScreenerFilter{}, &FilterError{Index: i, Message: "invalid condition format: expected [field, operator, value]"}
 		}
 
 		field, ok := raw[0].(string)
 		if !ok {
-			return ScreenerFilter{}, fmt.Errorf("field must be a string")
+			return ScreenerFilter{}, &FilterError{Index: i, Message: "field must be a string"}
 		}
 
 		operator, ok := raw[1].(string)
 		if !ok {
-			return ScreenerFilter{}, fmt.Errorf("operator must be a string")
+			return ScreenerFilter{}, &FilterError{Index: i, Field: field, Message: "operator must be a string"}
 		}
 
 		// Map EODHD-style field names to our schema
-		field = mapFieldName(field)
+		if f, ok := lookupFilterField(field); ok {
+			field = f.Name
+		}
 
-		conditions = append(conditions, FilterCondition{
-			Field:    field,
-			Operator: operator,
-			Value:    raw[2],
-		})
+		condition := FilterCondition{Field: field, Operator: operator, Value: raw[2]}
+		value, err := checkCondition(condition)
+		if err != nil {
+			return ScreenerFilter{}, conditionError(i, condition, err)
+		}
+		condition.Value = value
+
+		conditions = append(conditions, condition)
 	}
 
 	return ScreenerFilter{
 		Conditions: conditions,
-		Sort:       "pe_ratio ASC",
+		Sort:       "pe_ratio.asc",
 		Limit:      50,
 		Offset:     0,
 	}, nil
 }
 
-// mapFieldName maps external field names to internal database column names
-func mapFieldName(field string) string {
-	mapping := map[string]string{
-		"market_capitalization": "market_cap",
-		"dividend_yield":        "dividend_yield",
-		"earnings_share":        "eps",
-		"sector":                "sector",
-		"industry":              "industry",
-		"exchange":              "exchange",
-		"refund_5d_p":           "return_5d",
-		"avgvol_200d":           "avg_volume_200d",
-		"type":                  "asset_type",
+// ScreenStocks performs stock screening based on the provided filter
+func ScreenStocks(db *sql.DB, filter ScreenerFilter) ([]ScreenerResult, error) {
+	var results []ScreenerResult
+	err := StreamStocks(db, filter, func(result ScreenerResult) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return results, nil
+}
+
+// StreamStocks runs the filter and calls fn for each matching row as it is
+// read, so large result sets need not be held in memory. Iteration stops at
+// the first error returned by fn. filter.Fields is ignored; every
+// ScreenerResult field is read and missing metrics are zero.
+func StreamStocks(db *sql.DB, filter ScreenerFilter, fn func(ScreenerResult) error) error {
+	filter.Fields = ResultColumns
+	return StreamRows(db, filter, func(row Row) error {
+		return fn(resultFromRow(row))
+	})
+}
 
-	if mapped, exists := mapping[field]; exists {
-		return mapped
+// ScreenRows screens like ScreenStocks but returns only filter.Fields, which
+// may include computed fields, as maps
+func ScreenRows(db *sql.DB, filter ScreenerFilter) ([]Row, error) {
+	rows := []Row{}
+	err := StreamRows(db, filter, func(row Row) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return field
+	return rows, nil
 }
 
-// ScreenStocks performs stock screening based on the provided filter
-func ScreenStocks(db *sql.DB, filter ScreenerFilter) ([]ScreenerResult, error) {
-	query, args := buildQuery(filter)
+// StreamRows is the streaming form of ScreenRows. Without filter.Fields every
+// ScreenerResult field is returned.
+func StreamRows(db *sql.DB, filter ScreenerFilter, fn func(Row) error) error {
+	names := filter.Fields
+	if len(names) == 0 {
+		names = ResultColumns
+	}
+	selected, err := ParseFields(names)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := buildQuery(filter, selected)
+	if err != nil {
+		return err
+	}
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query execution failed: %w", err)
+		return fmt.Errorf("query execution failed: %w", err)
 	}
 	defer rows.Close()
 
-	var results []ScreenerResult
 	for rows.Next() {
-		var result ScreenerResult
-		err := rows.Scan(
-			&result.Ticker,
-			&result.PE,
-			&result.ROE,
-			&result.Close,
-			&result.SMA50,
-			&result.SMA200,
-			&result.EarningsOutlook,
-			&result.DividendYield,
-			&result.DividendGrowth5Y,
-			&result.IntrinsicValue,
-			&result.MarginOfSafety,
-		)
+		row, err := scanRow(rows, selected)
 		if err != nil {
-			return nil, fmt.Errorf("row scanning failed: %w", err)
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
 		}
-		results = append(results, result)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration failed: %w", err)
+		return fmt.Errorf("row iteration failed: %w", err)
 	}
 
-	return results, nil
+	return nil
 }
 
-// buildQuery constructs the SQL query based on the filter conditions
-func buildQuery(filter ScreenerFilter) (string, []any) {
-	baseQuery := `
-		SELECT
-			f.ticker,
-			COALESCE(f.pe_ratio, 0) as pe_ratio,
-			COALESCE(f.roe, 0) as roe,
-			COALESCE(p.close, 0) as close,
-			COALESCE(p.sma50, 0) as sma50,
-			COALESCE(p.sma200, 0) as sma200,
-			COALESCE(f.earnings_outlook, '') as earnings_outlook,
-			COALESCE(f.dividend_yield, 0) as dividend_yield,
-			COALESCE(f.dividend_growth_5y, 0) as dividend_growth_5y,
-			COALESCE(f.intrinsic_value, 0) as intrinsic_value,
-			COALESCE(f.margin_of_safety, 0) as margin_of_safety
-		FROM fundamentals f
+// resultFromRow converts a row holding the ResultColumns fields
+func resultFromRow(row Row) ScreenerResult {
+	str := func(name string) string {
+		s, _ := row[name].(string)
+		return s
+	}
+	num := func(name string) float64 {
+		f, _ := row[name].(float64)
+		return f
+	}
+
+	return ScreenerResult{
+		Ticker:           str("ticker"),
+		PE:               num("pe_ratio"),
+		ROE:              num("roe"),
+		Close:            num("close"),
+		SMA50:            num("sma50"),
+		SMA200:           num("sma200"),
+		EarningsOutlook:  str("earnings_outlook"),
+		DividendYield:    num("dividend_yield"),
+		DividendGrowth5Y: num("dividend_growth_5y"),
+		IntrinsicValue:   num("intrinsic_value"),
+		MarginOfSafety:   num("margin_of_safety"),
+	}
+}
+
+// fromClause joins fundamentals (f) to each ticker's latest prices (p)
+const fromClause = `FROM fundamentals f
 		LEFT JOIN (
 			SELECT ticker, close, sma50, sma200
 			FROM prices p1
@@ -291,25 +372,38 @@ func buildQuery(filter ScreenerFilter) (string, []any) {
 		) p ON f.ticker = p.ticker
 	`
 
+// buildQuery constructs the SQL query selecting the given fields, in order,
+// based on the filter conditions
+func buildQuery(filter ScreenerFilter, selected []Field) (string, []any, error) {
+	currency := filter.Currency
+	if currency != "" {
+		var err error
+		if currency, err = fx.Normalize(currency); err != nil {
+			return "", nil, err
+		}
+	}
+
+	exprs := make([]string, len(selected))
+	for i, f := range selected {
+		exprs[i] = f.inCurrency(currency).Expr
+	}
+
+	baseQuery := `
+		SELECT
+			` + strings.Join(exprs, ",\n\t\t\t") + `
+		` + fromClause
+
 	var whereConditions []string
 	var args []any
 
 	// Build WHERE clause from filter conditions
-	for _, condition := range filter.Conditions {
-		sqlCondition, value := buildSQLCondition(condition)
-		if sqlCondition != "" {
-			whereConditions = append(whereConditions, sqlCondition)
-			if value != nil {
-				// Handle array values for IN operator
-				if arr, ok := value.([]string); ok {
-					for _, v := range arr {
-						args = append(args, v)
-					}
-				} else {
-					args = append(args, value)
-				}
-			}
+	for i, condition := range filter.Conditions {
+		sqlCondition, conditionArgs, err := buildSQLCondition(condition, currency)
+		if err != nil {
+			return "", nil, conditionError(i, condition, err)
 		}
+		whereConditions = append(whereConditions, sqlCondition)
+		args = append(args, conditionArgs...)
 	}
 
 	// Add WHERE clause if there are conditions
@@ -319,7 +413,11 @@ func buildQuery(filter ScreenerFilter) (string, []any) {
 
 	// Add ORDER BY clause
 	if filter.Sort != "" {
-		baseQuery += " ORDER BY " + sanitizeSort(filter.Sort)
+		orderBy, err := parseSort(filter.Sort, currency)
+		if err != nil {
+			return "", nil, err
+		}
+		baseQuery += " ORDER BY " + orderBy
 	}
 
 	// Add LIMIT and OFFSET
@@ -330,106 +428,104 @@ func buildQuery(filter ScreenerFilter) (string, []any) {
 		baseQuery += fmt.Sprintf(" OFFSET %d", filter.Offset)
 	}
 
-	return baseQuery, args
+	return baseQuery, args, nil
 }
 
-// buildSQLCondition converts a FilterCondition to SQL
-func buildSQLCondition(condition FilterCondition) (string, any) {
-	field := condition.Field
-	operator := condition.Operator
-	value := condition.Value
-
-	// Handle special computed fields
-	switch field {
-	case "price_vs_sma50":
-		if operator == "<" && value == 1.0 {
-			return "p.close < p.sma50", nil
-		} else if operator == ">" && value == 1.0 {
-			return "p.close > p.sma50", nil
+// buildSQLCondition converts a FilterCondition to SQL over the field's
+// registered expression, in currency if one is given, and the arguments for
+// its placeholders
+func buildSQLCondition(condition FilterCondition, currency string) (string, []any, error) {
+	value, err := checkCondition(condition)
+	if err != nil {
+		return "", nil, err
+	}
+	field, _ := lookupFilterField(condition.Field)
+	expr := field.inCurrency(currency).Expr
+
+	switch operator := condition.Operator; operator {
+	case "IN", "NOT IN":
+		list := value.([]string)
+		args := make([]any, len(list))
+		for i, v := range list {
+			args[i] = v
 		}
-	case "price_vs_sma200":
-		if operator == "<" && value == 1.0 {
-			return "p.close < p.sma200", nil
-		} else if operator == ">" && value == 1.0 {
-			return "p.close > p.sma200", nil
+		placeholders := strings.Repeat("?,", len(list)-1) + "?"
+		return fmt.Sprintf("%s %s (%s)", expr, operator, placeholders), args, nil
+	case "BETWEEN":
+		bounds := value.([2]float64)
+		return fmt.Sprintf("%s BETWEEN ? AND ?", expr), []any{bounds[0], bounds[1]}, nil
+	case "IS NULL", "IS NOT NULL":
+		return fmt.Sprintf("%s %s", expr, operator), nil, nil
+	case "LIKE", "NOT LIKE":
+		// SQLite's LIKE ignores case, so case-sensitive matches use GLOB
+		glob := "GLOB"
+		if operator == "NOT LIKE" {
+			glob = "NOT GLOB"
 		}
-	case "intrinsic_vs_price":
-		if operator == ">" && value == 1.0 {
-			return "f.intrinsic_value > p.close", nil
+		return fmt.Sprintf("%s %s ?", expr, glob), []any{likeToGlob(value.(string))}, nil
+	case "ILIKE":
+		return fmt.Sprintf("%s LIKE ?", expr), []any{value}, nil
+	case "top_pct", "bottom_pct":
+		direction := "DESC"
+		if operator == "bottom_pct" {
+			direction = "ASC"
 		}
+		return percentileCondition(expr, direction), []any{value}, nil
 	}
-
-	// Handle IN operator for arrays
-	if operator == "IN" {
-		if arr, ok := value.([]string); ok {
-			placeholders := strings.Repeat("?,", len(arr)-1) + "?"
-			if isFieldInFundamentals(field) {
-				return fmt.Sprintf("f.%s IN (%s)", field, placeholders), value
-			}
-			return fmt.Sprintf("%s IN (%s)", field, placeholders), value
+	return fmt.Sprintf("%s %s ?", expr, condition.Operator), []any{value}, nil
+}
+
+// likeToGlob translates a LIKE pattern into a GLOB pattern matching the same
+// strings: % and _ become * and ?, and GLOB's own wildcards match literally
+func likeToGlob(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteByte('*')
+		case '_':
+			b.WriteByte('?')
+		case '*', '?', '[':
+			b.WriteString("[" + string(r) + "]")
+		default:
+			b.WriteRune(r)
 		}
 	}
-
-	// Map field to table alias
-	if isFieldInFundamentals(field) {
-		field = "f." + field
-	} else if isFieldInPrices(field) {
-		field = "p." + field
-	} else {
-		// For unknown fields, assume fundamentals table
-		field = "f." + field
-	}
-
-	// Standard operators
-	switch operator {
-	case "=", ">", "<", ">=", "<=", "!=":
-		return fmt.Sprintf("%s %s ?", field, operator), value
-	case "LIKE":
-		return fmt.Sprintf("%s LIKE ?", field), value
-	}
-
-	return "", nil
-}
-
-// isFieldInFundamentals checks if a field belongs to the fundamentals table
-func isFieldInFundamentals(field string) bool {
-	fundamentalsFields := []string{
-		"ticker", "pe_ratio", "roe", "earnings_outlook",
-		"dividend_yield", "dividend_growth_5y", "intrinsic_value", "margin_of_safety",
-	}
-	return slices.Contains(fundamentalsFields, field)
-}
-
-// isFieldInPrices checks if a field belongs to the prices table
-func isFieldInPrices(field string) bool {
-	pricesFields := []string{"close", "sma50", "sma200"}
-	return slices.Contains(pricesFields, field)
-}
-
-// sanitizeSort ensures the sort parameter is safe for SQL
-func sanitizeSort(sort string) string {
-	// Allow only known fields and directions
-	validSorts := map[string]string{
-		"pe_ratio.asc":          "f.pe_ratio ASC",
-		"pe_ratio.desc":         "f.pe_ratio DESC",
-		"roe.asc":               "f.roe ASC",
-		"roe.desc":              "f.roe DESC",
-		"close.asc":             "p.close ASC",
-		"close.desc":            "p.close DESC",
-		"dividend_yield.asc":    "f.dividend_yield ASC",
-		"dividend_yield.desc":   "f.dividend_yield DESC",
-		"margin_of_safety.asc":  "f.margin_of_safety ASC",
-		"margin_of_safety.desc": "f.margin_of_safety DESC",
-		"ticker.asc":            "f.ticker ASC",
-		"ticker.desc":           "f.ticker DESC",
-	}
-
-	if sanitized, exists := validSorts[sort]; exists {
-		return sanitized
+	return b.String()
+}
+
+// percentileCondition matches tickers ranked within the given percentage of
+// all tickers that have a value for expr, ordered by direction. Ties at the
+// cutoff are all included, and at least one ticker always qualifies.
+func percentileCondition(expr, direction string) string {
+	return fmt.Sprintf(`f.ticker IN (
+			SELECT ticker FROM (
+				SELECT f.ticker, RANK() OVER (ORDER BY %[1]s %[2]s) AS rnk, COUNT(*) OVER () AS n
+				%[3]s
+				WHERE %[1]s IS NOT NULL
+			)
+			WHERE (rnk - 1) * 100.0 < n * ?
+		)`, expr, direction, fromClause)
+}
+
+// FilterOperators lists the operators accepted in filter conditions; each
+// field allows a subset of them
+var FilterOperators = []string{
+	"=", "!=", ">", "<", ">=", "<=", "BETWEEN",
+	"LIKE", "NOT LIKE", "ILIKE", "IN", "NOT IN",
+	"IS NULL", "IS NOT NULL", "top_pct", "bottom_pct",
+}
+
+// FilterFields lists the names and EODHD aliases accepted in filter conditions
+func FilterFields() []string {
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+		if f.Alias != "" {
+			names = append(names, f.Alias)
+		}
 	}
-
-	// Default sort
-	return "f.pe_ratio ASC"
+	return names
 }
 
 // Common filter presets for easy usage
@@ -2,6 +2,8 @@ package screener
 
 import (
 	"database/sql"
+	"errors"
+	"slices"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -26,7 +28,66 @@ func setupTestDB(t *testing.T) *sql.DB {
 			dividend_yield REAL,
 			dividend_growth_5y REAL,
 			intrinsic_value REAL,
-			margin_of_safety REAL
+			margin_of_safety REAL,
+			sector TEXT,
+			industry TEXT,
+			piotroski_f_score INTEGER,
+			piotroski_components JSON,
+			altman_z_score REAL,
+			altman_components JSON,
+			earnings_outlook_evidence JSON,
+			news_sentiment_30d REAL,
+			news_articles_30d INTEGER,
+			currency TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS fx_rates (
+			currency TEXT NOT NULL,
+			date TEXT NOT NULL,
+			rate REAL NOT NULL,
+			PRIMARY KEY (currency, date)
+		);
+
+		CREATE TABLE IF NOT EXISTS news (
+			ticker TEXT NOT NULL,
+			link TEXT NOT NULL,
+			published_at TEXT NOT NULL,
+			title TEXT NOT NULL,
+			tags JSON NOT NULL DEFAULT '[]',
+			sentiment REAL,
+			scorer TEXT NOT NULL,
+			PRIMARY KEY (ticker, link)
+		);
+
+		CREATE TABLE IF NOT EXISTS financial_ratios (
+			ticker TEXT NOT NULL,
+			ratio TEXT NOT NULL,
+			value REAL NOT NULL,
+			period TEXT,
+			PRIMARY KEY (ticker, ratio)
+		);
+
+		CREATE TABLE IF NOT EXISTS valuations (
+			ticker TEXT NOT NULL,
+			model TEXT NOT NULL,
+			as_of TEXT NOT NULL,
+			price REAL,
+			intrinsic_value REAL,
+			margin_of_safety REAL,
+			inputs JSON,
+			error TEXT,
+			PRIMARY KEY (ticker, model, as_of)
+		);
+
+		CREATE TABLE IF NOT EXISTS peer_metrics (
+			ticker TEXT NOT NULL,
+			metric TEXT NOT NULL,
+			peer_group TEXT NOT NULL,
+			median REAL NOT NULL,
+			zscore REAL,
+			pct_rank REAL,
+			peers INTEGER NOT NULL,
+			PRIMARY KEY (ticker, metric, peer_group)
 		);
 
 		CREATE TABLE IF NOT EXISTS prices (
@@ -118,7 +179,7 @@ func TestScreenStocks(t *testing.T) {
 				Offset: 0,
 			},
 			expectedCount: 5,
-			expectedFirst: "KO",
+			expectedFirst: "AAPL",
 		},
 		{
 			name: "multiple conditions",
@@ -158,7 +219,7 @@ func TestScreenStocks(t *testing.T) {
 				Offset: 0,
 			},
 			expectedCount: 6,
-			expectedFirst: "PFE",
+			expectedFirst: "AAPL",
 		},
 		{
 			name: "margin of safety filter",
@@ -171,7 +232,7 @@ func TestScreenStocks(t *testing.T) {
 				Offset: 0,
 			},
 			expectedCount: 6,
-			expectedFirst: "PFE",
+			expectedFirst: "IBM",
 		},
 		{
 			name: "price below SMA50",
@@ -184,7 +245,7 @@ func TestScreenStocks(t *testing.T) {
 				Offset: 0,
 			},
 			expectedCount: 5,
-			expectedFirst: "PFE",
+			expectedFirst: "IBM",
 		},
 		{
 			name: "pagination test",
@@ -195,7 +256,7 @@ func TestScreenStocks(t *testing.T) {
 				Offset:     2,
 			},
 			expectedCount: 2,
-			expectedFirst: "KO",
+			expectedFirst: "IBM",
 		},
 	}
 
@@ -354,10 +415,35 @@ func TestParseFilterFromJSON(t *testing.T) {
 		},
 		{
 			name:           "field mapping",
-			filterJSON:     `[["market_capitalization",">",1000000]]`,
+			filterJSON:     `[["code","IN",["AAPL","KO"]]]`,
 			expectedLength: 1,
 			expectError:    false,
 		},
+		{
+			name:        "unknown field",
+			filterJSON:  `[["market_capitalization",">",1000000]]`,
+			expectError: true,
+		},
+		{
+			name:        "unknown operator",
+			filterJSON:  `[["pe_ratio","<>",20]]`,
+			expectError: true,
+		},
+		{
+			name:        "operator not allowed for field",
+			filterJSON:  `[["roe","LIKE","0.1%"]]`,
+			expectError: true,
+		},
+		{
+			name:        "string value for numeric field",
+			filterJSON:  `[["pe_ratio","<","20"]]`,
+			expectError: true,
+		},
+		{
+			name:        "empty IN list",
+			filterJSON:  `[["ticker","IN",[]]]`,
+			expectError: true,
+		},
 		{
 			name:        "invalid JSON",
 			filterJSON:  `invalid json`,
@@ -403,25 +489,25 @@ func TestParseFilterFromJSON(t *testing.T) {
 	}
 }
 
-func TestMapFieldName(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"market_capitalization", "market_cap"},
-		{"dividend_yield", "dividend_yield"},
-		{"earnings_share", "eps"},
-		{"pe_ratio", "pe_ratio"},           // no mapping
-		{"unknown_field", "unknown_field"}, // no mapping
+func TestParseFilterFromJSONReportsIndex(t *testing.T) {
+	_, err := ParseFilterFromJSON(`[["pe_ratio","<",20],["ticker","IN",["AAPL",1]]]`)
+
+	var filterErr *FilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("Expected a FilterError, got %v", err)
+	}
+	if filterErr.Index != 1 || filterErr.Field != "ticker" || filterErr.Operator != "IN" {
+		t.Errorf("Expected the second condition to be reported, got %+v", filterErr)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			result := mapFieldName(tt.input)
-			if result != tt.expected {
-				t.Errorf("mapFieldName(%s) = %s, want %s", tt.input, result, tt.expected)
-			}
-		})
+func TestParseFilterFromJSONMapsAliases(t *testing.T) {
+	filter, err := ParseFilterFromJSON(`[["code","=","AAPL"],["dividend_yield",">",0.02]]`)
+	if err != nil {
+		t.Fatalf("ParseFilterFromJSON failed: %v", err)
+	}
+	if filter.Conditions[0].Field != "ticker" || filter.Conditions[1].Field != "dividend_yield" {
+		t.Errorf("Expected code to map to ticker, got %+v", filter.Conditions)
 	}
 }
 
@@ -468,124 +554,298 @@ func TestPresetFilters(t *testing.T) {
 
 func TestBuildSQLCondition(t *testing.T) {
 	tests := []struct {
-		name             string
-		condition        FilterCondition
-		expectedSQL      string
-		expectedHasValue bool
+		name         string
+		condition    FilterCondition
+		expectedSQL  string
+		expectedArgs int
+		expectError  bool
 	}{
 		{
-			name:             "price below SMA50",
-			condition:        FilterCondition{Field: "price_vs_sma50", Operator: "<", Value: 1.0},
-			expectedSQL:      "p.close < p.sma50",
-			expectedHasValue: false,
+			name:         "price below SMA50",
+			condition:    FilterCondition{Field: "price_vs_sma50", Operator: "<", Value: 1.0},
+			expectedSQL:  "p.close / NULLIF(p.sma50, 0) < ?",
+			expectedArgs: 1,
+		},
+		{
+			name:         "intrinsic value greater than price",
+			condition:    FilterCondition{Field: "intrinsic_vs_price", Operator: ">", Value: 1.0},
+			expectedSQL:  "f.intrinsic_value / NULLIF(p.close, 0) > ?",
+			expectedArgs: 1,
 		},
 		{
-			name:             "price above SMA200",
-			condition:        FilterCondition{Field: "price_vs_sma200", Operator: ">", Value: 1.0},
-			expectedSQL:      "p.close > p.sma200",
-			expectedHasValue: false,
+			name:         "standard fundamentals field",
+			condition:    FilterCondition{Field: "pe_ratio", Operator: "<", Value: 20.0},
+			expectedSQL:  "f.pe_ratio < ?",
+			expectedArgs: 1,
 		},
 		{
-			name:             "intrinsic value greater than price",
-			condition:        FilterCondition{Field: "intrinsic_vs_price", Operator: ">", Value: 1.0},
-			expectedSQL:      "f.intrinsic_value > p.close",
-			expectedHasValue: false,
+			name:         "standard prices field",
+			condition:    FilterCondition{Field: "close", Operator: ">", Value: 100.0},
+			expectedSQL:  "p.close > ?",
+			expectedArgs: 1,
 		},
 		{
-			name:             "standard fundamentals field",
-			condition:        FilterCondition{Field: "pe_ratio", Operator: "<", Value: 20.0},
-			expectedSQL:      "f.pe_ratio < ?",
-			expectedHasValue: true,
+			name:         "EODHD alias",
+			condition:    FilterCondition{Field: "code", Operator: "LIKE", Value: "A%"},
+			expectedSQL:  "f.ticker GLOB ?",
+			expectedArgs: 1,
 		},
 		{
-			name:             "standard prices field",
-			condition:        FilterCondition{Field: "close", Operator: ">", Value: 100.0},
-			expectedSQL:      "p.close > ?",
-			expectedHasValue: true,
+			name:         "IN list",
+			condition:    FilterCondition{Field: "ticker", Operator: "IN", Value: []string{"AAPL", "KO"}},
+			expectedSQL:  "f.ticker IN (?,?)",
+			expectedArgs: 2,
+		},
+		{
+			name:         "NOT IN list decoded from JSON",
+			condition:    FilterCondition{Field: "ticker", Operator: "NOT IN", Value: []any{"AAPL", "KO", "PFE"}},
+			expectedSQL:  "f.ticker NOT IN (?,?,?)",
+			expectedArgs: 3,
+		},
+		{
+			name:         "BETWEEN",
+			condition:    FilterCondition{Field: "pe_ratio", Operator: "BETWEEN", Value: []any{10.0, 20.0}},
+			expectedSQL:  "f.pe_ratio BETWEEN ? AND ?",
+			expectedArgs: 2,
+		},
+		{
+			name:         "IS NULL",
+			condition:    FilterCondition{Field: "intrinsic_value", Operator: "IS NULL"},
+			expectedSQL:  "f.intrinsic_value IS NULL",
+			expectedArgs: 0,
+		},
+		{
+			name:         "NOT LIKE",
+			condition:    FilterCondition{Field: "ticker", Operator: "NOT LIKE", Value: "%.L"},
+			expectedSQL:  "f.ticker NOT GLOB ?",
+			expectedArgs: 1,
+		},
+		{
+			name:         "case-insensitive match",
+			condition:    FilterCondition{Field: "earnings_outlook", Operator: "ILIKE", Value: "Positive"},
+			expectedSQL:  "f.earnings_outlook LIKE ?",
+			expectedArgs: 1,
+		},
+		{
+			name:        "unknown field",
+			condition:   FilterCondition{Field: "1=1) OR (1", Operator: "=", Value: 1.0},
+			expectError: true,
+		},
+		{
+			name:        "operator not allowed for field",
+			condition:   FilterCondition{Field: "pe_ratio", Operator: "LIKE", Value: "1%"},
+			expectError: true,
+		},
+		{
+			name:        "empty IN list",
+			condition:   FilterCondition{Field: "ticker", Operator: "IN", Value: []any{}},
+			expectError: true,
+		},
+		{
+			name:        "BETWEEN bounds reversed",
+			condition:   FilterCondition{Field: "roe", Operator: "BETWEEN", Value: []any{0.3, 0.1}},
+			expectError: true,
+		},
+		{
+			name:        "IS NULL with a value",
+			condition:   FilterCondition{Field: "roe", Operator: "IS NULL", Value: 0.0},
+			expectError: true,
+		},
+		{
+			name:        "percentile out of range",
+			condition:   FilterCondition{Field: "roe", Operator: "top_pct", Value: 150.0},
+			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sqlCondition, value := buildSQLCondition(tt.condition)
+			sqlCondition, args, err := buildSQLCondition(tt.condition, "")
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got SQL '%s'", sqlCondition)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildSQLCondition failed: %v", err)
+			}
 
 			if sqlCondition != tt.expectedSQL {
 				t.Errorf("Expected SQL '%s', got '%s'", tt.expectedSQL, sqlCondition)
 			}
+			if len(args) != tt.expectedArgs {
+				t.Errorf("Expected %d args, got %d", tt.expectedArgs, len(args))
+			}
+		})
+	}
+}
+
+func TestScreenStocksExtendedOperators(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
 
-			hasValue := value != nil
-			if hasValue != tt.expectedHasValue {
-				t.Errorf("Expected hasValue %v, got %v", tt.expectedHasValue, hasValue)
+	// A ticker with a PE but no other metrics
+	if _, err := db.Exec(`INSERT INTO fundamentals (ticker, pe_ratio) VALUES ('NEW', 5.0)`); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filters  string
+		expected []string
+	}{
+		{"IN from JSON", `[["ticker","IN",["KO","AAPL"]]]`, []string{"AAPL", "KO"}},
+		{"NOT IN", `[["ticker","NOT IN",["AAPL","GOOGL","IBM","JNJ","KO","NEW"]]]`, []string{"MSFT", "PFE", "TSLA"}},
+		{"BETWEEN", `[["pe_ratio","BETWEEN",[9,13]]]`, []string{"JNJ", "KO", "MSFT"}},
+		{"IS NULL", `[["roe","IS NULL",null]]`, []string{"NEW"}},
+		{"IS NOT NULL", `[["roe","IS NOT NULL",null],["pe_ratio",">",13]]`, []string{"AAPL", "GOOGL", "TSLA"}},
+		{"NOT LIKE", `[["ticker","NOT LIKE","%L%"],["ticker","NOT LIKE","%A%"]]`, []string{"IBM", "JNJ", "KO", "MSFT", "NEW", "PFE"}},
+		{"LIKE is case-sensitive", `[["earnings_outlook","LIKE","NEG%"]]`, nil},
+		{"LIKE", `[["earnings_outlook","LIKE","neg%"]]`, []string{"IBM"}},
+		{"ILIKE", `[["earnings_outlook","ILIKE","NEG%"]]`, []string{"IBM"}},
+		{"top_pct", `[["roe","top_pct",25]]`, []string{"AAPL", "MSFT"}},
+		// GOOGL and JNJ tie at the cutoff; the percentile ignores other conditions
+		{"top_pct with ties", `[["roe","top_pct",50],["earnings_outlook","=","positive"]]`, []string{"AAPL", "GOOGL", "JNJ", "MSFT"}},
+		{"bottom_pct", `[["pe_ratio","bottom_pct",10]]`, []string{"NEW"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilterFromJSON(tt.filters)
+			if err != nil {
+				t.Fatalf("ParseFilterFromJSON failed: %v", err)
+			}
+			filter.Sort = "ticker.asc"
+
+			results, err := ScreenStocks(db, filter)
+			if err != nil {
+				t.Fatalf("ScreenStocks failed: %v", err)
+			}
+			tickers := []string{}
+			for _, r := range results {
+				tickers = append(tickers, r.Ticker)
+			}
+			if !slices.Equal(tickers, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tickers)
 			}
 		})
 	}
 }
 
-func TestSanitizeSort(t *testing.T) {
+func TestLikeToGlob(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"A%":      "A*",
+		"_BC.L":   "?BC.L",
+		"5*[x]?%": "5[*][[]x][?]*",
+	} {
+		if got := likeToGlob(pattern); got != want {
+			t.Errorf("likeToGlob(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
+		wantErr  bool
 	}{
-		{"pe_ratio.asc", "f.pe_ratio ASC"},
-		{"pe_ratio.desc", "f.pe_ratio DESC"},
-		{"roe.asc", "f.roe ASC"},
-		{"close.desc", "p.close DESC"},
-		{"invalid_sort", "f.pe_ratio ASC"}, // default
-		{"", "f.pe_ratio ASC"},             // default
+		{input: "pe_ratio.asc", expected: "f.pe_ratio ASC NULLS LAST, f.ticker ASC"},
+		{input: "roe.desc", expected: "f.roe DESC NULLS LAST, f.ticker ASC"},
+		{input: "close.desc", expected: "p.close DESC NULLS LAST, f.ticker ASC"},
+		{input: "roe DESC", expected: "f.roe DESC NULLS LAST, f.ticker ASC"},
+		{input: "roe", expected: "f.roe ASC NULLS LAST, f.ticker ASC"},
+		{input: "ticker.desc", expected: "f.ticker DESC NULLS LAST"},
+		{
+			input:    "earnings_outlook.asc, upside.desc",
+			expected: "f.earnings_outlook ASC NULLS LAST, f.intrinsic_value / NULLIF(p.close, 0) - 1 DESC NULLS LAST, f.ticker ASC",
+		},
+		{input: "invalid_sort", wantErr: true},
+		{input: "roe.sideways", wantErr: true},
+		{input: "roe.desc,", wantErr: true},
+		{input: "f.roe; DROP TABLE fundamentals", wantErr: true},
+		{input: "", wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			result := sanitizeSort(tt.input)
+			result, err := ParseSort(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSort(%q) = %q, want error", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort(%q) failed: %v", tt.input, err)
+			}
 			if result != tt.expected {
-				t.Errorf("sanitizeSort(%s) = %s, want %s", tt.input, result, tt.expected)
+				t.Errorf("ParseSort(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestIsFieldInTables(t *testing.T) {
-	fundamentalsTests := []struct {
-		field    string
-		expected bool
-	}{
-		{"ticker", true},
-		{"pe_ratio", true},
-		{"roe", true},
-		{"dividend_yield", true},
-		{"close", false},
-		{"sma50", false},
-		{"unknown", false},
-	}
-
-	for _, tt := range fundamentalsTests {
-		t.Run("fundamentals_"+tt.field, func(t *testing.T) {
-			result := isFieldInFundamentals(tt.field)
-			if result != tt.expected {
-				t.Errorf("isFieldInFundamentals(%s) = %v, want %v", tt.field, result, tt.expected)
-			}
-		})
+func TestScreenStocksSortNullsLast(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO fundamentals (ticker, pe_ratio) VALUES ('NEW', 5.0)`); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
 	}
 
-	pricesTests := []struct {
-		field    string
-		expected bool
-	}{
-		{"close", true},
-		{"sma50", true},
-		{"sma200", true},
-		{"ticker", false},
-		{"pe_ratio", false},
-		{"unknown", false},
+	for _, sort := range []string{"roe.asc", "roe.desc"} {
+		results, err := ScreenStocks(db, ScreenerFilter{Sort: sort})
+		if err != nil {
+			t.Fatalf("ScreenStocks failed: %v", err)
+		}
+		if last := results[len(results)-1].Ticker; last != "NEW" {
+			t.Errorf("sort %s: expected NEW without ROE last, got %s", sort, last)
+		}
 	}
 
-	for _, tt := range pricesTests {
-		t.Run("prices_"+tt.field, func(t *testing.T) {
-			result := isFieldInPrices(tt.field)
-			if result != tt.expected {
-				t.Errorf("isFieldInPrices(%s) = %v, want %v", tt.field, result, tt.expected)
+	results, err := ScreenStocks(db, ScreenerFilter{Sort: "dividend_yield.desc,pe_ratio.asc"})
+	if err != nil {
+		t.Fatalf("ScreenStocks failed: %v", err)
+	}
+	// IBM and KO share a 4.5% yield and are ordered by PE
+	got := []string{results[1].Ticker, results[2].Ticker}
+	if got[0] != "IBM" || got[1] != "KO" {
+		t.Errorf("Expected IBM, KO after PFE, got %v", got)
+	}
+}
+
+func TestScreenStocksUnknownSort(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := ScreenStocks(db, ScreenerFilter{Sort: "volume.desc"}); err == nil {
+		t.Error("Expected error for unknown sort field")
+	}
+}
+
+func TestFieldRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, f := range Fields() {
+		if seen[f.Name] {
+			t.Errorf("duplicate field %s", f.Name)
+		}
+		seen[f.Name] = true
+
+		if f.Label == "" || f.Description == "" || len(f.Operators) == 0 {
+			t.Errorf("field %s is missing metadata: %+v", f.Name, f)
+		}
+		for _, op := range f.Operators {
+			if !slices.Contains(FilterOperators, op) {
+				t.Errorf("field %s allows unknown operator %s", f.Name, op)
 			}
-		})
+		}
+
+		prefix, isColumn := map[string]string{"fundamentals": "f.", "prices": "p."}[f.Table]
+		if isColumn && f.Expr != prefix+f.Name {
+			t.Errorf("stored field %s has expression %s", f.Name, f.Expr)
+		}
 	}
 }
 
@@ -0,0 +1,59 @@
+package screener
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortKeys lists the accepted sort keys, "field.asc" and "field.desc" for
+// every named field
+func SortKeys() []string {
+	keys := make([]string, 0, 2*len(fields))
+	for _, f := range fields {
+		keys = append(keys, f.Name+".asc", f.Name+".desc")
+	}
+	return keys
+}
+
+// ParseSort converts a comma-separated list of sort keys such as
+// "dividend_yield.desc,pe_ratio.asc" into an ORDER BY clause. "roe DESC" is accepted as
+// well, and the direction defaults to ascending. Missing metrics sort last in
+// either direction, and the ticker breaks remaining ties so pages are stable.
+func ParseSort(sort string) (string, error) {
+	return parseSort(sort, "")
+}
+
+// parseSort is ParseSort with currency amounts converted into currency
+func parseSort(sort, currency string) (string, error) {
+	var terms []string
+	byTicker := false
+
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", fmt.Errorf("empty sort key in %q", sort)
+		}
+
+		name, direction := key, "asc"
+		if i := strings.LastIndexAny(key, ". "); i >= 0 {
+			name, direction = strings.TrimSpace(key[:i]), strings.ToLower(key[i+1:])
+		}
+
+		f, ok := LookupField(name)
+		if !ok {
+			return "", fmt.Errorf("unknown sort field %q", name)
+		}
+		f = f.inCurrency(currency)
+		if direction != "asc" && direction != "desc" {
+			return "", fmt.Errorf("invalid sort direction %q for %s: use asc or desc", direction, name)
+		}
+
+		terms = append(terms, fmt.Sprintf("%s %s NULLS LAST", f.Expr, strings.ToUpper(direction)))
+		byTicker = byTicker || f.Name == "ticker"
+	}
+
+	if !byTicker {
+		terms = append(terms, "f.ticker ASC")
+	}
+	return strings.Join(terms, ", "), nil
+}
@@ -0,0 +1,68 @@
+package screener
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/finsights-ai/backend/packages/news"
+)
+
+// ErrTickerNotFound is returned for tickers without fundamentals
+var ErrTickerNotFound = errors.New("ticker not found")
+
+// StockDetail is everything known about one ticker: the value of every
+// screener field, each valuation model's latest value with its inputs, the
+// net income and revenue growth history, the earnings outlook's evidence and
+// the latest news headlines
+type StockDetail struct {
+	Ticker     string           `json:"ticker"`
+	Fields     Row              `json:"fields"`
+	Valuations []Valuation      `json:"valuations"`
+	YoYProfit  *GrowthSeries    `json:"yoy_profit"`
+	YoYRevenue *GrowthSeries    `json:"yoy_turnover"`
+	Outlook    *EarningsOutlook `json:"earnings_outlook"`
+	Headlines  []news.Article   `json:"headlines"`
+}
+
+// GetStock returns the ticker's detail
+func GetStock(db *sql.DB, ticker string) (StockDetail, error) {
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "ticker", Operator: "=", Value: ticker}},
+		Fields:     FieldNames(),
+		Sort:       "ticker.asc",
+		Limit:      1,
+	})
+	if err != nil {
+		return StockDetail{}, err
+	}
+	if len(rows) == 0 {
+		return StockDetail{}, ErrTickerNotFound
+	}
+
+	valuations, err := LatestValuations(db, ticker)
+	if err != nil {
+		return StockDetail{}, err
+	}
+	profit, turnover, err := loadGrowthSeries(db, ticker)
+	if err != nil {
+		return StockDetail{}, err
+	}
+	outlook, err := loadOutlook(db, ticker)
+	if err != nil {
+		return StockDetail{}, err
+	}
+	headlines, err := news.Recent(db, ticker, stockHeadlines)
+	if err != nil {
+		return StockDetail{}, err
+	}
+
+	return StockDetail{
+		Ticker:     ticker,
+		Fields:     rows[0],
+		Valuations: valuations,
+		YoYProfit:  profit,
+		YoYRevenue: turnover,
+		Outlook:    outlook,
+		Headlines:  headlines,
+	}, nil
+}
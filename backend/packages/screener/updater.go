@@ -2,10 +2,13 @@ package screener
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/finsights-ai/backend/packages/eodhd"
+	"github.com/finsights-ai/backend/packages/fx"
+	"github.com/finsights-ai/backend/packages/macro"
 )
 
 func ShouldUpdateNow(now time.Time) bool {
@@ -13,7 +16,10 @@ func ShouldUpdateNow(now time.Time) bool {
 	return weekday != time.Saturday && weekday != time.Sunday
 }
 
-func RunNightlyUpdate(db *sql.DB, client *eodhd.Client, tickers []string) {
+// UpdateHook runs after a nightly update has processed all tickers
+type UpdateHook func(db *sql.DB) error
+
+func RunNightlyUpdate(db *sql.DB, client *eodhd.Client, tickers []string, hooks ...UpdateHook) {
 	now := time.Now()
 	weekday := now.Weekday()
 
@@ -24,6 +30,11 @@ func RunNightlyUpdate(db *sql.DB, client *eodhd.Client, tickers []string) {
 
 	log.Println("Starting nightly update...")
 
+	// Valuations use the bond yield as of each ticker's price date
+	if _, err := macro.Sync(db, client, macro.BondYieldSeries, now); err != nil {
+		log.Printf("Error syncing bond yields: %v\n", err)
+	}
+
 	for _, ticker := range tickers {
 		log.Printf("Updating: %s\n", ticker)
 
@@ -34,5 +45,62 @@ func RunNightlyUpdate(db *sql.DB, client *eodhd.Client, tickers []string) {
 		}
 	}
 
+	// After the tickers, so that currencies first seen tonight are included
+	if err := fx.SyncAll(db, client, now); err != nil {
+		log.Printf("Error syncing exchange rates: %v\n", err)
+	}
+
+	for _, hook := range hooks {
+		if err := hook(db); err != nil {
+			log.Printf("Error running post-update hook: %v\n", err)
+		}
+	}
+
 	log.Println("Nightly update complete.")
 }
+
+// NextUpdate returns the first time after now at hour:minute in now's location
+func NextUpdate(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ScheduleNightlyUpdate runs RunNightlyUpdate every day at hour:minute local
+// time, blocking forever. tickers is called before each run so that tickers
+// added since the last run are included.
+func ScheduleNightlyUpdate(db *sql.DB, client *eodhd.Client, tickers func() ([]string, error), hour, minute int, hooks ...UpdateHook) {
+	for {
+		next := NextUpdate(time.Now(), hour, minute)
+		log.Printf("Next nightly update at %s", next.Format(time.RFC3339))
+		time.Sleep(time.Until(next))
+
+		list, err := tickers()
+		if err != nil {
+			log.Printf("Error listing tickers for the nightly update: %v\n", err)
+			continue
+		}
+		RunNightlyUpdate(db, client, list, hooks...)
+	}
+}
+
+// StoredTickers lists every ticker with stored fundamentals
+func StoredTickers(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT ticker FROM fundamentals ORDER BY ticker")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tickers: %w", err)
+	}
+	defer rows.Close()
+
+	tickers := []string{}
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, ticker)
+	}
+	return tickers, rows.Err()
+}
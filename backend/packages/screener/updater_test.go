@@ -0,0 +1,36 @@
+package screener
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextUpdate(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	tests := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2024, 1, 15, 9, 0, 0, 0, loc), time.Date(2024, 1, 15, 22, 30, 0, 0, loc)},
+		// At or after the time of day, the update runs tomorrow
+		{time.Date(2024, 1, 15, 22, 30, 0, 0, loc), time.Date(2024, 1, 16, 22, 30, 0, 0, loc)},
+		{time.Date(2024, 1, 31, 23, 0, 0, 0, loc), time.Date(2024, 2, 1, 22, 30, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := NextUpdate(tt.now, 22, 30); !got.Equal(tt.want) {
+			t.Errorf("NextUpdate(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestStoredTickers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	tickers, err := StoredTickers(db)
+	if err != nil {
+		t.Fatalf("StoredTickers failed: %v", err)
+	}
+	if len(tickers) != 8 || tickers[0] != "AAPL" || tickers[7] != "TSLA" {
+		t.Errorf("Expected the eight test tickers in order, got %v", tickers)
+	}
+}
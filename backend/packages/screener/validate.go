@@ -0,0 +1,158 @@
+package screener
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FilterError reports an invalid filter condition and its position in the
+// filter, so clients can point at the condition to fix
+type FilterError struct {
+	Index    int    `json:"index"`
+	Field    string `json:"field,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("condition %d: %s", e.Index, e.Message)
+}
+
+// Validate checks every condition against the field registry, returning a
+// *FilterError for the first invalid one
+func (f ScreenerFilter) Validate() error {
+	for i, c := range f.Conditions {
+		if _, err := checkCondition(c); err != nil {
+			return conditionError(i, c, err)
+		}
+	}
+	return nil
+}
+
+func conditionError(index int, c FilterCondition, err error) *FilterError {
+	return &FilterError{Index: index, Field: c.Field, Operator: c.Operator, Message: err.Error()}
+}
+
+// checkCondition resolves the condition's field, checks the operator is
+// allowed for it and returns the value converted to the type the query
+// expects: []string for IN and NOT IN, [2]float64 for BETWEEN, nil for the
+// null checks, and otherwise float64 or string by field type. top_pct and
+// bottom_pct take a percentage between 0 and 100.
+func checkCondition(c FilterCondition) (any, error) {
+	field, ok := lookupFilterField(c.Field)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", c.Field)
+	}
+	if !slices.Contains(FilterOperators, c.Operator) {
+		return nil, fmt.Errorf("unknown operator %q", c.Operator)
+	}
+	if !slices.Contains(field.Operators, c.Operator) {
+		return nil, fmt.Errorf("operator %s is not supported for %s", c.Operator, field.Name)
+	}
+
+	switch c.Operator {
+	case "IN", "NOT IN":
+		list, ok := stringList(c.Value)
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%s on %s requires a non-empty list of strings", c.Operator, field.Name)
+		}
+		return list, nil
+	case "BETWEEN":
+		bounds, ok := numberPair(c.Value)
+		if !ok {
+			return nil, fmt.Errorf("BETWEEN on %s requires [min, max] numbers", field.Name)
+		}
+		if bounds[0] > bounds[1] {
+			return nil, fmt.Errorf("BETWEEN on %s: min %v is greater than max %v", field.Name, bounds[0], bounds[1])
+		}
+		return bounds, nil
+	case "IS NULL", "IS NOT NULL":
+		if c.Value != nil {
+			return nil, fmt.Errorf("%s takes no value; pass null", c.Operator)
+		}
+		return nil, nil
+	case "top_pct", "bottom_pct":
+		pct, ok := number(c.Value)
+		if !ok || pct <= 0 || pct > 100 {
+			return nil, fmt.Errorf("%s on %s requires a percentage above 0 and at most 100", c.Operator, field.Name)
+		}
+		return pct, nil
+	}
+
+	switch field.Type {
+	case TypeNumber:
+		n, ok := number(c.Value)
+		if !ok {
+			return nil, fmt.Errorf("value for %s must be a number", field.Name)
+		}
+		return n, nil
+	default:
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value for %s must be a string", field.Name)
+		}
+		return s, nil
+	}
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// numberPair accepts a two-element list of numbers
+func numberPair(v any) ([2]float64, bool) {
+	var pair [2]float64
+	var items []any
+	switch list := v.(type) {
+	case [2]float64:
+		return list, true
+	case []any:
+		items = list
+	case []float64:
+		for _, f := range list {
+			items = append(items, f)
+		}
+	}
+	if len(items) != 2 {
+		return pair, false
+	}
+	for i, item := range items {
+		n, ok := number(item)
+		if !ok {
+			return pair, false
+		}
+		pair[i] = n
+	}
+	return pair, true
+}
+
+// stringList accepts []string and the []any of strings JSON decoding produces
+func stringList(v any) ([]string, bool) {
+	switch list := v.(type) {
+	case []string:
+		return list, true
+	case []any:
+		result := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			result[i] = s
+		}
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,319 @@
+package screener
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// ValuationInputs are the company figures available to valuation models.
+// Amounts other than Price are in the statements' reporting currency.
+type ValuationInputs struct {
+	// Price is quoted in the security's currency, which may differ from the
+	// statements' or be its minor unit, such as GBX for GBP
+	Price float64
+	// MarketPrice is Price in the statements' currency. Values can't be
+	// compared with the price without it.
+	MarketPrice float64
+	EPS         float64
+	// GrowthRate is the estimated annual earnings growth
+	GrowthRate float64
+	// BondYield is the AAA corporate bond yield in percent, as Graham used it
+	BondYield float64
+	// Dividend is the dividend per share paid over the last year
+	Dividend       float64
+	DividendGrowth float64
+	Beta           float64
+	// Years are the fiscal years' statements, newest first
+	Years []FinancialYear
+}
+
+// ValuationModel estimates a stock's intrinsic value per share
+type ValuationModel interface {
+	// Name identifies the model in the valuations table and field names
+	Name() string
+	Label() string
+	// Value returns the value per share in the statements' currency and the
+	// inputs it was derived from
+	Value(in ValuationInputs) (float64, any, error)
+}
+
+// Valuation is one model's value of a ticker on a price date. Models that
+// can't value the company store the error instead.
+type Valuation struct {
+	Ticker         string          `json:"ticker"`
+	Model          string          `json:"model"`
+	AsOf           string          `json:"as_of"`
+	Price          float64         `json:"price"`
+	IntrinsicValue *float64        `json:"intrinsic_value"`
+	MarginOfSafety *float64        `json:"margin_of_safety"`
+	Inputs         json.RawMessage `json:"inputs,omitempty"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// Model names become part of field names and SQL, so they are restricted
+var valuationModelName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// valuationModels run for each ticker during the update, in order
+var valuationModels = DefaultValuationModels()
+
+// DefaultValuationModels returns the built-in models with their default
+// settings
+func DefaultValuationModels() []ValuationModel {
+	return []ValuationModel{
+		GrahamModel{},
+		DCFModel{Params: DefaultDCFParams()},
+		DividendDiscountModel{RiskFreeRate: 0.044, EquityRiskPremium: 0.055, MaxGrowth: 0.06},
+		EVEBITDAModel{Multiple: 10},
+		PEGModel{FairPEG: 1, MaxGrowth: 0.25},
+	}
+}
+
+// ValuationModels returns the registered models
+func ValuationModels() []ValuationModel {
+	return slices.Clone(valuationModels)
+}
+
+// RegisterValuationModel adds a model, or replaces the model of the same name
+// such as to configure it, and registers its screener fields. It must happen
+// during startup, before any update or screening.
+func RegisterValuationModel(m ValuationModel) error {
+	if !valuationModelName.MatchString(m.Name()) {
+		return fmt.Errorf("invalid valuation model name %q", m.Name())
+	}
+	for _, f := range valuationFields([]ValuationModel{m}) {
+		if err := RegisterField(f); err != nil {
+			return err
+		}
+	}
+
+	i := slices.IndexFunc(valuationModels, func(existing ValuationModel) bool { return existing.Name() == m.Name() })
+	if i >= 0 {
+		valuationModels[i] = m
+	} else {
+		valuationModels = append(valuationModels, m)
+	}
+	return nil
+}
+
+// valuationFields exposes each model's latest intrinsic value and margin of
+// safety as screener fields, e.g. dcf_margin_of_safety
+func valuationFields(models []ValuationModel) []Field {
+	var result []Field
+	for _, m := range models {
+		latest := func(column string) string {
+			return fmt.Sprintf("(SELECT v.%s FROM valuations v WHERE v.ticker = f.ticker AND v.model = '%s' ORDER BY v.as_of DESC LIMIT 1)",
+				column, m.Name())
+		}
+		result = append(result,
+			Field{Name: m.Name() + "_intrinsic_value", Label: m.Label() + " value",
+				Description: fmt.Sprintf("Intrinsic value per share from the %s model", m.Label()),
+				Table:       "valuations", Expr: latest("intrinsic_value"), Type: TypeNumber, Unit: UnitCurrency, Operators: numberOperators},
+			Field{Name: m.Name() + "_margin_of_safety", Label: m.Label() + " margin of safety",
+				Description: fmt.Sprintf("Discount of the price to the %s value", m.Label()),
+				Table:       "valuations", Expr: latest("margin_of_safety"), Type: TypeNumber, Unit: UnitPercent, Operators: numberOperators},
+		)
+	}
+	return result
+}
+
+// RunValuations values the ticker with every registered model and stores the
+// results under asOf. Values are stored in the currency the price is quoted
+// in, so that they compare with it and convert like it.
+func RunValuations(db *sql.DB, ticker, asOf string, in ValuationInputs) ([]Valuation, error) {
+	result := make([]Valuation, 0, len(valuationModels))
+	for _, m := range valuationModels {
+		v := Valuation{Ticker: ticker, Model: m.Name(), AsOf: asOf, Price: in.Price}
+
+		value, inputs, err := m.Value(in)
+		switch {
+		case err != nil:
+			v.Error = err.Error()
+		case in.MarketPrice <= 0:
+			v.Error = "no price in the statements' currency"
+		default:
+			quoted := value * in.Price / in.MarketPrice
+			margin := CalculateMarginOfSafety(quoted, in.Price)
+			v.IntrinsicValue, v.MarginOfSafety = &quoted, &margin
+		}
+		if inputs != nil {
+			if v.Inputs, err = json.Marshal(inputs); err != nil {
+				return nil, fmt.Errorf("encoding %s inputs: %w", m.Name(), err)
+			}
+		}
+
+		if err := SaveValuation(db, v); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// SaveValuation stores a valuation, replacing one for the same date
+func SaveValuation(db *sql.DB, v Valuation) error {
+	var inputs any
+	if v.Inputs != nil {
+		inputs = string(v.Inputs)
+	}
+	_, err := db.Exec(`
+		INSERT INTO valuations (ticker, model, as_of, price, intrinsic_value, margin_of_safety, inputs, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT(ticker, model, as_of) DO UPDATE SET
+			price = excluded.price, intrinsic_value = excluded.intrinsic_value,
+			margin_of_safety = excluded.margin_of_safety, inputs = excluded.inputs, error = excluded.error`,
+		v.Ticker, v.Model, v.AsOf, v.Price, v.IntrinsicValue, v.MarginOfSafety, inputs, v.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("saving %s valuation: %w", v.Model, err)
+	}
+	return nil
+}
+
+// LatestValuations returns each model's most recent valuation of the ticker
+func LatestValuations(db *sql.DB, ticker string) ([]Valuation, error) {
+	rows, err := db.Query(`
+		SELECT v.ticker, v.model, v.as_of, v.price, v.intrinsic_value, v.margin_of_safety,
+		       COALESCE(v.inputs, ''), COALESCE(v.error, '')
+		FROM valuations v
+		WHERE v.ticker = ? AND v.as_of = (
+			SELECT MAX(latest.as_of) FROM valuations latest
+			WHERE latest.ticker = v.ticker AND latest.model = v.model
+		)
+		ORDER BY v.model`, ticker)
+	if err != nil {
+		return nil, fmt.Errorf("querying valuations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Valuation{}
+	for rows.Next() {
+		var v Valuation
+		var inputs string
+		if err := rows.Scan(&v.Ticker, &v.Model, &v.AsOf, &v.Price, &v.IntrinsicValue, &v.MarginOfSafety, &inputs, &v.Error); err != nil {
+			return nil, fmt.Errorf("scanning valuation: %w", err)
+		}
+		if inputs != "" {
+			v.Inputs = json.RawMessage(inputs)
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// GrahamModel is Benjamin Graham's revised formula, EPS × (8.5 + 2g) scaled by
+// the bond yield
+type GrahamModel struct{}
+
+func (GrahamModel) Name() string  { return "graham" }
+func (GrahamModel) Label() string { return "Graham" }
+
+func (GrahamModel) Value(in ValuationInputs) (float64, any, error) {
+	inputs := map[string]float64{"eps": in.EPS, "growth_rate": in.GrowthRate, "bond_yield": in.BondYield}
+	value, err := CalculateIntrinsicValue(in.EPS, in.GrowthRate, in.BondYield)
+	return value, inputs, err
+}
+
+// DCFModel discounts the latest fiscal year's free cash flow
+type DCFModel struct {
+	Params DCFParams
+}
+
+func (DCFModel) Name() string  { return "dcf" }
+func (DCFModel) Label() string { return "DCF" }
+
+func (m DCFModel) Value(in ValuationInputs) (float64, any, error) {
+	if len(in.Years) == 0 {
+		return 0, nil, errors.New("no financial statements")
+	}
+	inputs := DCFInputsFromYear(in.Years[0], in.MarketPrice, in.Beta, in.GrowthRate)
+	result, err := CalculateDCF(inputs, m.Params)
+	if err != nil {
+		return 0, inputs, err
+	}
+	return result.ValuePerShare, result, nil
+}
+
+// DividendDiscountModel is the Gordon growth model: next year's dividend over
+// the CAPM cost of equity less dividend growth
+type DividendDiscountModel struct {
+	RiskFreeRate      float64
+	EquityRiskPremium float64
+	// MaxGrowth caps dividend growth, which must stay below the cost of equity
+	MaxGrowth float64
+}
+
+func (DividendDiscountModel) Name() string  { return "ddm" }
+func (DividendDiscountModel) Label() string { return "Dividend discount" }
+
+func (m DividendDiscountModel) Value(in ValuationInputs) (float64, any, error) {
+	beta := in.Beta
+	if beta <= 0 {
+		beta = 1
+	}
+	costOfEquity := m.RiskFreeRate + beta*m.EquityRiskPremium
+	growth := math.Max(0, math.Min(in.DividendGrowth, m.MaxGrowth))
+	inputs := map[string]float64{"dividend": in.Dividend, "growth_rate": growth, "cost_of_equity": costOfEquity}
+
+	if in.Dividend <= 0 {
+		return 0, inputs, errors.New("no dividend paid")
+	}
+	if costOfEquity <= growth {
+		return 0, inputs, errors.New("cost of equity must exceed dividend growth")
+	}
+	return in.Dividend * (1 + growth) / (costOfEquity - growth), inputs, nil
+}
+
+// EVEBITDAModel values the enterprise at a multiple of EBITDA and subtracts
+// net debt
+type EVEBITDAModel struct {
+	Multiple float64
+}
+
+func (EVEBITDAModel) Name() string  { return "ev_ebitda" }
+func (EVEBITDAModel) Label() string { return "EV/EBITDA multiple" }
+
+func (m EVEBITDAModel) Value(in ValuationInputs) (float64, any, error) {
+	if len(in.Years) == 0 {
+		return 0, nil, errors.New("no financial statements")
+	}
+	y := in.Years[0]
+	ebitda := y.EBITDA()
+	netDebt := y.TotalDebt() - y.Cash
+	inputs := map[string]float64{"ebitda": ebitda, "multiple": m.Multiple, "net_debt": netDebt, "shares_outstanding": y.SharesOutstanding}
+
+	if ebitda <= 0 || y.SharesOutstanding <= 0 {
+		return 0, inputs, errors.New("positive EBITDA and shares outstanding are required")
+	}
+	equity := m.Multiple*ebitda - netDebt
+	if equity <= 0 {
+		return 0, inputs, errors.New("net debt exceeds enterprise value")
+	}
+	return equity / y.SharesOutstanding, inputs, nil
+}
+
+// PEGModel prices earnings at the P/E that gives a fair PEG ratio, so a PEG
+// of 1 values a company growing 12% a year at 12 times earnings
+type PEGModel struct {
+	FairPEG float64
+	// MaxGrowth caps the growth rate the P/E is derived from
+	MaxGrowth float64
+}
+
+func (PEGModel) Name() string  { return "peg" }
+func (PEGModel) Label() string { return "PEG" }
+
+func (m PEGModel) Value(in ValuationInputs) (float64, any, error) {
+	growth := math.Min(in.GrowthRate, m.MaxGrowth)
+	fairPE := m.FairPEG * growth * 100
+	inputs := map[string]float64{"eps": in.EPS, "growth_rate": growth, "fair_pe": fairPE}
+
+	if in.EPS <= 0 || growth <= 0 {
+		return 0, inputs, errors.New("positive EPS and growth are required")
+	}
+	return in.EPS * fairPE, inputs, nil
+}
@@ -0,0 +1,231 @@
+package screener
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestValuationModels(t *testing.T) {
+	in := ValuationInputs{
+		Price: 50, EPS: 4, GrowthRate: 0.08, BondYield: 4.4, Dividend: 2, DividendGrowth: 0.03, Beta: 1,
+		Years: []FinancialYear{{EBIT: 90, Depreciation: -10, ShortTermDebt: 50, LongTermDebt: 250, Cash: 100, SharesOutstanding: 20}},
+	}
+
+	tests := []struct {
+		model ValuationModel
+		want  float64
+	}{
+		{GrahamModel{}, 4 * (8.5 + 2*0.08)},
+		// 2 × 1.03 / (4.4% + 5.5% − 3%)
+		{DividendDiscountModel{RiskFreeRate: 0.044, EquityRiskPremium: 0.055, MaxGrowth: 0.06}, 2.06 / 0.069},
+		// (10 × 100 EBITDA − 200 net debt) / 20 shares
+		{EVEBITDAModel{Multiple: 10}, 40},
+		{PEGModel{FairPEG: 1, MaxGrowth: 0.25}, 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model.Name(), func(t *testing.T) {
+			got, inputs, err := tt.model.Value(in)
+			if err != nil {
+				t.Fatalf("Value failed: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+			if inputs == nil {
+				t.Error("Expected the inputs used")
+			}
+		})
+	}
+
+	if _, _, err := (DividendDiscountModel{}).Value(ValuationInputs{}); err == nil {
+		t.Error("Expected the dividend discount model to reject non-payers")
+	}
+	if _, _, err := (DCFModel{Params: DefaultDCFParams()}).Value(ValuationInputs{}); err == nil {
+		t.Error("Expected the DCF model to require statements")
+	}
+}
+
+// failingModel can't value anything
+type failingModel struct{}
+
+func (failingModel) Name() string  { return "failing" }
+func (failingModel) Label() string { return "Failing" }
+func (failingModel) Value(ValuationInputs) (float64, any, error) {
+	return 0, nil, errors.New("no data")
+}
+
+func TestRunValuations(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	saved := valuationModels
+	defer func() { valuationModels = saved }()
+	valuationModels = []ValuationModel{PEGModel{FairPEG: 1, MaxGrowth: 0.25}, failingModel{}}
+
+	if _, err := RunValuations(db, "KO", "2024-01-12", ValuationInputs{Price: 40, MarketPrice: 40, EPS: 2, GrowthRate: 0.10}); err != nil {
+		t.Fatalf("RunValuations failed: %v", err)
+	}
+	valuations, err := RunValuations(db, "KO", "2024-01-15", ValuationInputs{Price: 48.75, MarketPrice: 48.75, EPS: 2.5, GrowthRate: 0.10})
+	if err != nil {
+		t.Fatalf("RunValuations failed: %v", err)
+	}
+	if len(valuations) != 2 || *valuations[0].IntrinsicValue != 25 || valuations[1].Error != "no data" {
+		t.Errorf("Unexpected valuations: %+v", valuations)
+	}
+
+	latest, err := LatestValuations(db, "KO")
+	if err != nil {
+		t.Fatalf("LatestValuations failed: %v", err)
+	}
+	if len(latest) != 2 {
+		t.Fatalf("Expected the latest valuation of each model, got %+v", latest)
+	}
+	for _, v := range latest {
+		if v.AsOf != "2024-01-15" {
+			t.Errorf("Expected %s valued on 2024-01-15, got %s", v.Model, v.AsOf)
+		}
+	}
+	if failed := latest[0]; failed.Model != "failing" || failed.IntrinsicValue != nil || failed.Error != "no data" {
+		t.Errorf("Expected the failing model's error, got %+v", failed)
+	}
+	if peg := latest[1]; string(peg.Inputs) == "" || math.Abs(*peg.MarginOfSafety-(25-48.75)/25) > 1e-9 {
+		t.Errorf("Expected PEG inputs and margin of safety, got %+v", peg)
+	}
+}
+
+func TestRunValuationsAcrossCurrencies(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	saved := valuationModels
+	defer func() { valuationModels = saved }()
+	valuationModels = []ValuationModel{EVEBITDAModel{Multiple: 10}}
+
+	// Quoted at 3800 pence, reporting in pounds: 40 GBP per share is a 5%
+	// discount, not a 95-fold premium
+	in := ValuationInputs{Price: 3800, MarketPrice: 38,
+		Years: []FinancialYear{{EBIT: 90, Depreciation: -10, ShortTermDebt: 50, LongTermDebt: 250, Cash: 100, SharesOutstanding: 20}}}
+	valuations, err := RunValuations(db, "ULVR.LSE", "2024-01-15", in)
+	if err != nil {
+		t.Fatalf("RunValuations failed: %v", err)
+	}
+	v := valuations[0]
+	if v.IntrinsicValue == nil || math.Abs(*v.IntrinsicValue-4000) > 1e-9 || math.Abs(*v.MarginOfSafety-0.05) > 1e-9 {
+		t.Errorf("Expected 4000 pence and a 5%% margin, got %+v", v)
+	}
+
+	// Without the price in the statements' currency nothing is compared
+	in.MarketPrice = 0
+	valuations, err = RunValuations(db, "ULVR.LSE", "2024-01-15", in)
+	if err != nil {
+		t.Fatalf("RunValuations failed: %v", err)
+	}
+	if v := valuations[0]; v.IntrinsicValue != nil || v.Error == "" {
+		t.Errorf("Expected no value without a converted price, got %+v", v)
+	}
+}
+
+func TestScreenValuationMarginOfSafety(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, v := range []Valuation{
+		{Ticker: "KO", Model: "dcf", AsOf: "2024-01-12", Price: 48, IntrinsicValue: ptr(50.0), MarginOfSafety: ptr(0.04)},
+		{Ticker: "KO", Model: "dcf", AsOf: "2024-01-15", Price: 48.75, IntrinsicValue: ptr(65.0), MarginOfSafety: ptr(0.25)},
+		{Ticker: "TSLA", Model: "dcf", AsOf: "2024-01-15", Price: 220.45, IntrinsicValue: ptr(110.0), MarginOfSafety: ptr(-1.0)},
+		{Ticker: "IBM", Model: "graham", AsOf: "2024-01-15", Price: 78.2, IntrinsicValue: ptr(120.0), MarginOfSafety: ptr(0.35)},
+	} {
+		if err := SaveValuation(db, v); err != nil {
+			t.Fatalf("SaveValuation failed: %v", err)
+		}
+	}
+
+	rows, err := ScreenRows(db, ScreenerFilter{
+		Conditions: []FilterCondition{{Field: "dcf_margin_of_safety", Operator: ">", Value: 0.2}},
+		Fields:     []string{"ticker", "dcf_intrinsic_value", "graham_intrinsic_value"},
+		Sort:       "dcf_margin_of_safety.desc",
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatalf("ScreenRows failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["ticker"] != "KO" || rows[0]["dcf_intrinsic_value"] != 65.0 || rows[0]["graham_intrinsic_value"] != nil {
+		t.Errorf("Expected only KO at its latest DCF value, got %v", rows)
+	}
+}
+
+func TestRegisterValuationModel(t *testing.T) {
+	savedModels, savedFields := valuationModels, fields
+	defer func() { valuationModels, fields = savedModels, savedFields }()
+	valuationModels, fields = slices.Clone(valuationModels), slices.Clone(fields)
+
+	params := DefaultDCFParams()
+	params.DiscountRate = 0.09
+	if err := RegisterValuationModel(DCFModel{Params: params}); err != nil {
+		t.Fatalf("Replacing the DCF model failed: %v", err)
+	}
+	if len(valuationModels) != len(savedModels) || valuationModels[1].(DCFModel).Params.DiscountRate != 0.09 {
+		t.Errorf("Expected the DCF model to be replaced in place, got %v", valuationModels)
+	}
+
+	if err := RegisterValuationModel(failingModel{}); err != nil {
+		t.Fatalf("RegisterValuationModel failed: %v", err)
+	}
+	if _, ok := LookupField("failing_margin_of_safety"); !ok {
+		t.Error("Expected the new model's margin of safety to be a field")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestGetStock(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	v := Valuation{Ticker: "IBM", Model: "graham", AsOf: "2024-01-15", Price: 78.2, IntrinsicValue: ptr(120.0), MarginOfSafety: ptr(0.35),
+		Inputs: []byte(`{"eps":9.6}`)}
+	if err := SaveValuation(db, v); err != nil {
+		t.Fatalf("SaveValuation failed: %v", err)
+	}
+
+	stock, err := GetStock(db, "IBM")
+	if err != nil {
+		t.Fatalf("GetStock failed: %v", err)
+	}
+	if stock.Fields["pe_ratio"] != 8.3 || stock.Fields["graham_margin_of_safety"] != 0.35 {
+		t.Errorf("Expected every field, got %v", stock.Fields)
+	}
+	if len(stock.Valuations) != 1 || string(stock.Valuations[0].Inputs) != `{"eps":9.6}` {
+		t.Errorf("Expected the Graham valuation with its inputs, got %+v", stock.Valuations)
+	}
+
+	if _, err := GetStock(db, "NOPE"); !errors.Is(err, ErrTickerNotFound) {
+		t.Errorf("Expected ErrTickerNotFound, got %v", err)
+	}
+}
+
+func TestBondYieldAsOf(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if got := bondYieldAsOf(db, "2024-01-15"); got != fallbackBondYield {
+		t.Errorf("Expected the fallback yield without macro data, got %v", got)
+	}
+
+	_, err := db.Exec(`
+		CREATE TABLE macro_series (series TEXT, date TEXT, value REAL, PRIMARY KEY (series, date));
+		INSERT INTO macro_series VALUES ('US10Y.GBOND', '2020-08-04', 0.51), ('US10Y.GBOND', '2023-10-19', 4.99);`)
+	if err != nil {
+		t.Fatalf("Failed to insert yields: %v", err)
+	}
+	if got := bondYieldAsOf(db, "2021-01-04"); got != 0.51 {
+		t.Errorf("Expected the 2020 yield, got %v", got)
+	}
+	if got := bondYieldAsOf(db, "2024-01-15"); got != 4.99 {
+		t.Errorf("Expected the 2023 yield, got %v", got)
+	}
+}
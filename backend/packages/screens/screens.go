@@ -0,0 +1,358 @@
+package screens
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+// ErrNotFound is returned when a screen or screen version does not exist
+var ErrNotFound = errors.New("screen not found")
+
+// Screen is a named, saved screener configuration ("strategy")
+type Screen struct {
+	ID          int64           `json:"id"`
+	UserID      int64           `json:"-"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Filters     json.RawMessage `json:"filters"`
+	Sort        string          `json:"sort"`
+	Columns     []string        `json:"columns"`
+	Version     int             `json:"version"`
+	// ShareToken is set while the screen is shared, and shown only to its
+	// owner
+	ShareToken string `json:"share_token,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// ScreenVersion is a historical snapshot of a screen's definition
+type ScreenVersion struct {
+	ScreenID    int64           `json:"screen_id"`
+	Version     int             `json:"version"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Filters     json.RawMessage `json:"filters"`
+	Sort        string          `json:"sort"`
+	Columns     []string        `json:"columns"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// Validate checks that the screen has a name, a parseable filter definition,
+// known sort keys and known columns
+func (s *Screen) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("name is required")
+	}
+	if _, err := screener.ParseFilterFromJSON(s.filtersJSON()); err != nil {
+		return err
+	}
+	if s.Sort != "" {
+		if _, err := screener.ParseSort(s.Sort); err != nil {
+			return err
+		}
+	}
+	if len(s.Columns) > 0 {
+		if _, err := screener.ParseFields(s.Columns); err != nil {
+			return fmt.Errorf("invalid columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// ScreenerFilter converts the saved screen into a filter ready for ScreenStocks,
+// or for ScreenRows with the saved columns selected
+func (s *Screen) ScreenerFilter(limit, offset int) (screener.ScreenerFilter, error) {
+	filter, err := screener.ParseFilterFromJSON(s.filtersJSON())
+	if err != nil {
+		return screener.ScreenerFilter{}, err
+	}
+	if s.Sort != "" {
+		filter.Sort = s.Sort
+	}
+	filter.Fields = s.Columns
+	filter.Limit = limit
+	filter.Offset = offset
+	return filter, nil
+}
+
+// filtersJSON returns the filters as a string, treating JSON null as no filters
+func (s *Screen) filtersJSON() string {
+	raw := strings.TrimSpace(string(s.Filters))
+	if raw == "null" {
+		return ""
+	}
+	return raw
+}
+
+// CreateScreen stores a new screen owned by userID as version 1. It is not
+// shared until ShareScreen is called.
+func CreateScreen(db *sql.DB, userID int64, s Screen) (Screen, error) {
+	columns, err := json.Marshal(nonNilColumns(s.Columns))
+	if err != nil {
+		return Screen{}, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Screen{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO screens (user_id, name, description, filters, sort, columns, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, 1, datetime('now'), datetime('now'))`,
+		userID, s.Name, s.Description, s.filtersJSON(), s.Sort, string(columns),
+	)
+	if err != nil {
+		return Screen{}, fmt.Errorf("failed to insert screen: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Screen{}, err
+	}
+
+	if err := insertVersion(tx, id); err != nil {
+		return Screen{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Screen{}, err
+	}
+
+	return GetScreen(db, userID, id)
+}
+
+// GetScreen returns the current version of a screen owned by userID
+func GetScreen(db *sql.DB, userID, id int64) (Screen, error) {
+	return scanScreen(db.QueryRow(selectScreen+" WHERE id = ? AND user_id = ?", id, userID))
+}
+
+// GetScreenByShareToken returns the screen a share link points to
+func GetScreenByShareToken(db *sql.DB, token string) (Screen, error) {
+	return scanScreen(db.QueryRow(selectScreen+" WHERE share_token = ?", token))
+}
+
+// ShareScreen gives a screen owned by userID a new share token, replacing
+// any earlier one so that old links stop working
+func ShareScreen(db *sql.DB, userID, id int64) (Screen, error) {
+	token, err := newShareToken()
+	if err != nil {
+		return Screen{}, err
+	}
+	if err := setShareToken(db, userID, id, token); err != nil {
+		return Screen{}, err
+	}
+	return GetScreen(db, userID, id)
+}
+
+// UnshareScreen revokes a screen's share token
+func UnshareScreen(db *sql.DB, userID, id int64) error {
+	return setShareToken(db, userID, id, nil)
+}
+
+func setShareToken(db *sql.DB, userID, id int64, token any) error {
+	res, err := db.Exec(`UPDATE screens SET share_token = ? WHERE id = ? AND user_id = ?`, token, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update share token: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListScreens returns the user's saved screens ordered by name
+func ListScreens(db *sql.DB, userID int64) ([]Screen, error) {
+	rows, err := db.Query(selectScreen+" WHERE user_id = ? ORDER BY name ASC, id ASC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	screens := []Screen{}
+	for rows.Next() {
+		s, err := scanScreen(rows)
+		if err != nil {
+			return nil, err
+		}
+		screens = append(screens, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return screens, nil
+}
+
+// UpdateScreen replaces a screen's definition and records it as a new version
+func UpdateScreen(db *sql.DB, userID, id int64, s Screen) (Screen, error) {
+	columns, err := json.Marshal(nonNilColumns(s.Columns))
+	if err != nil {
+		return Screen{}, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Screen{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE screens
+		SET name = ?, description = ?, filters = ?, sort = ?, columns = ?,
+		    version = version + 1, updated_at = datetime('now')
+		WHERE id = ? AND user_id = ?`,
+		s.Name, s.Description, s.filtersJSON(), s.Sort, string(columns), id, userID,
+	)
+	if err != nil {
+		return Screen{}, fmt.Errorf("failed to update screen: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Screen{}, ErrNotFound
+	}
+
+	if err := insertVersion(tx, id); err != nil {
+		return Screen{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Screen{}, err
+	}
+
+	return GetScreen(db, userID, id)
+}
+
+// DeleteScreen removes a screen together with its version history
+func DeleteScreen(db *sql.DB, userID, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM screens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete screen: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM screen_versions WHERE screen_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete screen versions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListScreenVersions returns the version history of a screen, newest first
+func ListScreenVersions(db *sql.DB, userID, id int64) ([]ScreenVersion, error) {
+	rows, err := db.Query(`
+		SELECT v.screen_id, v.version, v.name, v.description, v.filters, v.sort, v.columns, v.created_at
+		FROM screen_versions v
+		JOIN screens s ON s.id = v.screen_id
+		WHERE v.screen_id = ? AND s.user_id = ?
+		ORDER BY v.version DESC`, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []ScreenVersion{}
+	for rows.Next() {
+		var v ScreenVersion
+		var filters, columns string
+		if err := rows.Scan(&v.ScreenID, &v.Version, &v.Name, &v.Description, &filters, &v.Sort, &columns, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		v.Filters = rawFilters(filters)
+		if err := json.Unmarshal([]byte(columns), &v.Columns); err != nil {
+			return nil, fmt.Errorf("invalid columns for screen %d: %w", id, err)
+		}
+		versions = append(versions, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return versions, nil
+}
+
+const selectScreen = `
+	SELECT id, user_id, name, description, filters, sort, columns, version, share_token, created_at, updated_at
+	FROM screens`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScreen(row rowScanner) (Screen, error) {
+	var s Screen
+	var filters, columns string
+	var token sql.NullString
+	err := row.Scan(&s.ID, &s.UserID, &s.Name, &s.Description, &filters, &s.Sort, &columns, &s.Version, &token, &s.CreatedAt, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Screen{}, ErrNotFound
+	}
+	if err != nil {
+		return Screen{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+
+	s.ShareToken = token.String
+	s.Filters = rawFilters(filters)
+	if err := json.Unmarshal([]byte(columns), &s.Columns); err != nil {
+		return Screen{}, fmt.Errorf("invalid columns for screen %d: %w", s.ID, err)
+	}
+
+	return s, nil
+}
+
+// insertVersion snapshots the current state of a screen into screen_versions
+func insertVersion(tx *sql.Tx, id int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO screen_versions (screen_id, version, name, description, filters, sort, columns, created_at)
+		SELECT id, version, name, description, filters, sort, columns, datetime('now')
+		FROM screens WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to insert screen version: %w", err)
+	}
+	return nil
+}
+
+func rawFilters(filters string) json.RawMessage {
+	if filters == "" {
+		return json.RawMessage("[]")
+	}
+	return json.RawMessage(filters)
+}
+
+func nonNilColumns(columns []string) []string {
+	if columns == nil {
+		return []string{}
+	}
+	return columns
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,165 @@
+package screens
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testUserID = 1
+
+func setupTestDB(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := db.MigrateDatabaseFromFile(conn, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	return conn
+}
+
+func TestScreenLifecycle(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	created, err := CreateScreen(conn, testUserID, Screen{
+		Name:    "Value",
+		Filters: json.RawMessage(`[["pe_ratio","<",15]]`),
+		Sort:    "pe_ratio.asc",
+		Columns: []string{"ticker", "pe_ratio"},
+	})
+	if err != nil {
+		t.Fatalf("CreateScreen failed: %v", err)
+	}
+
+	if created.Version != 1 {
+		t.Errorf("Expected version 1, got %d", created.Version)
+	}
+	if created.ShareToken != "" {
+		t.Error("Expected a new screen not to be shared")
+	}
+	created, err = ShareScreen(conn, testUserID, created.ID)
+	if err != nil || created.ShareToken == "" {
+		t.Fatalf("ShareScreen failed: %v, %+v", err, created)
+	}
+
+	updated, err := UpdateScreen(conn, testUserID, created.ID, Screen{
+		Name:    "Deep Value",
+		Filters: json.RawMessage(`[["pe_ratio","<",10]]`),
+		Sort:    "roe.desc",
+	})
+	if err != nil {
+		t.Fatalf("UpdateScreen failed: %v", err)
+	}
+
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2, got %d", updated.Version)
+	}
+	if updated.ShareToken != created.ShareToken {
+		t.Error("Expected share token to survive updates")
+	}
+
+	if _, err := GetScreen(conn, testUserID+1, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected another user's screen to be hidden, got %v", err)
+	}
+	if _, err := ShareScreen(conn, testUserID+1, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected another user's screen not to be shareable, got %v", err)
+	}
+
+	shared, err := GetScreenByShareToken(conn, created.ShareToken)
+	if err != nil {
+		t.Fatalf("GetScreenByShareToken failed: %v", err)
+	}
+	if shared.Name != "Deep Value" {
+		t.Errorf("Expected shared screen to be the latest version, got %s", shared.Name)
+	}
+
+	// Sharing again replaces the link, and unsharing revokes it
+	reshared, err := ShareScreen(conn, testUserID, created.ID)
+	if err != nil || reshared.ShareToken == created.ShareToken {
+		t.Fatalf("Expected a new share token, got %v, %+v", err, reshared)
+	}
+	if _, err := GetScreenByShareToken(conn, created.ShareToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the old link revoked, got %v", err)
+	}
+	if err := UnshareScreen(conn, testUserID, created.ID); err != nil {
+		t.Fatalf("UnshareScreen failed: %v", err)
+	}
+	if _, err := GetScreenByShareToken(conn, reshared.ShareToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the link revoked, got %v", err)
+	}
+	if s, err := GetScreen(conn, testUserID, created.ID); err != nil || s.ShareToken != "" {
+		t.Errorf("Expected no share token after unsharing, got %v, %+v", err, s)
+	}
+
+	versions, err := ListScreenVersions(conn, testUserID, created.ID)
+	if err != nil {
+		t.Fatalf("ListScreenVersions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[1].Name != "Value" {
+		t.Errorf("Expected 2 versions with the original name preserved, got %+v", versions)
+	}
+
+	filter, err := updated.ScreenerFilter(25, 50)
+	if err != nil {
+		t.Fatalf("ScreenerFilter failed: %v", err)
+	}
+	if len(filter.Conditions) != 1 || filter.Sort != "roe.desc" || filter.Limit != 25 || filter.Offset != 50 {
+		t.Errorf("Unexpected filter: %+v", filter)
+	}
+
+	if err := DeleteScreen(conn, testUserID, created.ID); err != nil {
+		t.Fatalf("DeleteScreen failed: %v", err)
+	}
+	if _, err := GetScreen(conn, testUserID, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestScreenValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		screen      Screen
+		expectError bool
+	}{
+		{"valid", Screen{Name: "Value", Filters: json.RawMessage(`[["pe_ratio","<",15]]`)}, false},
+		{"no filters", Screen{Name: "All"}, false},
+		{"missing name", Screen{Filters: json.RawMessage(`[]`)}, true},
+		{"invalid filters", Screen{Name: "Broken", Filters: json.RawMessage(`[["pe_ratio","<"]]`)}, true},
+		{"multi-key sort", Screen{Name: "Yield", Sort: "dividend_yield.desc,pe_ratio.asc"}, false},
+		{"unknown sort", Screen{Name: "Broken", Sort: "volume.desc"}, true},
+		{"columns", Screen{Name: "Trend", Columns: []string{"ticker", "upside", "close/sma200"}}, false},
+		{"unknown column", Screen{Name: "Broken", Columns: []string{"ticker", "volume"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.screen.Validate()
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestScreenerFilterSelectsColumns(t *testing.T) {
+	s := Screen{Name: "Trend", Sort: "upside.desc", Columns: []string{"ticker", "upside"}}
+	filter, err := s.ScreenerFilter(10, 20)
+	if err != nil {
+		t.Fatalf("ScreenerFilter failed: %v", err)
+	}
+	if len(filter.Fields) != 2 || filter.Fields[1] != "upside" || filter.Sort != "upside.desc" || filter.Limit != 10 || filter.Offset != 20 {
+		t.Errorf("Expected the saved columns, sort and page, got %+v", filter)
+	}
+}
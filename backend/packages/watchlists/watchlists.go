@@ -0,0 +1,252 @@
+package watchlists
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/finsights-ai/backend/packages/screener"
+)
+
+var (
+	// ErrNotFound is returned when a watchlist does not exist
+	ErrNotFound = errors.New("watchlist not found")
+	// ErrItemNotFound is returned when a ticker is not on the watchlist
+	ErrItemNotFound = errors.New("watchlist item not found")
+)
+
+// Watchlist is a named list of tickers a user keeps an eye on
+type Watchlist struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+	Items       []WatchlistItem `json:"items,omitempty"`
+}
+
+// WatchlistItem is a ticker on a watchlist together with the latest screener metrics
+type WatchlistItem struct {
+	Ticker      string                   `json:"ticker"`
+	Notes       string                   `json:"notes"`
+	TargetPrice *float64                 `json:"target_price"`
+	AddedAt     string                   `json:"added_at"`
+	Metrics     *screener.ScreenerResult `json:"metrics"`
+}
+
+// NormalizeTicker trims and upper-cases a ticker symbol
+func NormalizeTicker(ticker string) string {
+	return strings.ToUpper(strings.TrimSpace(ticker))
+}
+
+// CreateWatchlist stores a new, empty watchlist owned by userID
+func CreateWatchlist(db *sql.DB, userID int64, name, description string) (Watchlist, error) {
+	res, err := db.Exec(`
+		INSERT INTO watchlists (user_id, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, datetime('now'), datetime('now'))`,
+		userID, name, description,
+	)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to insert watchlist: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	return getWatchlistRow(db, userID, id)
+}
+
+// ListWatchlists returns the user's watchlists without their items
+func ListWatchlists(db *sql.DB, userID int64) ([]Watchlist, error) {
+	rows, err := db.Query(`
+		SELECT id, name, description, created_at, updated_at
+		FROM watchlists
+		WHERE user_id = ?
+		ORDER BY name ASC, id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []Watchlist{}
+	for rows.Next() {
+		var wl Watchlist
+		if err := rows.Scan(&wl.ID, &wl.Name, &wl.Description, &wl.CreatedAt, &wl.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("row scanning failed: %w", err)
+		}
+		lists = append(lists, wl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	return lists, nil
+}
+
+// GetWatchlist returns a watchlist with every item joined to its latest screener metrics
+func GetWatchlist(db *sql.DB, userID, id int64) (Watchlist, error) {
+	wl, err := getWatchlistRow(db, userID, id)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	rows, err := db.Query(`
+		SELECT ticker, notes, target_price, added_at
+		FROM watchlist_items
+		WHERE watchlist_id = ?
+		ORDER BY ticker ASC`, id)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	wl.Items = []WatchlistItem{}
+	tickers := []string{}
+	for rows.Next() {
+		var item WatchlistItem
+		var target sql.NullFloat64
+		if err := rows.Scan(&item.Ticker, &item.Notes, &target, &item.AddedAt); err != nil {
+			return Watchlist{}, fmt.Errorf("row scanning failed: %w", err)
+		}
+		if target.Valid {
+			item.TargetPrice = &target.Float64
+		}
+		wl.Items = append(wl.Items, item)
+		tickers = append(tickers, item.Ticker)
+	}
+
+	if err := rows.Err(); err != nil {
+		return Watchlist{}, fmt.Errorf("row iteration failed: %w", err)
+	}
+
+	if len(tickers) == 0 {
+		return wl, nil
+	}
+
+	// Join with the screener results for all members in one query
+	filter := screener.NewFilterBuilder().TickerIn(tickers).BuildWithPagination("ticker.asc", 0, 0)
+	results, err := screener.ScreenStocks(db, filter)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	byTicker := make(map[string]screener.ScreenerResult, len(results))
+	for _, r := range results {
+		byTicker[r.Ticker] = r
+	}
+	for i := range wl.Items {
+		if r, ok := byTicker[wl.Items[i].Ticker]; ok {
+			wl.Items[i].Metrics = &r
+		}
+	}
+
+	return wl, nil
+}
+
+// UpdateWatchlist renames a watchlist or changes its description
+func UpdateWatchlist(db *sql.DB, userID, id int64, name, description string) (Watchlist, error) {
+	res, err := db.Exec(`
+		UPDATE watchlists
+		SET name = ?, description = ?, updated_at = datetime('now')
+		WHERE id = ? AND user_id = ?`,
+		name, description, id, userID,
+	)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("failed to update watchlist: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Watchlist{}, ErrNotFound
+	}
+
+	return getWatchlistRow(db, userID, id)
+}
+
+// DeleteWatchlist removes a watchlist and all of its items
+func DeleteWatchlist(db *sql.DB, userID, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM watchlists WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM watchlist_items WHERE watchlist_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete watchlist items: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SaveItem adds a ticker to a watchlist or updates its notes and target price
+func SaveItem(db *sql.DB, userID, id int64, ticker, notes string, targetPrice *float64) error {
+	if _, err := getWatchlistRow(db, userID, id); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO watchlist_items (watchlist_id, ticker, notes, target_price, added_at)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT (watchlist_id, ticker) DO UPDATE
+		SET notes = excluded.notes, target_price = excluded.target_price`,
+		id, NormalizeTicker(ticker), notes, targetPrice,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save watchlist item: %w", err)
+	}
+
+	return touchWatchlist(db, id)
+}
+
+// RemoveItem removes a ticker from a watchlist
+func RemoveItem(db *sql.DB, userID, id int64, ticker string) error {
+	if _, err := getWatchlistRow(db, userID, id); err != nil {
+		return err
+	}
+
+	res, err := db.Exec(`
+		DELETE FROM watchlist_items WHERE watchlist_id = ? AND ticker = ?`,
+		id, NormalizeTicker(ticker),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove watchlist item: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrItemNotFound
+	}
+
+	return touchWatchlist(db, id)
+}
+
+func getWatchlistRow(db *sql.DB, userID, id int64) (Watchlist, error) {
+	var wl Watchlist
+	err := db.QueryRow(`
+		SELECT id, name, description, created_at, updated_at
+		FROM watchlists WHERE id = ? AND user_id = ?`, id, userID,
+	).Scan(&wl.ID, &wl.Name, &wl.Description, &wl.CreatedAt, &wl.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Watchlist{}, ErrNotFound
+	}
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("row scanning failed: %w", err)
+	}
+	return wl, nil
+}
+
+func touchWatchlist(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE watchlists SET updated_at = datetime('now') WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,98 @@
+package watchlists
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/finsights-ai/backend/packages/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testUserID = 1
+
+func setupTestDB(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := db.MigrateDatabaseFromFile(conn, "../screener/schema.sql"); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	testData := `
+		INSERT INTO fundamentals (ticker, pe_ratio, roe, earnings_outlook, dividend_yield, dividend_growth_5y, intrinsic_value, margin_of_safety) VALUES
+		('KO', 9.7, 0.16, 'positive', 0.045, 0.08, 65.0, 0.25),
+		('PFE', 7.8, 0.12, 'positive', 0.055, 0.10, 55.0, 0.30);
+
+		INSERT INTO prices (ticker, date, close, sma50, sma200) VALUES
+		('KO', '2024-01-14', 48.00, 52.00, 55.00),
+		('KO', '2024-01-15', 48.75, 52.20, 55.50),
+		('PFE', '2024-01-15', 42.15, 45.20, 48.90);
+	`
+	if _, err := conn.Exec(testData); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	return conn
+}
+
+func TestGetWatchlistJoinsLatestMetrics(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	wl, err := CreateWatchlist(conn, testUserID, "Dividends", "")
+	if err != nil {
+		t.Fatalf("CreateWatchlist failed: %v", err)
+	}
+
+	target := 45.0
+	if err := SaveItem(conn, testUserID, wl.ID, " ko ", "buy the dip", &target); err != nil {
+		t.Fatalf("SaveItem failed: %v", err)
+	}
+	if err := SaveItem(conn, testUserID, wl.ID, "UNKNOWN", "", nil); err != nil {
+		t.Fatalf("SaveItem failed: %v", err)
+	}
+
+	got, err := GetWatchlist(conn, testUserID, wl.ID)
+	if err != nil {
+		t.Fatalf("GetWatchlist failed: %v", err)
+	}
+
+	if len(got.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(got.Items))
+	}
+
+	ko := got.Items[0]
+	if ko.Ticker != "KO" || ko.Metrics == nil {
+		t.Fatalf("Expected KO with metrics, got %+v", ko)
+	}
+	if ko.Metrics.Close != 48.75 {
+		t.Errorf("Expected latest close 48.75, got %v", ko.Metrics.Close)
+	}
+	if ko.TargetPrice == nil || *ko.TargetPrice != 45.0 {
+		t.Errorf("Expected target price 45, got %v", ko.TargetPrice)
+	}
+
+	if got.Items[1].Metrics != nil {
+		t.Errorf("Expected no metrics for unknown ticker, got %+v", got.Items[1].Metrics)
+	}
+
+	if err := RemoveItem(conn, testUserID, wl.ID, "unknown"); err != nil {
+		t.Fatalf("RemoveItem failed: %v", err)
+	}
+	if err := RemoveItem(conn, testUserID, wl.ID, "unknown"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Expected ErrItemNotFound, got %v", err)
+	}
+}
+
+func TestSaveItemUnknownWatchlist(t *testing.T) {
+	conn := setupTestDB(t)
+	defer conn.Close()
+
+	if err := SaveItem(conn, testUserID, 42, "KO", "", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -82,7 +82,13 @@ export async function loader({
     apiUrl.searchParams.set("page", page.toString());
     apiUrl.searchParams.set("limit", limit.toString());
 
-    const response = await fetch(apiUrl.toString());
+    // The backend requires authentication; the server-side loader uses an API key
+    const headers: HeadersInit = {};
+    if (process.env.FINSIGHTS_API_KEY) {
+      headers["X-API-Key"] = process.env.FINSIGHTS_API_KEY;
+    }
+
+    const response = await fetch(apiUrl.toString(), { headers });
 
     if (!response.ok) {
       throw new Error(`API Error: ${response.status}`);